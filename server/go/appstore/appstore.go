@@ -0,0 +1,409 @@
+// Package appstore 是 DooTask 应用商店（AppStore）的客户端：浏览应用市场，
+// 安装、升级、卸载应用，查看日志与容器，上传本地应用压缩包。
+//
+// AppStore 是独立的微服务，由主程序反代在 /appstore/api/v1，响应格式为 {code,message,data}。
+// 客户端复用主程序 dootask.Client 的 Token、传输层、中间件与重试策略，并以主程序版本作为
+// Version 请求头，供 AppStore 校验应用的 require_version。
+//
+//	store := appstore.New(dootask.NewClient(token, dootask.WithTimeout(5*time.Minute)))
+//	detail, err := store.Get("ai")
+//	err = store.Install(detail.Merge(appstore.InstallRequest{AppID: "ai", Version: "latest"}))
+package appstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// BasePath AppStore 接口在主程序中的反代路径
+const BasePath = "/appstore/api/v1"
+
+// Error AppStore 接口错误：code != 200，或响应不是 {code,message,data} 结构
+type Error struct {
+	Code       int    // 业务状态码（响应不是标准结构时为 0）
+	Message    string // 错误信息（响应不是标准结构时为响应体文本）
+	StatusCode int    // HTTP 状态码
+	Method     string // 请求方法
+	Path       string // 请求接口，相对 BasePath，如 /internal/install
+}
+
+// Error 实现 error 接口；业务错误直接返回服务端信息
+func (e *Error) Error() string {
+	if e.Code == 0 && e.StatusCode != http.StatusOK {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+	}
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("appstore error: %d", e.Code)
+}
+
+// Is 按状态码匹配 dootask 的哨兵错误，例如 errors.Is(err, dootask.ErrNotFound)
+func (e *Error) Is(target error) bool {
+	code := e.Code
+	if code == 0 {
+		code = e.StatusCode
+	}
+	switch {
+	case code == http.StatusUnauthorized:
+		return target == dootask.ErrUnauthorized
+	case code == http.StatusForbidden:
+		return target == dootask.ErrPermissionDenied
+	case code == http.StatusNotFound:
+		return target == dootask.ErrNotFound
+	case code == http.StatusTooManyRequests:
+		return target == dootask.ErrRateLimited
+	case code >= http.StatusInternalServerError:
+		return target == dootask.ErrServerError
+	}
+	return false
+}
+
+// Option Client 选项
+type Option func(*Client)
+
+// WithMainVersion 指定主程序版本作为 Version 请求头，不再调用 GetVersion 获取
+func WithMainVersion(version string) Option {
+	return func(c *Client) {
+		c.version.value = version
+	}
+}
+
+// Client AppStore 客户端，可并发使用
+type Client struct {
+	client  *dootask.Client
+	version *mainVersion
+}
+
+const (
+	// versionRetryInterval 获取主程序版本失败后，再次尝试前的间隔
+	versionRetryInterval = 30 * time.Second
+	// versionFetchTimeout 获取主程序版本的超时，不受调用方 ctx 取消影响
+	versionFetchTimeout = 10 * time.Second
+)
+
+// mainVersion 缓存主程序版本，获取失败后间隔 versionRetryInterval 再重试
+type mainVersion struct {
+	mu       sync.Mutex
+	value    string
+	failedAt time.Time // 最近一次获取失败的时间
+}
+
+// New 基于主程序客户端创建 AppStore 客户端。安装、卸载会执行 docker compose，
+// 耗时可能较长，主程序客户端的超时（WithTimeout）应留足时间
+func New(client *dootask.Client, opts ...Option) *Client {
+	c := &Client{client: client, version: &mainVersion{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithContext 返回绑定 ctx 的客户端浅拷贝（共享主程序版本缓存）
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c2 := *c
+	c2.client = c.client.WithContext(ctx)
+	return &c2
+}
+
+// mainVersion 返回主程序版本，获取失败时返回空串（不发送 Version 头）。
+// 获取版本时不持锁，慢请求不会阻塞其它调用；版本缓存由所有 WithContext 副本共享，
+// 因此以脱离调用方取消的 ctx 获取，调用方 ctx 取消导致的失败也不计入重试间隔
+func (c *Client) mainVersion() string {
+	c.version.mu.Lock()
+	value, failedAt := c.version.value, c.version.failedAt
+	c.version.mu.Unlock()
+	if value != "" || (!failedAt.IsZero() && time.Since(failedAt) < versionRetryInterval) {
+		return value
+	}
+
+	ctx := c.client.Context()
+	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), versionFetchTimeout)
+	defer cancel()
+	v, err := c.client.WithContext(fetchCtx).GetVersion()
+	c.version.mu.Lock()
+	defer c.version.mu.Unlock()
+	if err != nil || v == nil || v.Version == "" {
+		if !isContextError(err) || ctx.Err() == nil {
+			c.version.failedAt = time.Now()
+		}
+		return c.version.value
+	}
+	c.version.value = v.Version
+	return v.Version
+}
+
+// isContextError 是否为 ctx 取消或超时导致的错误
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// ------------------------------------------------------------------------------------------
+// 应用市场
+// ------------------------------------------------------------------------------------------
+
+// Catalog 获取应用市场中可安装的应用
+func (c *Client) Catalog() ([]App, error) {
+	var response []App
+	if err := c.Request("GET", "/list", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// CatalogAll 获取全部应用，包括已安装的社区应用
+func (c *Client) CatalogAll() ([]App, error) {
+	var response []App
+	if err := c.Request("GET", "/list", url.Values{"include": {"all"}}, nil, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Upgradeable 获取已安装且有新版本可升级的应用（与网页「可升级」标记同源）
+func (c *Client) Upgradeable() ([]App, error) {
+	apps, err := c.CatalogAll()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]App, 0)
+	for _, app := range apps {
+		if app.Upgradeable {
+			result = append(result, app)
+		}
+	}
+	return result, nil
+}
+
+// Get 获取应用详情与安装参数定义
+func (c *Client) Get(appID string) (*AppDetail, error) {
+	var response AppDetail
+	if err := c.Request("GET", "/one/"+url.PathEscape(appID), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Refresh 刷新应用市场列表（拉取远程源），需管理员权限
+func (c *Client) Refresh() error {
+	return c.Request("GET", "/internal/apps/update", nil, nil, nil)
+}
+
+// ------------------------------------------------------------------------------------------
+// 安装管理（需管理员权限）
+// ------------------------------------------------------------------------------------------
+
+// Installed 获取已安装的应用
+func (c *Client) Installed() ([]InstalledApp, error) {
+	var response []InstalledApp
+	if err := c.Request("GET", "/internal/installed", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Install 安装应用，对已安装应用即为升级或重装。请求原样提交，
+// 需要沿用当前参数与资源限额时先用 AppDetail.Merge 补齐
+func (c *Client) Install(req InstallRequest) error {
+	if req.AppID == "" {
+		return errors.New("appstore: install: app id is required")
+	}
+	if req.Version == "" {
+		req.Version = "latest"
+	}
+	return c.Request("POST", "/internal/install", nil, req, nil)
+}
+
+// Update 升级已安装的应用，未指定的参数与资源限额沿用当前配置；应用未安装时返回错误
+func (c *Client) Update(req InstallRequest) error {
+	detail, err := c.Get(req.AppID)
+	if err != nil {
+		return err
+	}
+	if !detail.Config.Installed() {
+		return fmt.Errorf("appstore: update: app %q is not installed", req.AppID)
+	}
+	return c.Install(detail.Merge(req))
+}
+
+// Uninstall 卸载应用，deleteData 为 true 时同时删除应用数据（不可恢复）
+func (c *Client) Uninstall(appID string, deleteData bool) error {
+	var query url.Values
+	if deleteData {
+		query = url.Values{"delete_data": {"true"}}
+	}
+	return c.Request("GET", "/internal/uninstall/"+url.PathEscape(appID), query, nil, nil)
+}
+
+// Remove 删除社区应用（需先卸载，仅 community_ 开头的应用）
+func (c *Client) Remove(appID string) error {
+	return c.Request("GET", "/internal/remove/"+url.PathEscape(appID), nil, nil, nil)
+}
+
+// Logs 获取应用的安装与运行日志，lines 为 0 时使用服务端默认行数
+func (c *Client) Logs(appID string, lines int) (json.RawMessage, error) {
+	var response json.RawMessage
+	err := c.Request("GET", "/internal/log/"+url.PathEscape(appID), linesQuery(nil, lines), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Containers 获取应用的容器与服务
+func (c *Client) Containers(appID string) (json.RawMessage, error) {
+	var response json.RawMessage
+	if err := c.Request("GET", "/internal/containers/"+url.PathEscape(appID), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ContainerLogs 获取应用某个服务容器的日志，lines 为 0 时使用服务端默认行数
+func (c *Client) ContainerLogs(appID, service string, lines int) (json.RawMessage, error) {
+	if service == "" {
+		return nil, errors.New("appstore: container logs: service is required")
+	}
+	var response json.RawMessage
+	query := linesQuery(url.Values{"service": {service}}, lines)
+	if err := c.Request("GET", "/internal/containers/"+url.PathEscape(appID)+"/logs", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// linesQuery 在 lines > 0 时设置日志行数参数 n
+func linesQuery(query url.Values, lines int) url.Values {
+	if lines > 0 {
+		if query == nil {
+			query = url.Values{}
+		}
+		query.Set("n", strconv.Itoa(lines))
+	}
+	return query
+}
+
+// Upload 上传本地应用压缩包（.zip、.tar.gz）导入应用市场，返回应用ID。
+// 只做导入与合规校验，不会部署，导入后用 Install 安装。内容从 r 流式读取，上传不会重试
+func (c *Client) Upload(params UploadRequest, r io.Reader) (string, error) {
+	const path = "/internal/apps/upload"
+	if params.Name == "" {
+		return "", errors.New("appstore: upload: name is required")
+	}
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeUpload(mw, params, r))
+	}()
+
+	var response struct {
+		ID string `json:"id"`
+	}
+	if err := c.send("POST", path, nil, pr, mw.FormDataContentType(), &response); err != nil {
+		return "", err
+	}
+	return response.ID, nil
+}
+
+// writeUpload 写入上传表单：appid 与文件内容
+func writeUpload(mw *multipart.Writer, params UploadRequest, r io.Reader) error {
+	if params.AppID != "" {
+		if err := mw.WriteField("appid", params.AppID); err != nil {
+			return err
+		}
+	}
+	part, err := mw.CreateFormFile("file", params.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return fmt.Errorf("read upload content: %w", err)
+	}
+	return mw.Close()
+}
+
+// ------------------------------------------------------------------------------------------
+// 请求
+// ------------------------------------------------------------------------------------------
+
+// Request 调用 AppStore 接口，用于尚未封装的接口。path 相对 BasePath；
+// body 非 nil 时以 JSON 提交；out 非 nil 时把 data 解析到 out
+func (c *Client) Request(method, path string, query url.Values, body, out any) error {
+	if body == nil {
+		return c.send(method, path, query, nil, "", out)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request data failed: %w", err)
+	}
+	return c.send(method, path, query, bytes.NewReader(data), "application/json", out)
+}
+
+// send 发送请求并解析 {code,message,data} 响应
+func (c *Client) send(method, path string, query url.Values, body io.Reader, contentType string, out any) error {
+	ctx := c.client.Context()
+	fullURL := strings.TrimRight(c.client.Server(), "/") + BasePath + path
+	if len(query) > 0 {
+		fullURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	// AppStore 按主程序版本校验 require_version，缺省会被当作 1.0.0
+	if v := c.mainVersion(); v != "" {
+		req.Header.Set("Version", v)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return &dootask.TransportError{Method: method, Endpoint: BasePath + path, Err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return &dootask.TransportError{Method: method, Endpoint: BasePath + path, Err: fmt.Errorf("read response failed: %w", err)}
+	}
+	var response struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return &Error{Message: strings.TrimSpace(string(data)), StatusCode: resp.StatusCode, Method: method, Path: path}
+	}
+	if response.Code != http.StatusOK {
+		return &Error{Code: response.Code, Message: strings.TrimSpace(response.Message), StatusCode: resp.StatusCode, Method: method, Path: path}
+	}
+	if out != nil && len(response.Data) > 0 {
+		if err := json.Unmarshal(response.Data, out); err != nil {
+			return fmt.Errorf("unmarshal response data failed: %w", err)
+		}
+	}
+	return nil
+}
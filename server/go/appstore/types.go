@@ -0,0 +1,160 @@
+package appstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+)
+
+// 应用安装状态
+const (
+	StatusInstalled = "installed" // 已安装
+)
+
+// App 应用市场中的应用（GET /list）
+type App struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Version     string   `json:"version"`
+	Tags        []string `json:"tags"`
+	Versions    []string `json:"versions"`    // 可安装的版本，按版本降序
+	Upgradeable bool     `json:"upgradeable"` // 已安装且有更新的版本可升级
+	Config      Config   `json:"config"`
+}
+
+// LatestVersion 返回可安装的最新版本，没有版本信息时返回空串
+func (a App) LatestVersion() string {
+	if len(a.Versions) == 0 {
+		return ""
+	}
+	return a.Versions[0]
+}
+
+// Config 应用的安装配置
+type Config struct {
+	Status         string         `json:"status"`          // 安装状态，已安装为 installed
+	InstallVersion string         `json:"install_version"` // 已安装的版本
+	Params         map[string]any `json:"params"`          // 已安装时的安装参数
+	Resources      Resources      `json:"resources"`       // 资源限额
+}
+
+// Installed 应用是否已安装
+func (c Config) Installed() bool {
+	return c.Status == StatusInstalled
+}
+
+// Resources 资源限额，空值表示使用应用默认值
+type Resources struct {
+	CPULimit    string `json:"cpu_limit,omitempty"`    // CPU 限额，如 1.0
+	MemoryLimit string `json:"memory_limit,omitempty"` // 内存限额，如 512M、2G
+}
+
+// UnmarshalJSON 兼容服务端以数字返回的限额
+func (r *Resources) UnmarshalJSON(data []byte) error {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	str := func(v any) string {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	}
+	r.CPULimit = str(raw["cpu_limit"])
+	r.MemoryLimit = str(raw["memory_limit"])
+	return nil
+}
+
+// merge 用 fallback 补齐未设置的限额
+func (r Resources) merge(fallback Resources) Resources {
+	if r.CPULimit == "" {
+		r.CPULimit = fallback.CPULimit
+	}
+	if r.MemoryLimit == "" {
+		r.MemoryLimit = fallback.MemoryLimit
+	}
+	return r
+}
+
+// AppDetail 应用详情（GET /one/:id），含安装参数定义
+type AppDetail struct {
+	App
+	Fields []Field `json:"fields"`
+}
+
+// Field 安装参数定义（label、description 已按当前语种展开）
+type Field struct {
+	Name        string        `json:"name"`
+	Label       string        `json:"label"`
+	Type        string        `json:"type"` // input、select、password、textarea 等
+	Required    bool          `json:"required"`
+	Default     any           `json:"default"`
+	Description string        `json:"description"`
+	Options     []FieldOption `json:"options"`
+}
+
+// HasDefault 字段是否有非空默认值（必填字段未提供时由服务端兜底）
+func (f Field) HasDefault() bool {
+	return f.Default != nil && fmt.Sprint(f.Default) != ""
+}
+
+// FieldOption 选择类参数的可选项
+type FieldOption struct {
+	Label string `json:"label"`
+	Value any    `json:"value"`
+}
+
+// Field 按名称查找安装参数定义
+func (d *AppDetail) Field(name string) (*Field, bool) {
+	for i := range d.Fields {
+		if d.Fields[i].Name == name {
+			return &d.Fields[i], true
+		}
+	}
+	return nil, false
+}
+
+// Merge 以应用当前配置补齐安装请求（sticky，与网页表单「初值即当前值」一致）：
+// 已安装时以当前参数为底叠加 req.Params，未指定资源限额时沿用当前限额；
+// 只指定部分限额时，其余限额取当前配置
+func (d *AppDetail) Merge(req InstallRequest) InstallRequest {
+	if d.Config.Installed() && len(d.Config.Params) > 0 {
+		params := maps.Clone(d.Config.Params)
+		maps.Copy(params, req.Params)
+		req.Params = params
+	}
+	switch {
+	case req.Resources != nil:
+		merged := req.Resources.merge(d.Config.Resources)
+		req.Resources = &merged
+	case d.Config.Installed() && d.Config.Resources != (Resources{}):
+		current := d.Config.Resources
+		req.Resources = &current
+	}
+	return req
+}
+
+// InstalledApp 已安装的应用（GET /internal/installed）
+type InstalledApp struct {
+	ID        string `json:"id"`
+	Version   string `json:"version"`
+	Status    string `json:"status"`
+	InstallAt string `json:"install_at"`
+}
+
+// InstallRequest 安装或升级应用（对已安装应用再次安装即为升级或重装）
+type InstallRequest struct {
+	AppID     string         `json:"appid"`
+	Version   string         `json:"version"`             // 版本号，缺省为 latest
+	PullImage bool           `json:"pull_image"`          // 操作前先拉取镜像
+	Params    map[string]any `json:"params,omitempty"`    // 安装参数
+	Resources *Resources     `json:"resources,omitempty"` // 资源限额，nil 表示使用应用默认值
+}
+
+// UploadRequest 上传本地应用压缩包
+type UploadRequest struct {
+	Name  string // 文件名（必填），AppID 为空时服务端从文件名提取应用ID
+	AppID string // 应用ID，如 myapp-1.0.0.zip 提取为 myapp，与目标ID不一致时需指定
+}
@@ -0,0 +1,295 @@
+package dootask
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------------------------
+// 登录与验证码
+// ------------------------------------------------------------------------------------------
+
+// LoginResult 登录结果：用户信息与新 token
+type LoginResult struct {
+	UserInfo
+	Token string `json:"token"`
+}
+
+// Captcha 登录验证码
+type Captcha struct {
+	Key       string `json:"key"`       // 验证码标识，提交时作为 code_key
+	Image     string `json:"img"`       // 验证码图片（data URI）
+	Sensitive bool   `json:"sensitive"` // 是否区分大小写
+}
+
+// ImageBytes 解码验证码图片，返回图片内容与 MIME 类型（如 image/png）
+func (c *Captcha) ImageBytes() ([]byte, string, error) {
+	meta, data, ok := strings.Cut(strings.TrimPrefix(c.Image, "data:"), ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return nil, "", errors.New("captcha image is not a base64 data uri")
+	}
+	img, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("decode captcha image failed: %w", err)
+	}
+	return img, strings.TrimSuffix(meta, ";base64"), nil
+}
+
+// Login 用邮箱密码登录。需要验证码时返回 ErrCaptchaRequired，可改用 LoginWithCaptcha；
+// 返回的 token 不会替换客户端自身的 token，需要时用它创建新客户端
+func (c *Client) Login(email, password string) (*LoginResult, error) {
+	return c.login(email, password, "", "")
+}
+
+// GetLoginCaptcha 获取登录验证码
+func (c *Client) GetLoginCaptcha() (*Captcha, error) {
+	var response Captcha
+	if err := c.NewGetRequest("/api/users/login/codejson", nil, &response); err != nil {
+		return nil, err
+	}
+	if response.Key == "" {
+		return nil, errors.New("get login captcha: empty captcha key")
+	}
+	return &response, nil
+}
+
+// LoginWithCaptcha 获取验证码并交给 solve 识别（如展示图片让用户输入），再带上答案登录。
+// solve 返回错误时放弃登录并返回该错误
+func (c *Client) LoginWithCaptcha(email, password string, solve func(*Captcha) (string, error)) (*LoginResult, error) {
+	if solve == nil {
+		return nil, errors.New("login with captcha: solve is required")
+	}
+	captcha, err := c.GetLoginCaptcha()
+	if err != nil {
+		return nil, err
+	}
+	code, err := solve(captcha)
+	if err != nil {
+		return nil, err
+	}
+	return c.login(email, password, code, captcha.Key)
+}
+
+// login 调用登录接口，code 与 codeKey 为空时不带验证码
+func (c *Client) login(email, password, code, codeKey string) (*LoginResult, error) {
+	if email == "" || password == "" {
+		return nil, errors.New("login: email and password are required")
+	}
+	params := map[string]any{
+		"type":     "login",
+		"email":    email,
+		"password": password,
+	}
+	if code != "" || codeKey != "" {
+		params["code"] = code
+		params["code_key"] = codeKey
+	}
+
+	var response struct {
+		LoginResult
+		Code string `json:"code"`
+	}
+	if err := c.NewGetRequest("/api/users/login", params, &response); err != nil {
+		return nil, err
+	}
+	if response.Token == "" {
+		if response.Code == "need" {
+			return nil, fmt.Errorf("login: %w", ErrCaptchaRequired)
+		}
+		return nil, errors.New("login: response has no token")
+	}
+	return &response.LoginResult, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// Token 有效期
+// ------------------------------------------------------------------------------------------
+
+// TokenExpire token 有效期
+type TokenExpire struct {
+	ExpiredAt        string `json:"expired_at"`        // 过期时间（服务器时区），永久有效时为空
+	RemainingSeconds int64  `json:"remaining_seconds"` // 剩余秒数
+	IsExpired        bool   `json:"is_expired"`        // 是否已过期
+}
+
+// ExpiresIn 返回剩余有效时长，已过期时为 0；永久有效时返回 -1
+func (e *TokenExpire) ExpiresIn() time.Duration {
+	switch {
+	case e.IsExpired:
+		return 0
+	case e.ExpiredAt == "" && e.RemainingSeconds <= 0:
+		return -1
+	}
+	return time.Duration(max(e.RemainingSeconds, 0)) * time.Second
+}
+
+// ExpiresWithin 是否已过期或将在 d 内过期，用于提前刷新 token
+func (e *TokenExpire) ExpiresWithin(d time.Duration) bool {
+	in := e.ExpiresIn()
+	return in >= 0 && in <= d
+}
+
+// GetTokenExpire 查询当前 token 的有效期；token 已失效时返回 ErrUnauthorized
+func (c *Client) GetTokenExpire() (*TokenExpire, error) {
+	var response TokenExpire
+	if err := c.NewGetRequest("/api/users/token/expire", nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// Token 来源
+// ------------------------------------------------------------------------------------------
+
+// TokenSource 提供并刷新 token。配合 WithTokenSource 使用：请求返回 ErrUnauthorized 时，
+// 客户端调用 Refresh 换取新 token 并重试一次
+type TokenSource interface {
+	// Token 返回当前可用的 token，客户端在没有 token 时调用
+	Token(ctx context.Context) (string, error)
+	// Refresh 在 expired 失效后返回新的 token
+	Refresh(ctx context.Context, expired string) (string, error)
+}
+
+// StaticTokenSource 返回固定 token 的来源，无法刷新
+func StaticTokenSource(token string) TokenSource {
+	return staticTokenSource(token)
+}
+
+type staticTokenSource string
+
+func (s staticTokenSource) Token(context.Context) (string, error) {
+	if s == "" {
+		return "", ErrUnauthorized
+	}
+	return string(s), nil
+}
+
+func (s staticTokenSource) Refresh(context.Context, string) (string, error) {
+	return "", fmt.Errorf("static token cannot be refreshed: %w", ErrUnauthorized)
+}
+
+// LoginTokenSource 用邮箱密码登录获取 token，失效后重新登录。opts 用于创建登录所用的
+// 客户端（如 WithServer）；需要验证码时 Token 与 Refresh 返回 ErrCaptchaRequired
+func LoginTokenSource(email, password string, opts ...ClientOption) TokenSource {
+	return &loginTokenSource{client: NewClient("", opts...), email: email, password: password}
+}
+
+type loginTokenSource struct {
+	client   *Client
+	email    string
+	password string
+}
+
+func (s *loginTokenSource) Token(ctx context.Context) (string, error) {
+	result, err := s.client.WithContext(ctx).Login(s.email, s.password)
+	if err != nil {
+		return "", err
+	}
+	return result.Token, nil
+}
+
+func (s *loginTokenSource) Refresh(ctx context.Context, _ string) (string, error) {
+	return s.Token(ctx)
+}
+
+// WithTokenSource 从 src 获取 token，并在请求返回 ErrUnauthorized 时刷新 token 后重试一次。
+// NewClient 传入的 token 非空时作为初始 token；WithContext 的副本共享同一 token
+func WithTokenSource(src TokenSource) ClientOption {
+	return func(c *Client) {
+		c.tokens = &tokenState{src: src}
+	}
+}
+
+// Token 返回客户端当前使用的 token
+func (c *Client) Token() string {
+	if c.tokens != nil {
+		return c.tokens.current()
+	}
+	return c.token
+}
+
+// resolveToken 返回本次请求将使用的 token，设置了 TokenSource 且没有 token 时先获取
+func (c *Client) resolveToken(ctx context.Context) (string, error) {
+	if c.tokens == nil {
+		return c.token, nil
+	}
+	token, err := c.tokens.get(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get token failed: %w", err)
+	}
+	return token, nil
+}
+
+// authorized 以 token 执行 send 并返回最后使用的 token，所有请求路径共用。
+// 设置了 TokenSource 时先确保有 token；send 返回 ErrUnauthorized 时刷新 token，
+// 请求可重放（replayable）时以新 token 重试一次，不可重放时只刷新（下次请求生效）
+func (c *Client) authorized(ctx context.Context, replayable bool, send func(token string) error) (string, error) {
+	token, err := c.resolveToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	err = send(token)
+	if c.tokens == nil || !errors.Is(err, ErrUnauthorized) {
+		return token, err
+	}
+	fresh, refreshErr := c.tokens.refresh(ctx, token)
+	if refreshErr != nil {
+		return token, fmt.Errorf("%w (refresh token failed: %w)", err, refreshErr)
+	}
+	if !replayable {
+		return token, err
+	}
+	return fresh, send(fresh)
+}
+
+// tokenState 由 TokenSource 维护的 token，刷新期间持锁以合并并发刷新
+type tokenState struct {
+	src   TokenSource
+	mu    sync.Mutex
+	token string
+}
+
+// current 返回当前 token，可能为空
+func (s *tokenState) current() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// get 返回当前 token，没有时向来源获取
+func (s *tokenState) get(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == "" {
+		token, err := s.src.Token(ctx)
+		if err != nil {
+			return "", err
+		}
+		s.token = token
+	}
+	return s.token, nil
+}
+
+// refresh 在 expired 失效后刷新 token；其它调用已刷新过时直接返回新 token
+func (s *tokenState) refresh(ctx context.Context, expired string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && s.token != expired {
+		return s.token, nil
+	}
+	token, err := s.src.Refresh(ctx, expired)
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", errors.New("token source returned an empty token")
+	}
+	s.token = token
+	return token, nil
+}
@@ -0,0 +1,350 @@
+// Package bot 是建在 webhook 包之上的机器人命令路由：按命令模式解析参数、
+// 串联中间件、自动生成帮助文本，并为每个对话保存会话状态。
+//
+//	r := bot.NewRouter()
+//	r.Use(bot.Recover())
+//	r.Handle("/deploy <env> [version]", func(c *bot.Context) error {
+//		return c.Reply("部署到 " + c.Arg("env"))
+//	}).Help("部署服务")
+//	http.Handle("/bot/webhook", r.Webhook(webhook.WithClientOptions(dootask.WithServer("http://nginx"))))
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/webhook"
+)
+
+// ErrUsage 参数不符合命令模式，Router 会回复用法说明
+var ErrUsage = errors.New("bot: invalid command usage")
+
+// HandlerFunc 命令处理函数
+type HandlerFunc func(c *Context) error
+
+// Middleware 命令中间件
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Context 一次命令或消息的处理上下文
+type Context struct {
+	context.Context
+
+	Event   *webhook.Event    // 触发的 Webhook 事件
+	Command *Command          // 匹配的命令，普通消息为 nil
+	Args    map[string]string // 命名参数
+	Rest    []string          // 未被模式消费的多余参数
+
+	router *Router
+}
+
+// Arg 返回命名参数，不存在时返回空串
+func (c *Context) Arg(name string) string {
+	return c.Args[name]
+}
+
+// Client 返回以机器人身份调用接口的客户端
+func (c *Context) Client() *dootask.Client {
+	return c.Event.Client()
+}
+
+// Reply 向所在对话回复 Markdown 文本（SendMessage）
+func (c *Context) Reply(text string) error {
+	return c.Event.Reply(text)
+}
+
+// Replyf 格式化后回复
+func (c *Context) Replyf(format string, a ...any) error {
+	return c.Reply(fmt.Sprintf(format, a...))
+}
+
+// ReplyBot 以系统机器人私信发送者（SendBotMessage）
+func (c *Context) ReplyBot(message dootask.SendBotMessageRequest) error {
+	message.UserID = c.Event.Sender.UserID
+	return c.Client().SendBotMessage(message)
+}
+
+// ReplyTemplate 向所在对话发送模板消息（SendTemplateMessage）
+func (c *Context) ReplyTemplate(title string, content ...dootask.TemplateContent) error {
+	return c.Client().SendTemplateMessage(dootask.SendTemplateMessageRequest{
+		DialogID: c.Event.Dialog.ID,
+		Title:    title,
+		Content:  content,
+	})
+}
+
+// State 返回所在对话的会话状态
+func (c *Context) State() *State {
+	return c.router.states.get(c.Event.Dialog.ID)
+}
+
+// Next 把所在对话的下一条非命令消息交给 fn 处理，用于多轮对话
+func (c *Context) Next(fn HandlerFunc) {
+	c.State().setNext(fn)
+}
+
+// Command 已注册的命令
+type Command struct {
+	Name    string // 命令名，如 /deploy
+	Pattern string // 完整模式，如 /deploy <env> [version]
+	help    string
+	params  []param
+	handler HandlerFunc
+}
+
+// Help 设置帮助说明
+func (cmd *Command) Help(text string) *Command {
+	cmd.help = text
+	return cmd
+}
+
+// Usage 返回用法说明
+func (cmd *Command) Usage() string {
+	if cmd.help == "" {
+		return cmd.Pattern
+	}
+	return cmd.Pattern + " — " + cmd.help
+}
+
+// param 命令参数：<name> 必填，[name] 可选，name... 接收剩余全部参数
+type param struct {
+	name     string
+	optional bool
+	variadic bool
+}
+
+// parsePattern 解析命令模式
+func parsePattern(pattern string) (string, []param, error) {
+	fields := strings.Fields(pattern)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil, fmt.Errorf("bot: pattern %q must start with /", pattern)
+	}
+	var params []param
+	for i, f := range fields[1:] {
+		var p param
+		switch {
+		case strings.HasPrefix(f, "<") && strings.HasSuffix(f, ">"):
+			p.name = f[1 : len(f)-1]
+		case strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]"):
+			p.name, p.optional = f[1:len(f)-1], true
+		default:
+			return "", nil, fmt.Errorf("bot: invalid parameter %q in pattern %q", f, pattern)
+		}
+		if name, ok := strings.CutSuffix(p.name, "..."); ok {
+			if i != len(fields)-2 {
+				return "", nil, fmt.Errorf("bot: variadic parameter must be last in pattern %q", pattern)
+			}
+			p.name, p.variadic = name, true
+		}
+		if len(params) > 0 && params[len(params)-1].optional && !p.optional {
+			return "", nil, fmt.Errorf("bot: required parameter after optional in pattern %q", pattern)
+		}
+		params = append(params, p)
+	}
+	return fields[0], params, nil
+}
+
+// bind 按参数定义绑定实参
+func (cmd *Command) bind(args []string) (map[string]string, []string, error) {
+	values := make(map[string]string, len(cmd.params))
+	for i, p := range cmd.params {
+		if i >= len(args) {
+			if !p.optional {
+				return nil, nil, ErrUsage
+			}
+			continue
+		}
+		if p.variadic {
+			values[p.name] = strings.Join(args[i:], " ")
+			return values, nil, nil
+		}
+		values[p.name] = args[i]
+	}
+	if len(args) > len(cmd.params) {
+		return values, args[len(cmd.params):], nil
+	}
+	return values, nil, nil
+}
+
+// Option Router 选项
+type Option func(*Router)
+
+// WithStateTTL 设置会话状态的闲置过期时间，默认 30 分钟
+func WithStateTTL(ttl time.Duration) Option {
+	return func(r *Router) {
+		r.states.ttl = ttl
+	}
+}
+
+// WithUnknownReply 设置未知命令的回复，空串表示不回复
+func WithUnknownReply(text string) Option {
+	return func(r *Router) {
+		r.unknownReply = text
+	}
+}
+
+// Router 机器人命令路由
+type Router struct {
+	commands     []*Command
+	byName       map[string]*Command
+	middlewares  []Middleware
+	fallback     HandlerFunc
+	unknownReply string
+	states       *stateStore
+}
+
+// NewRouter 创建命令路由，默认注册 /help
+func NewRouter(opts ...Option) *Router {
+	r := &Router{
+		byName:       make(map[string]*Command),
+		unknownReply: "未知命令，发送 /help 查看可用命令",
+		states:       newStateStore(30 * time.Minute),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.Handle("/help", func(c *Context) error {
+		return c.Reply(r.HelpText())
+	}).Help("查看可用命令")
+	return r
+}
+
+// Use 追加中间件，先添加的位于外层
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// Handle 注册命令，模式形如 "/deploy <env> [version] [note...]"；同名命令覆盖之前的注册。
+// 模式不合法时 panic
+func (r *Router) Handle(pattern string, fn HandlerFunc) *Command {
+	name, params, err := parsePattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	cmd := &Command{Name: name, Pattern: strings.Join(strings.Fields(pattern), " "), params: params, handler: fn}
+	if old, ok := r.byName[name]; ok {
+		for i, c := range r.commands {
+			if c == old {
+				r.commands[i] = cmd
+			}
+		}
+	} else {
+		r.commands = append(r.commands, cmd)
+	}
+	r.byName[name] = cmd
+	return cmd
+}
+
+// Fallback 设置非命令消息与其它事件的处理函数
+func (r *Router) Fallback(fn HandlerFunc) {
+	r.fallback = fn
+}
+
+// Commands 返回已注册的命令（按注册顺序）
+func (r *Router) Commands() []*Command {
+	return r.commands
+}
+
+// HelpText 生成帮助文本
+func (r *Router) HelpText() string {
+	var b strings.Builder
+	b.WriteString("可用命令：\n")
+	for _, cmd := range r.commands {
+		b.WriteString("\n- `" + cmd.Pattern + "`")
+		if cmd.help != "" {
+			b.WriteString(" " + cmd.help)
+		}
+	}
+	return b.String()
+}
+
+// Webhook 返回接入机器人 Webhook 的 http.Handler
+func (r *Router) Webhook(opts ...webhook.Option) *webhook.Handler {
+	return webhook.NewHandler(r.HandleEvent, opts...)
+}
+
+// HandleEvent 分发 Webhook 事件，可作为 webhook.HandlerFunc 使用
+func (r *Router) HandleEvent(ctx context.Context, e *webhook.Event) error {
+	c := &Context{Context: ctx, Event: e, router: r}
+
+	if e.Type != webhook.EventCommand {
+		// 多轮对话：优先交给上一步登记的处理函数
+		if e.Type == webhook.EventMessage {
+			if next := c.State().takeNext(); next != nil {
+				return r.wrap(next)(c)
+			}
+		}
+		if r.fallback == nil {
+			return nil
+		}
+		return r.wrap(r.fallback)(c)
+	}
+
+	args := splitArgs(e.Message.Text)
+	cmd, ok := r.byName[args[0]]
+	if !ok {
+		if r.unknownReply == "" {
+			return nil
+		}
+		return c.Reply(r.unknownReply)
+	}
+	c.Command = cmd
+	values, rest, err := cmd.bind(args[1:])
+	if err != nil {
+		return c.Reply("用法：`" + cmd.Pattern + "`")
+	}
+	c.Args, c.Rest = values, rest
+
+	err = r.wrap(cmd.handler)(c)
+	if errors.Is(err, ErrUsage) {
+		return c.Reply("用法：`" + cmd.Pattern + "`")
+	}
+	return err
+}
+
+// wrap 用中间件包装处理函数
+func (r *Router) wrap(fn HandlerFunc) HandlerFunc {
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		fn = r.middlewares[i](fn)
+	}
+	return fn
+}
+
+// splitArgs 按空白切分命令文本，支持用双引号或单引号包含空白
+func splitArgs(text string) []string {
+	var args []string
+	var cur strings.Builder
+	var quote rune
+	inArg := false
+	for _, ch := range strings.TrimSpace(text) {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(ch)
+			}
+		case ch == '"' || ch == '\'':
+			quote, inArg = ch, true
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(ch)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	return args
+}
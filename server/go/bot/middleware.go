@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Recover 捕获处理函数中的 panic，记录堆栈并回复错误提示
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) (err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					log.Printf("bot: panic in handler: %v\n%s", v, debug.Stack())
+					err = fmt.Errorf("bot: panic: %v", v)
+					c.Reply("处理失败，请稍后重试")
+				}
+			}()
+			return next(c)
+		}
+	}
+}
+
+// Authorize 执行前调用 check，返回错误时回复「权限不足」并终止
+func Authorize(check func(c *Context) error) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			if err := check(c); err != nil {
+				return c.Reply("权限不足：" + err.Error())
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireUsers 只允许指定用户触发（按推送中的发送者ID判断）。需要按身份等其它条件
+// 控制时用 Authorize 自行校验 c.Event.Sender.UserID，不要用机器人自己的客户端判断
+func RequireUsers(userIDs ...int) Middleware {
+	return Authorize(func(c *Context) error {
+		if !slices.Contains(userIDs, c.Event.Sender.UserID) {
+			return fmt.Errorf("用户 %d 不在允许列表中", c.Event.Sender.UserID)
+		}
+		return nil
+	})
+}
+
+// RateLimit 限制每个发送者在 per 时间窗口内最多触发 n 次，超出时回复提示
+func RateLimit(n int, per time.Duration) Middleware {
+	var mu sync.Mutex
+	var swept time.Time
+	hits := make(map[int][]time.Time)
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			uid := c.Event.Sender.UserID
+			now := time.Now()
+
+			mu.Lock()
+			recent := hits[uid][:0]
+			for _, t := range hits[uid] {
+				if now.Sub(t) < per {
+					recent = append(recent, t)
+				}
+			}
+			allowed := len(recent) < n
+			if allowed {
+				recent = append(recent, now)
+			}
+			if len(recent) == 0 {
+				delete(hits, uid)
+			} else {
+				hits[uid] = recent
+			}
+			// 每个时间窗口清理一次窗口内已无记录的发送者，避免不再发言的用户一直占用内存
+			if now.Sub(swept) >= per {
+				for id, times := range hits {
+					if now.Sub(times[len(times)-1]) >= per {
+						delete(hits, id)
+					}
+				}
+				swept = now
+			}
+			mu.Unlock()
+
+			if !allowed {
+				return c.Reply("操作过于频繁，请稍后再试")
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// State 对话的会话状态，闲置超过 TTL 后清空
+type State struct {
+	mu     sync.Mutex
+	values map[string]any
+	next   HandlerFunc
+	seenAt time.Time
+}
+
+// Get 读取状态值
+func (s *State) Get(key string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Set 写入状态值
+func (s *State) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+// Delete 删除状态值
+func (s *State) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// Clear 清空状态值与待处理的下一步
+func (s *State) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.values)
+	s.next = nil
+}
+
+func (s *State) setNext(fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next = fn
+}
+
+func (s *State) takeNext() HandlerFunc {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn := s.next
+	s.next = nil
+	return fn
+}
+
+// stateStore 按对话ID保存会话状态
+type stateStore struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	states map[int]*State
+}
+
+func newStateStore(ttl time.Duration) *stateStore {
+	return &stateStore{ttl: ttl, states: make(map[int]*State)}
+}
+
+// get 返回对话的状态，不存在或已过期时新建；顺带清理过期状态
+func (st *stateStore) get(dialogID int) *State {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	for id, s := range st.states {
+		if st.ttl > 0 && now.Sub(s.seenAt) > st.ttl {
+			delete(st.states, id)
+		}
+	}
+	s, ok := st.states[dialogID]
+	if !ok {
+		s = &State{values: make(map[string]any)}
+		st.states[dialogID] = s
+	}
+	s.seenAt = now
+	return s
+}
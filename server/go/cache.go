@@ -0,0 +1,173 @@
+package dootask
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------------------------
+// 用户信息缓存
+// ------------------------------------------------------------------------------------------
+
+// defaultCacheSize 默认内存缓存的最大条目数
+const defaultCacheSize = 1000
+
+// Cache 用户信息缓存，键为 token。实现必须并发安全
+type Cache interface {
+	Get(key string) (UserInfo, bool)                  // 获取未过期的缓存
+	Set(key string, user UserInfo, ttl time.Duration) // 写入缓存，ttl 后过期
+	Delete(key string)                                // 删除缓存
+}
+
+// WithCache 使用自定义用户信息缓存（如 Redis），缺省为容量 1000 的内存 LRU 缓存
+func WithCache(cache Cache) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// WithCacheTTL 设置用户信息缓存时间，默认 10 分钟
+func WithCacheTTL(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.cacheTime = ttl
+	}
+}
+
+// MemoryCache 并发安全的内存 LRU 缓存
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key   string
+	value UserCache
+}
+
+// NewMemoryCache 创建内存 LRU 缓存，maxEntries <= 0 表示不限容量
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get 获取未过期的缓存
+func (m *MemoryCache) Get(key string) (UserInfo, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		return UserInfo{}, false
+	}
+	entry := el.Value.(*memoryCacheEntry)
+	if !time.Now().Before(entry.value.ExpiresAt) {
+		m.removeElement(el)
+		return UserInfo{}, false
+	}
+	m.ll.MoveToFront(el)
+	return entry.value.User, true
+}
+
+// Set 写入缓存，超出容量时淘汰最久未使用的条目
+func (m *MemoryCache) Set(key string, user UserInfo, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value := UserCache{User: user, ExpiresAt: time.Now().Add(ttl)}
+	if el, ok := m.items[key]; ok {
+		el.Value.(*memoryCacheEntry).value = value
+		m.ll.MoveToFront(el)
+		return
+	}
+	m.items[key] = m.ll.PushFront(&memoryCacheEntry{key: key, value: value})
+	if m.maxEntries > 0 && m.ll.Len() > m.maxEntries {
+		m.removeElement(m.ll.Back())
+	}
+}
+
+// Delete 删除缓存
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[key]; ok {
+		m.removeElement(el)
+	}
+}
+
+// Len 返回当前条目数（含已过期未清理的条目）
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ll.Len()
+}
+
+func (m *MemoryCache) removeElement(el *list.Element) {
+	m.ll.Remove(el)
+	delete(m.items, el.Value.(*memoryCacheEntry).key)
+}
+
+// ------------------------------------------------------------------------------------------
+// 请求合并
+// ------------------------------------------------------------------------------------------
+
+// flightGroup 合并同一键的并发调用，只执行一次（singleflight）
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+	val  any
+	err  error
+}
+
+// do 执行 fn；同一键已有调用进行中时等待其结果。fn 受发起者的 ctx 控制，
+// 发起者取消导致的 ctx 错误不共享给等待者：等待者的 ctx 仍有效时重新发起调用；
+// 等待者自己的 ctx 结束时立即返回 ctx.Err()
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (any, error)) (any, error) {
+	for {
+		g.mu.Lock()
+		if g.calls == nil {
+			g.calls = make(map[string]*flightCall)
+		}
+		if call, ok := g.calls[key]; ok {
+			g.mu.Unlock()
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if isContextError(call.err) && ctx.Err() == nil {
+				continue
+			}
+			return call.val, call.err
+		}
+		call := &flightCall{done: make(chan struct{})}
+		g.calls[key] = call
+		g.mu.Unlock()
+
+		defer func() {
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(call.done)
+		}()
+		call.val, call.err = fn()
+		return call.val, call.err
+	}
+}
+
+// isContextError 是否为 ctx 取消或超时导致的错误
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
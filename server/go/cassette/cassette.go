@@ -0,0 +1,407 @@
+// Package cassette 录制与回放 HTTP 请求：对真实 DooTask 服务运行一次，把脱敏后的请求/响应
+// 保存为 JSON 磁带（cassette），之后的测试从磁带回放，不再访问网络。
+//
+// Recorder 实现 http.RoundTripper，可接入 SDK 客户端（dootask.WithTransport）或任何 http.Client：
+//
+//	rec, err := cassette.Open("testdata/project.json")
+//	if err != nil { ... }
+//	defer rec.Close() // 录制模式下写入磁带
+//	client := dootask.NewClient(token, dootask.WithServer(server), dootask.WithTransport(rec))
+//
+// 回放按方法、路径与规范化的查询参数（键排序、去掉 token）匹配，同一请求多次出现时按录制顺序依次返回。
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNoInteraction 回放时磁带中没有匹配（或已用完）的请求
+var ErrNoInteraction = errors.New("cassette: no matching interaction")
+
+// Redacted 脱敏后的占位值
+const Redacted = "[REDACTED]"
+
+// Mode 工作模式
+type Mode int
+
+const (
+	ModeAuto   Mode = iota // 磁带文件存在时回放，否则录制
+	ModeReplay             // 只回放，磁带不存在时报错
+	ModeRecord             // 总是录制并覆盖磁带
+)
+
+// 默认脱敏的请求头与字段（请求头不区分大小写；字段匹配 JSON 键与查询参数名）
+var (
+	defaultRedactHeaders = []string{"Token", "Authorization", "Cookie", "Set-Cookie"}
+	defaultRedactKeys    = []string{"token", "password", "oldpass", "newpass"}
+)
+
+// Option Recorder 选项
+type Option func(*Recorder)
+
+// WithMode 设置工作模式，默认 ModeAuto
+func WithMode(mode Mode) Option {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithTransport 设置录制时实际发送请求的传输层，默认 http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithRedactHeaders 追加需要脱敏的请求头与响应头
+func WithRedactHeaders(headers ...string) Option {
+	return func(r *Recorder) {
+		for _, h := range headers {
+			r.redactHeaders[http.CanonicalHeaderKey(h)] = true
+		}
+	}
+}
+
+// WithRedactKeys 追加需要脱敏的 JSON 字段与查询参数
+func WithRedactKeys(keys ...string) Option {
+	return func(r *Recorder) {
+		for _, k := range keys {
+			r.redactKeys[strings.ToLower(k)] = true
+		}
+	}
+}
+
+// WithFilter 设置保存前的自定义处理（如替换服务器地址中的敏感信息），在内置脱敏之后调用
+func WithFilter(fn func(*Interaction)) Option {
+	return func(r *Recorder) {
+		r.filter = fn
+	}
+}
+
+// ------------------------------------------------------------------------------------------
+// 磁带格式
+// ------------------------------------------------------------------------------------------
+
+// Cassette 磁带文件
+type Cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction 一次请求与响应
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request 录制的请求
+type Request struct {
+	Method  string      `json:"method"`
+	Path    string      `json:"path"`
+	Query   string      `json:"query,omitempty"` // 规范化后的查询参数
+	Headers http.Header `json:"headers,omitempty"`
+	Body    Body        `json:"body,omitempty"`
+}
+
+// Response 录制的响应
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    Body        `json:"body,omitempty"`
+}
+
+// Body 请求或响应体：JSON 原样保存便于阅读，其它文本保存为字符串，二进制保存为 {"$base64":"..."}
+type Body []byte
+
+// MarshalJSON 实现 json.Marshaler
+func (b Body) MarshalJSON() ([]byte, error) {
+	switch {
+	case len(b) == 0:
+		return []byte(`""`), nil
+	case json.Valid(b) && (b[0] == '{' || b[0] == '['):
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, b); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case utf8.Valid(b):
+		return json.Marshal(string(b))
+	default:
+		return json.Marshal(map[string]string{"$base64": base64.StdEncoding.EncodeToString(b)})
+	}
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = []byte(s)
+		return nil
+	}
+	var bin map[string]json.RawMessage
+	if err := json.Unmarshal(data, &bin); err == nil && len(bin) == 1 && bin["$base64"] != nil {
+		var enc string
+		if err := json.Unmarshal(bin["$base64"], &enc); err != nil {
+			return fmt.Errorf("cassette: invalid base64 body: %w", err)
+		}
+		raw, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return fmt.Errorf("cassette: invalid base64 body: %w", err)
+		}
+		*b = raw
+		return nil
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// ------------------------------------------------------------------------------------------
+// Recorder
+// ------------------------------------------------------------------------------------------
+
+// Recorder 录制或回放请求的 http.RoundTripper，并发安全
+type Recorder struct {
+	path          string
+	mode          Mode
+	transport     http.RoundTripper
+	redactHeaders map[string]bool
+	redactKeys    map[string]bool
+	filter        func(*Interaction)
+
+	mu        sync.Mutex
+	recording bool
+	cassette  Cassette
+	used      []bool // 回放时各条记录是否已使用
+}
+
+// Open 打开磁带：ModeAuto 下文件存在则回放，否则录制
+func Open(path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:          path,
+		transport:     http.DefaultTransport,
+		redactHeaders: make(map[string]bool),
+		redactKeys:    make(map[string]bool),
+		cassette:      Cassette{Version: 1},
+	}
+	WithRedactHeaders(defaultRedactHeaders...)(r)
+	WithRedactKeys(defaultRedactKeys...)(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case r.mode == ModeRecord || (r.mode == ModeAuto && errors.Is(err, os.ErrNotExist)):
+		r.recording = true
+		return r, nil
+	case err != nil:
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("cassette: parse %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Recording 是否处于录制模式
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// Interactions 返回已录制或已载入的请求
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Interaction(nil), r.cassette.Interactions...)
+}
+
+// Close 录制模式下把磁带写入文件；回放模式下无操作
+func (r *Recorder) Close() error {
+	if !r.recording {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("cassette: encode: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	return nil
+}
+
+// RoundTrip 实现 http.RoundTripper：录制模式下发送请求并记录，回放模式下返回匹配的记录
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.recording {
+		return r.record(req)
+	}
+	return r.replay(req)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	it := &Interaction{
+		Request: Request{
+			Method:  req.Method,
+			Path:    req.URL.Path,
+			Query:   r.normalizeQuery(req.URL.RawQuery),
+			Headers: r.redactHeader(req.Header),
+			Body:    r.redactBody(reqBody),
+		},
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: r.redactHeader(resp.Header),
+			Body:    r.redactBody(respBody),
+		},
+	}
+	if r.filter != nil {
+		r.filter(it)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, it)
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	query := r.normalizeQuery(req.URL.RawQuery)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, it := range r.cassette.Interactions {
+		if r.used[i] || it.Request.Method != req.Method || it.Request.Path != req.URL.Path || it.Request.Query != query {
+			continue
+		}
+		r.used[i] = true
+		header := it.Response.Headers.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", it.Response.Status, http.StatusText(it.Response.Status)),
+			StatusCode:    it.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(it.Response.Body)),
+			ContentLength: int64(len(it.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s?%s", ErrNoInteraction, req.Method, req.URL.Path, query)
+}
+
+// normalizeQuery 规范化查询参数：键排序（同名参数保持顺序），脱敏字段替换为占位值
+func (r *Recorder) normalizeQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	for key, vals := range values {
+		if r.redactKeys[strings.ToLower(key)] {
+			for i := range vals {
+				vals[i] = Redacted
+			}
+		}
+	}
+	return values.Encode()
+}
+
+func (r *Recorder) redactHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := h.Clone()
+	for key := range out {
+		if r.redactHeaders[http.CanonicalHeaderKey(key)] {
+			out[key] = []string{Redacted}
+		}
+	}
+	return out
+}
+
+// redactBody 脱敏 JSON 体中的敏感字段，非 JSON 原样返回
+func (r *Recorder) redactBody(body []byte) Body {
+	var v any
+	if len(body) == 0 || json.Unmarshal(body, &v) != nil {
+		return body
+	}
+	if !r.redactValue(v) {
+		return body
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// redactValue 递归替换敏感字段，返回是否有修改
+func (r *Recorder) redactValue(v any) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, val := range v {
+			if r.redactKeys[strings.ToLower(key)] {
+				if s, ok := val.(string); !ok || s != "" {
+					v[key] = Redacted
+					changed = true
+				}
+				continue
+			}
+			changed = r.redactValue(val) || changed
+		}
+	case []any:
+		for _, item := range v {
+			changed = r.redactValue(item) || changed
+		}
+	}
+	return changed
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/appstore"
+	"github.com/dootask/tools/server/go/cassette"
+)
+
+// appStoreStub 模拟主程序版本接口与 AppStore 接口。
+func appStoreStub(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/system/version":
+			w.Write([]byte(`{"ret":1,"msg":"","data":{"version":"1.8.0"}}`))
+		case "/appstore/api/v1/list":
+			// 应发送主程序版本，而不是 CompatVersion
+			if r.Header.Get("Version") != "1.8.0" {
+				t.Errorf("Version 头=%q", r.Header.Get("Version"))
+			}
+			w.Write([]byte(`{"code":200,"message":"ok","data":[{"id":"` + r.URL.Query().Get("include") + `","upgradeable":true,"versions":["2.0.0","1.0.0"]}]}`))
+		case "/appstore/api/v1/internal/apps/upload":
+			f, h, err := r.FormFile("file")
+			if err != nil {
+				t.Errorf("读取上传文件失败: %v", err)
+				return
+			}
+			b, _ := io.ReadAll(f)
+			id := fmt.Sprintf("%s:%s:%d", r.FormValue("appid"), h.Filename, len(b))
+			json.NewEncoder(w).Encode(map[string]any{"code": 200, "data": map[string]any{"id": id}})
+		default:
+			w.Write([]byte(`{"code":404,"message":"应用不存在"}`))
+		}
+	}))
+}
+
+func TestAppStoreRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	tape := filepath.Join(dir, "appstore.json")
+	defer func() { Transport = nil }()
+
+	run := func(server string) ([]appstore.App, string, error) {
+		Opts = Options{Server: server, Token: "secret-token"}
+		store, err := Opts.AppStore()
+		if err != nil {
+			return nil, "", err
+		}
+		apps, err := store.Upgradeable()
+		if err != nil {
+			return nil, "", err
+		}
+		id, err := store.Upload(appstore.UploadRequest{Name: "app.zip", AppID: "myapp"}, strings.NewReader("PK\x03\x04binary"))
+		if err != nil {
+			return nil, "", err
+		}
+		_, err = store.Get("missing")
+		return apps, id, err
+	}
+
+	// 录制
+	srv := appStoreStub(t)
+	rec, err := cassette.Open(tape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Transport = rec
+	apps, id, err := run(srv.URL)
+	if err == nil || err.Error() != "应用不存在" || !errors.Is(err, dootask.ErrNotFound) {
+		t.Fatalf("录制时错误=%v", err)
+	}
+	if len(apps) != 1 || apps[0].ID != "all" || apps[0].LatestVersion() != "2.0.0" || id != "myapp:app.zip:10" {
+		t.Fatalf("录制结果异常: %+v %s", apps, id)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	data, _ := os.ReadFile(tape)
+	if strings.Contains(string(data), "secret-token") {
+		t.Error("磁带中不应包含 token")
+	}
+
+	// 回放：服务已关闭，结果应与录制一致
+	rec, err = cassette.Open(tape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Transport = rec
+	apps2, id2, err := run(srv.URL)
+	if err == nil || err.Error() != "应用不存在" {
+		t.Fatalf("回放时错误=%v", err)
+	}
+	if len(apps2) != 1 || apps2[0].ID != "all" || id2 != id {
+		t.Errorf("回放结果异常: %+v %s", apps2, id2)
+	}
+
+	// 记录已用完
+	store, _ := Opts.AppStore()
+	if _, err := store.CatalogAll(); !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("期望 ErrNoInteraction，实际 %v", err)
+	}
+}
@@ -4,6 +4,7 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"time"
 
@@ -30,6 +31,9 @@ type Options struct {
 // Opts 是本次调用生效的全局参数（CLI 单次执行，进程级单例）。
 var Opts Options
 
+// Transport 非 nil 时替换 SDK 客户端与 AppStore 请求的传输层（测试中接入 cassette 录制/回放）。
+var Transport http.RoundTripper
+
 // Resolve 按 flag > env > 配置文件 > 默认 的优先级合并参数。
 func Resolve(flagServer, flagToken string, jsonOut, yes, quiet bool) {
 	cfg, _ := config.Load()
@@ -47,20 +51,28 @@ func (o Options) Client() (*dootask.Client, error) {
 	if o.Token == "" {
 		return nil, ErrNoAuth
 	}
-	return dootask.NewClient(o.Token, dootask.WithServer(o.Server), dootask.WithTimeout(30*time.Second), dootask.WithVersion(CompatVersion)), nil
+	return dootask.NewClient(o.Token, o.clientOptions(dootask.WithVersion(CompatVersion))...), nil
 }
 
 // AnonClient 构造无 token 的客户端（仅用于登录换 token）。
 func (o Options) AnonClient() *dootask.Client {
-	return dootask.NewClient("", dootask.WithServer(o.Server), dootask.WithTimeout(30*time.Second))
+	return dootask.NewClient("", o.clientOptions()...)
+}
+
+func (o Options) clientOptions(extra ...dootask.ClientOption) []dootask.ClientOption {
+	opts := []dootask.ClientOption{dootask.WithServer(o.Server), dootask.WithTimeout(30 * time.Second)}
+	if Transport != nil {
+		opts = append(opts, dootask.WithTransport(Transport))
+	}
+	return append(opts, extra...)
 }
 
-// ExitCode 把错误映射为进程退出码。
+// ExitCode 把错误映射为进程退出码：未登录或 token 失效为 3，其余为 1。
 func ExitCode(err error) int {
 	if err == nil {
 		return 0
 	}
-	if errors.Is(err, ErrNoAuth) {
+	if errors.Is(err, ErrNoAuth) || errors.Is(err, dootask.ErrUnauthorized) {
 		return 3
 	}
 	return 1
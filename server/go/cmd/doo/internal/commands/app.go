@@ -2,48 +2,15 @@ package commands
 
 import (
 	"fmt"
-	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/dootask/tools/server/go/appstore"
 	"github.com/dootask/tools/server/go/cmd/doo/internal/cli"
 	"github.com/spf13/cobra"
 )
 
-// 字段定义来自 GET /one/:appId 顶层 fields（已按当前语种摊平 label/description）。
-type appField struct {
-	Name        string `json:"name"`
-	Label       string `json:"label"`
-	Type        string `json:"type"` // input/select/password/textarea/...
-	Required    bool   `json:"required"`
-	Default     any    `json:"default"`
-	Description string `json:"description"`
-	Options     []struct {
-		Label string `json:"label"`
-		Value any    `json:"value"`
-	} `json:"options"`
-}
-
-type appOne struct {
-	ID     string     `json:"id"`
-	Name   string     `json:"name"`
-	Fields []appField `json:"fields"`
-	Config struct {
-		Params         map[string]any `json:"params"`
-		Resources      map[string]any `json:"resources"`
-		InstallVersion string         `json:"install_version"`
-		Status         string         `json:"status"`
-	} `json:"config"`
-}
-
-func fetchAppOne(appid string) (*appOne, error) {
-	var o appOne
-	if err := cli.AppStoreRequest("GET", "/one/"+url.PathEscape(appid), nil, nil, &o); err != nil {
-		return nil, err
-	}
-	return &o, nil
-}
-
 // parseParamPairs 把 ["K=V","K2=V2"] 解析成 map；非法的 K=V 报错。
 func parseParamPairs(pairs []string) (map[string]any, error) {
 	m := map[string]any{}
@@ -59,8 +26,8 @@ func parseParamPairs(pairs []string) (map[string]any, error) {
 
 // validateRequiredFields 检查 required 字段：若用户未提供，且字段无 default，则报错。
 // 同时拒绝 fields 里不存在的多余 key，提示用户拼错。
-func validateRequiredFields(fields []appField, given map[string]any) error {
-	defined := map[string]appField{}
+func validateRequiredFields(fields []appstore.Field, given map[string]any) error {
+	defined := map[string]appstore.Field{}
 	for _, f := range fields {
 		defined[f.Name] = f
 	}
@@ -77,7 +44,7 @@ func validateRequiredFields(fields []appField, given map[string]any) error {
 		if _, ok := given[f.Name]; ok {
 			continue
 		}
-		if f.Default != nil && fmt.Sprintf("%v", f.Default) != "" {
+		if f.HasDefault() {
 			continue // 有默认值，后端会兜底
 		}
 		missing = append(missing, f.Name)
@@ -88,22 +55,6 @@ func validateRequiredFields(fields []appField, given map[string]any) error {
 	return nil
 }
 
-// buildResources 把 --cpu-limit/--memory-limit 拼成 AppStore 期望的形态；
-// 任一为空就不覆盖已装值（reinstall 场景下保留 sticky）。
-func buildResources(cpu, mem string, fallback map[string]any) map[string]any {
-	r := map[string]any{}
-	for k, v := range fallback {
-		r[k] = v
-	}
-	if cpu != "" {
-		r["cpu_limit"] = cpu
-	}
-	if mem != "" {
-		r["memory_limit"] = mem
-	}
-	return r
-}
-
 // 应用插件（AppStore）管理。安装/卸载/删除/更新列表需管理员；列表/日志/容器普通用户即可。
 func newAppCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -136,11 +87,15 @@ func newAppFieldsCmd() *cobra.Command {
 		Short: "列出应用的安装参数定义（字段名/类型/是否必填/默认值/可选项）",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			one, err := fetchAppOne(args[0])
+			store, err := cli.Opts.AppStore()
+			if err != nil {
+				return err
+			}
+			detail, err := store.Get(args[0])
 			if err != nil {
 				return err
 			}
-			return cli.Output(one.Fields, []string{"name", "type", "required", "default", "label", "description"})
+			return cli.Output(detail.Fields, []string{"name", "type", "required", "default", "label", "description"})
 		},
 	}
 }
@@ -150,11 +105,15 @@ func newAppListCmd() *cobra.Command {
 		Use:   "list",
 		Short: "列出已安装应用",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var out any
-			if err := cli.AppStoreRequest("GET", "/internal/installed", nil, nil, &out); err != nil {
+			store, err := cli.Opts.AppStore()
+			if err != nil {
+				return err
+			}
+			apps, err := store.Installed()
+			if err != nil {
 				return err
 			}
-			return cli.Output(out, []string{"id", "version", "status", "install_at"})
+			return cli.Output(apps, []string{"id", "version", "status", "install_at"})
 		},
 	}
 }
@@ -166,24 +125,22 @@ func newAppUpdatesCmd() *cobra.Command {
 		Use:   "updates",
 		Short: "列出可升级的已安装应用（与网页「可升级」一致；含已装版本与最新版本）",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var apps []map[string]any
-			if err := cli.AppStoreRequest("GET", "/list", map[string]string{"include": "all"}, nil, &apps); err != nil {
+			store, err := cli.Opts.AppStore()
+			if err != nil {
 				return err
 			}
-			rows := make([]map[string]any, 0)
+			apps, err := store.Upgradeable()
+			if err != nil {
+				return err
+			}
+			rows := make([]map[string]any, 0, len(apps))
 			for _, a := range apps {
-				if up, _ := a["upgradeable"].(bool); !up {
-					continue
-				}
-				row := map[string]any{"id": a["id"], "name": a["name"]}
-				if cfg, ok := a["config"].(map[string]any); ok {
-					row["installed"] = cfg["install_version"]
-				}
-				// versions 按版本降序，首个即可升级到的最新版本
-				if vs, ok := a["versions"].([]any); ok && len(vs) > 0 {
-					row["latest"] = vs[0]
-				}
-				rows = append(rows, row)
+				rows = append(rows, map[string]any{
+					"id":        a.ID,
+					"name":      a.Name,
+					"installed": a.Config.InstallVersion,
+					"latest":    a.LatestVersion(),
+				})
 			}
 			if len(rows) == 0 && !cli.Opts.JSON {
 				cli.OK("没有可升级的应用（所有已安装应用均为最新版本）")
@@ -200,15 +157,18 @@ func newAppCatalogCmd() *cobra.Command {
 		Use:   "catalog",
 		Short: "列出应用市场可安装的应用（--search 关键词模糊匹配 id/name/description/tags）",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var raw []map[string]any
-			if err := cli.AppStoreRequest("GET", "/list", nil, nil, &raw); err != nil {
+			store, err := cli.Opts.AppStore()
+			if err != nil {
+				return err
+			}
+			items, err := store.Catalog()
+			if err != nil {
 				return err
 			}
-			items := raw
 			if kw := strings.TrimSpace(search); kw != "" {
 				kwLower := strings.ToLower(kw)
-				matched := make([]map[string]any, 0, len(raw))
-				for _, it := range raw {
+				matched := make([]appstore.App, 0, len(items))
+				for _, it := range items {
 					if catalogMatch(it, kw, kwLower) {
 						matched = append(matched, it)
 					}
@@ -224,21 +184,10 @@ func newAppCatalogCmd() *cobra.Command {
 
 // catalogMatch 按关键词在 id/name/description/tags 中做大小写不敏感的子串匹配；
 // tags 任一项命中即视为匹配，便于覆盖中文标签如「客户管理」。
-func catalogMatch(item map[string]any, kw, kwLower string) bool {
-	for _, k := range []string{"id", "name", "description"} {
-		if v, ok := item[k].(string); ok && v != "" {
-			if strings.Contains(strings.ToLower(v), kwLower) || strings.Contains(v, kw) {
-				return true
-			}
-		}
-	}
-	if tags, ok := item["tags"].([]any); ok {
-		for _, t := range tags {
-			if s, ok := t.(string); ok {
-				if strings.Contains(strings.ToLower(s), kwLower) || strings.Contains(s, kw) {
-					return true
-				}
-			}
+func catalogMatch(app appstore.App, kw, kwLower string) bool {
+	for _, v := range append([]string{app.ID, app.Name, app.Description}, app.Tags...) {
+		if v != "" && (strings.Contains(strings.ToLower(v), kwLower) || strings.Contains(v, kw)) {
+			return true
 		}
 	}
 	return false
@@ -259,17 +208,20 @@ func newAppUploadCmd() *cobra.Command {
 			} else if fi.IsDir() {
 				return fmt.Errorf("%q 是目录，请指定应用压缩包文件", path)
 			}
-			fields := map[string]string{}
-			if appid != "" {
-				fields["appid"] = appid
+			store, err := cli.Opts.AppStore()
+			if err != nil {
+				return err
 			}
-			var res struct {
-				ID string `json:"id"`
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("打开文件失败: %w", err)
 			}
-			if err := cli.AppStoreUpload("/internal/apps/upload", "file", path, fields, &res); err != nil {
+			defer f.Close()
+			id, err := store.Upload(appstore.UploadRequest{Name: filepath.Base(path), AppID: appid}, f)
+			if err != nil {
 				return err
 			}
-			cli.OK("✓ 已导入应用：%s\n  接下来用 `doo app install %s` 安装部署", res.ID, res.ID)
+			cli.OK("✓ 已导入应用：%s\n  接下来用 `doo app install %s` 安装部署", id, id)
 			return nil
 		},
 	}
@@ -278,6 +230,8 @@ func newAppUploadCmd() *cobra.Command {
 }
 
 // install / update 共用：对已安装应用再 install 即为升级（后端自动判定）。
+// 已安装则 sticky：以当前已装 params/resources 为底叠加用户传入，避免不传 --param 时
+// 误把令牌/选项清空（与网页表单"初值即当前值"的行为对齐）。
 // 校验 fields（拒未知 key、缺必填字段在没默认值时报错），暴露 cpu/memory/pull。
 func newAppInstallCmd(verb string) *cobra.Command {
 	var version, cpuLimit, memLimit string
@@ -293,42 +247,19 @@ func newAppInstallCmd(verb string) *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			appid := args[0]
-			one, err := fetchAppOne(appid)
+			store, err := cli.Opts.AppStore()
 			if err != nil {
 				return err
 			}
-			given, err := parseParamPairs(params)
+			detail, err := store.Get(appid)
 			if err != nil {
 				return err
 			}
-			// 已安装则 sticky：以当前已装 params 为底叠加用户传入，避免不传 --param 时
-			// 误把令牌/选项清空（与网页表单"初值即当前值"的行为对齐）。
-			merged := map[string]any{}
-			if one.Config.Status == "installed" {
-				for k, v := range one.Config.Params {
-					merged[k] = v
-				}
-			}
-			for k, v := range given {
-				merged[k] = v
-			}
-			if err := validateRequiredFields(one.Fields, merged); err != nil {
+			req, err := installRequest(detail, version, params, cpuLimit, memLimit, pull)
+			if err != nil {
 				return err
 			}
-			body := map[string]any{
-				"appid":      appid,
-				"version":    version,
-				"pull_image": pull,
-			}
-			if len(merged) > 0 {
-				body["params"] = merged
-			}
-			if cpuLimit != "" || memLimit != "" {
-				body["resources"] = buildResources(cpuLimit, memLimit, one.Config.Resources)
-			} else if one.Config.Status == "installed" && len(one.Config.Resources) > 0 {
-				body["resources"] = one.Config.Resources
-			}
-			if err := cli.AppStoreRequest("POST", "/internal/install", nil, body, nil); err != nil {
+			if err := store.Install(req); err != nil {
 				return err
 			}
 			cli.OK("✓ 已触发%s：%s@%s", map[string]string{"install": "安装", "update": "更新"}[verb], appid, version)
@@ -344,6 +275,23 @@ func newAppInstallCmd(verb string) *cobra.Command {
 	return cmd
 }
 
+// installRequest 把命令行参数拼成安装请求：sticky 合并当前配置后校验 fields。
+func installRequest(detail *appstore.AppDetail, version string, params []string, cpu, mem string, pull bool) (appstore.InstallRequest, error) {
+	given, err := parseParamPairs(params)
+	if err != nil {
+		return appstore.InstallRequest{}, err
+	}
+	req := appstore.InstallRequest{AppID: detail.ID, Version: version, PullImage: pull, Params: given}
+	if cpu != "" || mem != "" {
+		req.Resources = &appstore.Resources{CPULimit: cpu, MemoryLimit: mem}
+	}
+	req = detail.Merge(req)
+	if err := validateRequiredFields(detail.Fields, req.Params); err != nil {
+		return appstore.InstallRequest{}, err
+	}
+	return req, nil
+}
+
 // reinstall：按当前已装版本重部署；sticky 复用当前 params/resources，允许 --param/--cpu-limit 覆盖。
 func newAppReinstallCmd() *cobra.Command {
 	var pull bool
@@ -355,37 +303,23 @@ func newAppReinstallCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			appid := args[0]
-			one, err := fetchAppOne(appid)
+			store, err := cli.Opts.AppStore()
 			if err != nil {
 				return err
 			}
-			version := one.Config.InstallVersion
-			if version == "" || one.Config.Status != "installed" {
-				return fmt.Errorf("应用 %s 未安装，无法重装", appid)
-			}
-			given, err := parseParamPairs(params)
+			detail, err := store.Get(appid)
 			if err != nil {
 				return err
 			}
-			// sticky：以当前已装 params 为底，再叠加用户传入的覆盖
-			merged := map[string]any{}
-			for k, v := range one.Config.Params {
-				merged[k] = v
-			}
-			for k, v := range given {
-				merged[k] = v
+			version := detail.Config.InstallVersion
+			if version == "" || !detail.Config.Installed() {
+				return fmt.Errorf("应用 %s 未安装，无法重装", appid)
 			}
-			if err := validateRequiredFields(one.Fields, merged); err != nil {
+			req, err := installRequest(detail, version, params, cpuLimit, memLimit, pull)
+			if err != nil {
 				return err
 			}
-			body := map[string]any{
-				"appid":      appid,
-				"version":    version,
-				"pull_image": pull,
-				"params":     merged,
-				"resources":  buildResources(cpuLimit, memLimit, one.Config.Resources),
-			}
-			if err := cli.AppStoreRequest("POST", "/internal/install", nil, body, nil); err != nil {
+			if err := store.Install(req); err != nil {
 				return err
 			}
 			cli.OK("✓ 已触发重装：%s@%s", appid, version)
@@ -414,11 +348,11 @@ func newAppUninstallCmd() *cobra.Command {
 			if err := cli.Confirm(msg); err != nil {
 				return err
 			}
-			q := map[string]string{}
-			if deleteData {
-				q["delete_data"] = "true"
+			store, err := cli.Opts.AppStore()
+			if err != nil {
+				return err
 			}
-			if err := cli.AppStoreRequest("GET", "/internal/uninstall/"+url.PathEscape(args[0]), q, nil, nil); err != nil {
+			if err := store.Uninstall(args[0], deleteData); err != nil {
 				return err
 			}
 			cli.OK("✓ 已触发卸载：%s", args[0])
@@ -438,7 +372,11 @@ func newAppRemoveCmd() *cobra.Command {
 			if err := cli.Confirm(fmt.Sprintf("确认删除应用 %s（不可恢复）?", args[0])); err != nil {
 				return err
 			}
-			if err := cli.AppStoreRequest("GET", "/internal/remove/"+url.PathEscape(args[0]), nil, nil, nil); err != nil {
+			store, err := cli.Opts.AppStore()
+			if err != nil {
+				return err
+			}
+			if err := store.Remove(args[0]); err != nil {
 				return err
 			}
 			cli.OK("✓ 已删除：%s", args[0])
@@ -454,12 +392,12 @@ func newAppLogsCmd() *cobra.Command {
 		Short: "查看应用安装/运行日志",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			q := map[string]string{}
-			if lines > 0 {
-				q["n"] = fmt.Sprintf("%d", lines)
+			store, err := cli.Opts.AppStore()
+			if err != nil {
+				return err
 			}
-			var out any
-			if err := cli.AppStoreRequest("GET", "/internal/log/"+url.PathEscape(args[0]), q, nil, &out); err != nil {
+			out, err := store.Logs(args[0], lines)
+			if err != nil {
 				return err
 			}
 			return cli.Output(out, nil)
@@ -475,8 +413,12 @@ func newAppContainersCmd() *cobra.Command {
 		Short: "列出应用的容器/服务",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var out any
-			if err := cli.AppStoreRequest("GET", "/internal/containers/"+url.PathEscape(args[0]), nil, nil, &out); err != nil {
+			store, err := cli.Opts.AppStore()
+			if err != nil {
+				return err
+			}
+			out, err := store.Containers(args[0])
+			if err != nil {
 				return err
 			}
 			return cli.Output(out, nil)
@@ -495,12 +437,12 @@ func newAppContainerLogsCmd() *cobra.Command {
 			if service == "" {
 				return fmt.Errorf("--service 必填（可先用 app containers 查看服务名）")
 			}
-			q := map[string]string{"service": service}
-			if lines > 0 {
-				q["n"] = fmt.Sprintf("%d", lines)
+			store, err := cli.Opts.AppStore()
+			if err != nil {
+				return err
 			}
-			var out any
-			if err := cli.AppStoreRequest("GET", "/internal/containers/"+url.PathEscape(args[0])+"/logs", q, nil, &out); err != nil {
+			out, err := store.ContainerLogs(args[0], service, lines)
+			if err != nil {
 				return err
 			}
 			return cli.Output(out, nil)
@@ -517,7 +459,11 @@ func newAppRefreshCmd() *cobra.Command {
 		Use:   "refresh",
 		Short: "刷新应用市场可安装列表（拉取远程源）",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := cli.AppStoreRequest("GET", "/internal/apps/update", nil, nil, nil); err != nil {
+			store, err := cli.Opts.AppStore()
+			if err != nil {
+				return err
+			}
+			if err := store.Refresh(); err != nil {
 				return err
 			}
 			cli.OK("✓ 已触发刷新应用列表")
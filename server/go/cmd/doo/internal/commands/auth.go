@@ -2,10 +2,14 @@ package commands
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"mime"
 	"os"
 	"strings"
+	"time"
 
+	dootask "github.com/dootask/tools/server/go"
 	"github.com/dootask/tools/server/go/cmd/doo/internal/cli"
 	"github.com/dootask/tools/server/go/cmd/doo/internal/config"
 	"github.com/spf13/cobra"
@@ -44,27 +48,20 @@ func newAuthLoginCmd() *cobra.Command {
 			}
 
 			c := cli.Opts.AnonClient()
-			var resp map[string]any
-			err := c.NewGetRequest("/api/users/login", map[string]any{
-				"type":     "login",
-				"email":    email,
-				"password": password,
-			}, &resp)
+			result, err := c.Login(email, password)
+			if errors.Is(err, dootask.ErrCaptchaRequired) {
+				if !term.IsTerminal(int(os.Stdin.Fd())) {
+					return fmt.Errorf("登录需要验证码，请在终端中运行，或在浏览器登录后用 --token / DOO_TOKEN 直接传入")
+				}
+				result, err = c.LoginWithCaptcha(email, password, promptCaptcha)
+			}
 			if err != nil {
 				return err
 			}
-			token, _ := resp["token"].(string)
-			if token == "" {
-				if code, _ := resp["code"].(string); code == "need" {
-					return fmt.Errorf("登录需要验证码，请在浏览器登录后用 --token / DOO_TOKEN 直接传入")
-				}
-				return fmt.Errorf("登录响应未包含 token")
-			}
-			if err := config.Save(config.Config{Server: cli.Opts.Server, Token: token}); err != nil {
+			if err := config.Save(config.Config{Server: cli.Opts.Server, Token: result.Token}); err != nil {
 				return err
 			}
-			nickname, _ := resp["nickname"].(string)
-			cli.OK("✓ 已登录：%s（%s）\n  配置已写入 %s", nickname, cli.Opts.Server, config.Path())
+			cli.OK("✓ 已登录：%s（%s）\n  配置已写入 %s", result.Nickname, cli.Opts.Server, config.Path())
 			return nil
 		},
 	}
@@ -73,6 +70,40 @@ func newAuthLoginCmd() *cobra.Command {
 	return cmd
 }
 
+// promptCaptcha 把验证码图片写入临时文件，提示用户查看后输入答案。
+func promptCaptcha(captcha *dootask.Captcha) (string, error) {
+	img, contentType, err := captcha.ImageBytes()
+	if err != nil {
+		return "", err
+	}
+	ext := ".png"
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		ext = exts[0]
+	}
+	f, err := os.CreateTemp("", "doo-captcha-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(img)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(os.Stderr, "登录需要验证码，图片已保存到 %s\n验证码: ", f.Name())
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	code := strings.TrimSpace(line)
+	if code == "" {
+		if err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("未输入验证码")
+	}
+	return code, nil
+}
+
 func newAuthStatusCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "status",
@@ -91,11 +122,29 @@ func newAuthStatusCmd() *cobra.Command {
 			}
 			cli.OK("服务器: %s\n用户:   #%d %s <%s>\n身份:   %s",
 				cli.Opts.Server, u.UserID, u.Nickname, u.Email, strings.Join(u.Identity, ","))
+			// 有效期仅作提示，旧版服务端没有该接口时不显示
+			if expire, err := c.GetTokenExpire(); err == nil {
+				cli.OK("有效期: %s", tokenExpireText(expire))
+			}
 			return nil
 		},
 	}
 }
 
+// tokenExpireText 描述 token 有效期。
+func tokenExpireText(expire *dootask.TokenExpire) string {
+	switch in := expire.ExpiresIn(); {
+	case in < 0:
+		return "永久有效"
+	case in == 0:
+		return "已过期"
+	case expire.ExpiresWithin(7 * 24 * time.Hour):
+		return fmt.Sprintf("%s 到期（剩余 %s，即将过期，请重新登录）", expire.ExpiredAt, in.Round(time.Minute))
+	default:
+		return fmt.Sprintf("%s 到期", expire.ExpiredAt)
+	}
+}
+
 func newAuthLogoutCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "logout",
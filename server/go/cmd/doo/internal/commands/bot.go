@@ -103,21 +103,21 @@ func newBotUpdateCmd() *cobra.Command {
 				return err
 			}
 			f := cmd.Flags()
-			params := map[string]any{"id": id}
+			req := dootask.EditBotRequest{ID: id}
 			if f.Changed("name") {
-				params["name"] = name
+				req.Name = dootask.Set(name)
 			}
 			if f.Changed("avatar") {
-				params["avatar"] = avatar
+				req.Avatar = dootask.Set(avatar)
 			}
 			if f.Changed("webhook") {
-				params["webhook_url"] = webhook
+				req.WebhookURL = dootask.Set(webhook)
 			}
 			if f.Changed("clear-day") {
-				params["clear_day"] = clearDay
+				req.ClearDay = dootask.Set(clearDay)
 			}
 			var out map[string]any
-			if err := c.NewPostRequest("/api/users/bot/edit", params, &out); err != nil {
+			if err := c.NewPostRequest("/api/users/bot/edit", req, &out); err != nil {
 				return err
 			}
 			if cli.Opts.JSON {
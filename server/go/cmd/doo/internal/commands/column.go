@@ -92,18 +92,18 @@ func newColumnUpdateCmd() *cobra.Command {
 				return err
 			}
 			f := cmd.Flags()
-			params := map[string]any{"column_id": id}
+			req := dootask.UpdateColumnRequest{ColumnID: id}
 			if f.Changed("name") {
-				params["name"] = name
+				req.Name = dootask.Set(name)
 			}
 			if f.Changed("color") {
-				params["color"] = color
+				req.Color = dootask.Set(color)
 			}
-			if len(params) == 1 {
+			if !req.Name.IsSet() && !req.Color.IsSet() {
 				return fmt.Errorf("没有要更新的字段")
 			}
 			var out map[string]any
-			if err := c.NewGetRequest("/api/project/column/update", params, &out); err != nil {
+			if err := c.NewGetRequest("/api/project/column/update", req, &out); err != nil {
 				return err
 			}
 			if cli.Opts.JSON {
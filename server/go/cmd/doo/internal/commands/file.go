@@ -1,13 +1,15 @@
 package commands
 
 import (
+	"fmt"
+
+	dootask "github.com/dootask/tools/server/go"
 	"github.com/dootask/tools/server/go/cmd/doo/internal/cli"
 	"github.com/spf13/cobra"
 )
 
-// file 全部走兜底端点（SDK 暂无文件域），标记为实验性。
 func newFileCmd() *cobra.Command {
-	cmd := &cobra.Command{Use: "file", Short: "文件（实验性）"}
+	cmd := &cobra.Command{Use: "file", Short: "文件"}
 	cmd.AddCommand(
 		newFileListCmd(),
 		newFileSearchCmd(),
@@ -17,6 +19,8 @@ func newFileCmd() *cobra.Command {
 	return cmd
 }
 
+var fileCols = []string{"id", "name", "type", "ext", "size"}
+
 func newFileListCmd() *cobra.Command {
 	var pid int
 	cmd := &cobra.Command{
@@ -27,15 +31,11 @@ func newFileListCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			params := map[string]any{}
-			if pid > 0 {
-				params["pid"] = pid
-			}
-			var out any
-			if err := c.NewGetRequest("/api/file/lists", params, &out); err != nil {
+			files, err := c.ListFiles(pid)
+			if err != nil {
 				return err
 			}
-			return cli.Output(out, []string{"id", "name", "type", "ext", "size"})
+			return cli.Output(files, fileCols)
 		},
 	}
 	cmd.Flags().IntVar(&pid, "pid", 0, "父文件夹 ID（默认根目录）")
@@ -53,15 +53,11 @@ func newFileSearchCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			params := map[string]any{"key": args[0]}
-			if take > 0 {
-				params["take"] = take
-			}
-			var out any
-			if err := c.NewGetRequest("/api/file/search", params, &out); err != nil {
+			files, err := c.SearchFiles(dootask.SearchFilesRequest{Key: args[0], Take: take})
+			if err != nil {
 				return err
 			}
-			return cli.Output(out, []string{"id", "name", "type", "ext", "size"})
+			return cli.Output(files, fileCols)
 		},
 	}
 	cmd.Flags().IntVar(&take, "take", 0, "返回数量（最大 100）")
@@ -75,22 +71,40 @@ func newFileViewCmd() *cobra.Command {
 		Short: "查看文件详情",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := cli.ParseInt(args[0], "文件ID")
+			if err != nil {
+				return err
+			}
 			c, err := cli.Opts.Client()
 			if err != nil {
 				return err
 			}
-			params := map[string]any{"id": args[0]}
-			if content {
-				params["with_text"] = "yes"
+			file, err := c.GetFile(id)
+			if err != nil {
+				return err
+			}
+			if !content {
+				return cli.Output(file, nil)
 			}
-			var out any
-			if err := c.NewGetRequest("/api/file/one", params, &out); err != nil {
+			fc, err := c.GetFileContent(id)
+			if err != nil {
 				return err
 			}
-			return cli.Output(out, nil)
+			// --json 时输出单个对象，便于 jq 等工具解析
+			if cli.Opts.JSON {
+				return cli.Output(struct {
+					File    *dootask.File        `json:"file"`
+					Content *dootask.FileContent `json:"content"`
+				}{file, fc}, nil)
+			}
+			if err := cli.Output(file, nil); err != nil {
+				return err
+			}
+			fmt.Println("\n--- 内容 ---")
+			return cli.Output(fc, nil)
 		},
 	}
-	cmd.Flags().BoolVar(&content, "content", false, "附带文本内容")
+	cmd.Flags().BoolVar(&content, "content", false, "附带文档内容")
 	return cmd
 }
 
@@ -105,18 +119,11 @@ func newFileFetchCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			params := map[string]any{"path": args[0]}
-			if offset > 0 {
-				params["offset"] = offset
-			}
-			if limit > 0 {
-				params["limit"] = limit
-			}
-			var out any
-			if err := c.NewGetRequest("/api/file/fetch", params, &out); err != nil {
+			text, err := c.FetchFileText(args[0], offset, limit)
+			if err != nil {
 				return err
 			}
-			return cli.Output(out, nil)
+			return cli.Output(text, nil)
 		},
 	}
 	cmd.Flags().IntVar(&offset, "offset", 0, "起始偏移")
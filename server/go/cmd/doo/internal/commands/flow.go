@@ -9,15 +9,48 @@ import (
 
 func newFlowCmd() *cobra.Command {
 	cmd := &cobra.Command{Use: "flow", Short: "工作流"}
-	cmd.AddCommand(newFlowListCmd())
+	cmd.AddCommand(newFlowListCmd(), newFlowDeleteCmd())
 	return cmd
 }
 
+var flowItemCols = []string{"id", "name", "status", "turns", "userids", "usertype", "columnid"}
+
 func newFlowListCmd() *cobra.Command {
 	var project int
 	cmd := &cobra.Command{
 		Use:   "list",
-		Short: "列出项目工作流及各状态（状态 id 用于 task move/update --flow）",
+		Short: "列出项目工作流的各状态（可用 task transition 按名称流转）",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if project <= 0 {
+				return fmt.Errorf("--project 必填")
+			}
+			c, err := cli.Opts.Client()
+			if err != nil {
+				return err
+			}
+			flows, err := c.GetProjectFlows(project)
+			if err != nil {
+				return err
+			}
+			if cli.Opts.JSON {
+				return cli.Output(flows, nil)
+			}
+			if len(flows) == 0 {
+				cli.OK("项目 #%d 未开启工作流", project)
+				return nil
+			}
+			return cli.Output(flows[0].Items, flowItemCols)
+		},
+	}
+	cmd.Flags().IntVar(&project, "project", 0, "项目 ID（必填）")
+	return cmd
+}
+
+func newFlowDeleteCmd() *cobra.Command {
+	var project int
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "删除项目工作流（任务的状态随之清空）",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if project <= 0 {
 				return fmt.Errorf("--project 必填")
@@ -26,11 +59,14 @@ func newFlowListCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			var out any
-			if err := c.NewGetRequest("/api/project/flow/list", map[string]any{"project_id": project}, &out); err != nil {
+			if err := cli.Confirm(fmt.Sprintf("确认删除项目 #%d 的工作流?", project)); err != nil {
+				return err
+			}
+			if err := c.DeleteProjectFlow(project); err != nil {
 				return err
 			}
-			return cli.Output(out, nil)
+			cli.OK("✓ 已删除项目 #%d 的工作流", project)
+			return nil
 		},
 	}
 	cmd.Flags().IntVar(&project, "project", 0, "项目 ID（必填）")
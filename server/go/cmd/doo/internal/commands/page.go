@@ -2,21 +2,19 @@ package commands
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"time"
 
+	dootask "github.com/dootask/tools/server/go"
 	"github.com/dootask/tools/server/go/cmd/doo/internal/cli"
 	"github.com/spf13/cobra"
 )
 
-// 页面操作经主程序常驻 WebSocket（/ws）派发：
-// POST assistant/operation/dispatch 拿 requestId → 轮询 GET assistant/operation/result。
+// 页面操作经主程序常驻 WebSocket（/ws）派发：SDK PageOps 派发后按退避间隔轮询结果，
 // 对调用者表现为一条同步命令。
-const (
-	pagePollInterval = 200 * time.Millisecond
-	pagePollTimeout  = 30 * time.Second
-)
+const pageTimeout = 30 * time.Second
 
 func newPageCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -49,8 +47,8 @@ func resolveSession(cmd *cobra.Command) (int, error) {
 	return fd, nil
 }
 
-// dispatchPageOp 派发一次操作并轮询结果，返回 result 字段。
-func dispatchPageOp(cmd *cobra.Command, action string, payload map[string]any) (any, error) {
+// pageOps 按 --session 构造页面操作客户端。
+func pageOps(cmd *cobra.Command) (*dootask.PageOps, error) {
 	fd, err := resolveSession(cmd)
 	if err != nil {
 		return nil, err
@@ -59,47 +57,26 @@ func dispatchPageOp(cmd *cobra.Command, action string, payload map[string]any) (
 	if err != nil {
 		return nil, err
 	}
+	return c.PageOps(fd, dootask.WithPageTimeout(pageTimeout)), nil
+}
 
-	var disp struct {
-		RequestID string `json:"requestId"`
-	}
-	dispParams := map[string]any{"fd": fd, "action": action}
-	if payload != nil {
-		dispParams["payload"] = payload
-	}
-	if err := c.NewPostRequest("/api/assistant/operation/dispatch", dispParams, &disp); err != nil {
-		return nil, err
+// waitPageOp 等待操作完成，把浏览器回包失败与超时转成中文提示。
+func waitPageOp[T any](cmd *cobra.Command, op *dootask.PageOp[T]) (T, error) {
+	result, err := op.Wait(cmd.Context())
+	if err == nil {
+		return result, nil
 	}
-	if disp.RequestID == "" {
-		return nil, fmt.Errorf("派发失败：未返回 requestId")
-	}
-
-	deadline := time.Now().Add(pagePollTimeout)
-	for {
-		var res struct {
-			Status  string `json:"status"`
-			Success bool   `json:"success"`
-			Result  any    `json:"result"`
-			Error   string `json:"error"`
-		}
-		err := c.NewGetRequest("/api/assistant/operation/result", map[string]any{"request_id": disp.RequestID}, &res)
-		if err != nil {
-			return nil, err
-		}
-		if res.Status == "ready" {
-			if !res.Success {
-				if res.Error != "" {
-					return nil, fmt.Errorf("页面操作失败：%s", res.Error)
-				}
-				return nil, fmt.Errorf("页面操作失败")
-			}
-			return res.Result, nil
-		}
-		if time.Now().After(deadline) {
-			return nil, fmt.Errorf("页面操作超时（%s）：浏览器未在限定时间内回包", pagePollTimeout)
-		}
-		time.Sleep(pagePollInterval)
+	var zero T
+	var opErr *dootask.PageOpError
+	switch {
+	case errors.As(err, &opErr) && opErr.Message != "":
+		return zero, fmt.Errorf("页面操作失败：%s", opErr.Message)
+	case errors.As(err, &opErr):
+		return zero, fmt.Errorf("页面操作失败")
+	case errors.Is(err, dootask.ErrPageTimeout):
+		return zero, fmt.Errorf("页面操作超时（%s）：浏览器未在限定时间内回包", pageTimeout)
 	}
+	return zero, err
 }
 
 func newPageContextCmd() *cobra.Command {
@@ -110,30 +87,26 @@ func newPageContextCmd() *cobra.Command {
 		Use:   "context",
 		Short: "获取当前页面上下文与可交互元素",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			payload := map[string]any{}
-			if query != "" {
-				payload["query"] = query
-			}
-			if container != "" {
-				payload["container"] = container
-			}
-			if maxElements > 0 {
-				payload["max_elements"] = maxElements
-			}
-			if offset > 0 {
-				payload["offset"] = offset
-			}
-			if interactiveOnly {
-				payload["interactive_only"] = true
+			pages, err := pageOps(cmd)
+			if err != nil {
+				return err
 			}
-			if noElements {
-				payload["include_elements"] = false
+			op, err := pages.GetPageContext(cmd.Context(), dootask.PageContextRequest{
+				Query:           query,
+				Container:       container,
+				MaxElements:     maxElements,
+				Offset:          offset,
+				InteractiveOnly: interactiveOnly,
+				NoElements:      noElements,
+			})
+			if err != nil {
+				return err
 			}
-			result, err := dispatchPageOp(cmd, "get_page_context", payload)
+			page, err := waitPageOp(cmd, op)
 			if err != nil {
 				return err
 			}
-			return cli.Output(result, nil)
+			return cli.Output(page.Raw, nil)
 		},
 	}
 	f := cmd.Flags()
@@ -153,15 +126,21 @@ func newPageActionCmd() *cobra.Command {
 		Short: "执行一个业务页面操作",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			payload := map[string]any{"name": args[0]}
+			var params map[string]any
 			if paramsJSON != "" {
-				var params map[string]any
 				if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
 					return fmt.Errorf("--params 不是合法 JSON：%w", err)
 				}
-				payload["params"] = params
 			}
-			result, err := dispatchPageOp(cmd, "execute_action", payload)
+			pages, err := pageOps(cmd)
+			if err != nil {
+				return err
+			}
+			op, err := pages.ExecuteAction(cmd.Context(), args[0], params)
+			if err != nil {
+				return err
+			}
+			result, err := waitPageOp(cmd, op)
 			if err != nil {
 				return err
 			}
@@ -178,18 +157,23 @@ func newPageElementCmd() *cobra.Command {
 		Short: "对页面元素执行动作（如 click、fill）",
 		Args:  cobra.RangeArgs(2, 3),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			payload := map[string]any{
-				"element_uid": args[0],
-				"action":      args[1],
-			}
+			req := dootask.ElementActionRequest{ElementUID: args[0], Action: args[1]}
 			if len(args) == 3 {
-				payload["value"] = args[2]
+				req.Value = args[2]
 			}
-			result, err := dispatchPageOp(cmd, "execute_element_action", payload)
+			pages, err := pageOps(cmd)
 			if err != nil {
 				return err
 			}
-			return cli.Output(result, nil)
+			op, err := pages.ExecuteElementAction(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+			result, err := waitPageOp(cmd, op)
+			if err != nil {
+				return err
+			}
+			return cli.Output(result.Raw, nil)
 		},
 	}
 	return cmd
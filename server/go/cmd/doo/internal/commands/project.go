@@ -133,18 +133,18 @@ func newProjectUpdateCmd() *cobra.Command {
 				return err
 			}
 			f := cmd.Flags()
-			params := map[string]any{"project_id": id, "name": name}
+			req := dootask.UpdateProjectRequest{ProjectID: id, Name: name}
 			if f.Changed("desc") {
-				params["desc"] = desc
+				req.Desc = dootask.Set(desc)
 			}
 			if f.Changed("archive-method") {
-				params["archive_method"] = archiveMethod
+				req.ArchiveMethod = dootask.Set(archiveMethod)
 			}
 			if f.Changed("archive-days") {
-				params["archive_days"] = archiveDays
+				req.ArchiveDays = dootask.Set(archiveDays)
 			}
 			var out map[string]any
-			if err := c.NewGetRequest("/api/project/update", params, &out); err != nil {
+			if err := c.NewGetRequest("/api/project/update", req, &out); err != nil {
 				return err
 			}
 			if cli.Opts.JSON {
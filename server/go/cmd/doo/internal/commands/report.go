@@ -5,13 +5,13 @@ import (
 	"os"
 	"strings"
 
+	dootask "github.com/dootask/tools/server/go"
 	"github.com/dootask/tools/server/go/cmd/doo/internal/cli"
 	"github.com/spf13/cobra"
 )
 
-// report 全部走兜底端点（SDK 暂无报告域），标记为实验性。
 func newReportCmd() *cobra.Command {
-	cmd := &cobra.Command{Use: "report", Short: "工作报告（实验性）"}
+	cmd := &cobra.Command{Use: "report", Short: "工作报告"}
 	cmd.AddCommand(
 		newReportReceivedCmd(),
 		newReportMyCmd(),
@@ -35,11 +35,11 @@ func newReportUnreadCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			var out any
-			if err := c.NewGetRequest("/api/report/unread", nil, &out); err != nil {
+			total, err := c.GetUnreadReportCount()
+			if err != nil {
 				return err
 			}
-			return cli.Output(out, nil)
+			return cli.Output(map[string]any{"total": total}, nil)
 		},
 	}
 }
@@ -62,21 +62,13 @@ func newReportAnalyzeCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			body := map[string]any{"id": id, "text": text}
-			if model != "" {
-				body["model"] = model
-			}
-			if focus != "" {
-				var fs []string
-				for _, s := range strings.Split(focus, ",") {
-					if s = strings.TrimSpace(s); s != "" {
-						fs = append(fs, s)
-					}
+			req := dootask.SaveReportAnalysisRequest{ID: id, Text: text, Model: model}
+			for _, s := range strings.Split(focus, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					req.Focus = append(req.Focus, s)
 				}
-				body["focus"] = fs
 			}
-			var out any
-			if err := c.NewPostRequest("/api/report/analysave", body, &out); err != nil {
+			if err := c.SaveReportAnalysis(req); err != nil {
 				return err
 			}
 			cli.OK("✓ 已保存报告 #%d 的 AI 分析", id)
@@ -120,17 +112,13 @@ func newReportShareCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			params := map[string]any{"id": ids}
-			if len(dialogIDs) > 0 {
-				params["dialogids"] = dialogIDs
-			}
-			if len(userIDs) > 0 {
-				params["userids"] = userIDs
-			}
-			if message != "" {
-				params["leave_message"] = message
-			}
-			if err := c.NewGetRequest("/api/report/share", params, nil); err != nil {
+			err = c.ShareReports(dootask.ShareReportsRequest{
+				IDs:          ids,
+				DialogIDs:    dialogIDs,
+				UserIDs:      userIDs,
+				LeaveMessage: message,
+			})
+			if err != nil {
 				return err
 			}
 			cli.OK("✓ 已分享 %d 份报告", len(ids))
@@ -156,21 +144,13 @@ func newReportReceivedCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			params := map[string]any{}
-			if typ != "" {
-				params["keys[type]"] = typ
-			}
-			if status != "" {
-				params["keys[status]"] = status
-			}
-			if search != "" {
-				params["keys[key]"] = search
-			}
-			var out any
-			if err := c.NewGetRequest("/api/report/receive", params, &out); err != nil {
+			reports, err := c.ListReceivedReports(dootask.ReportListRequest{
+				Keys: dootask.ReportListKeys{Key: search, Type: typ, Status: status},
+			})
+			if err != nil {
 				return err
 			}
-			return cli.Output(out, reportCols)
+			return cli.Output(reports, reportCols)
 		},
 	}
 	f := cmd.Flags()
@@ -190,18 +170,13 @@ func newReportMyCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			params := map[string]any{}
-			if typ != "" {
-				params["keys[type]"] = typ
-			}
-			if search != "" {
-				params["keys[key]"] = search
-			}
-			var out any
-			if err := c.NewGetRequest("/api/report/my", params, &out); err != nil {
+			reports, err := c.ListMyReports(dootask.ReportListRequest{
+				Keys: dootask.ReportListKeys{Key: search, Type: typ},
+			})
+			if err != nil {
 				return err
 			}
-			return cli.Output(out, reportCols)
+			return cli.Output(reports, reportCols)
 		},
 	}
 	cmd.Flags().StringVar(&typ, "type", "", "类型 weekly|daily|all")
@@ -223,11 +198,11 @@ func newReportViewCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			var out any
-			if err := c.NewGetRequest("/api/report/detail", map[string]any{"id": id}, &out); err != nil {
+			report, err := c.GetReport(id)
+			if err != nil {
 				return err
 			}
-			return cli.Output(out, nil)
+			return cli.Output(report, nil)
 		},
 	}
 }
@@ -246,11 +221,11 @@ func newReportTemplateCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			var out any
-			if err := c.NewGetRequest("/api/report/template", map[string]any{"type": typ, "offset": offset}, &out); err != nil {
+			tmpl, err := c.GetReportTemplate(dootask.ReportTemplateRequest{Type: typ, Offset: offset})
+			if err != nil {
 				return err
 			}
-			return cli.Output(out, nil)
+			return cli.Output(tmpl, nil)
 		},
 	}
 	cmd.Flags().StringVar(&typ, "type", "", "类型 weekly|daily（必填）")
@@ -287,19 +262,19 @@ func newReportSubmitCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			body := map[string]any{"type": typ, "title": title, "content": content, "offset": offset}
-			if len(receiveIDs) > 0 {
-				body["receive"] = receiveIDs
-			}
-			if sign != "" {
-				body["sign"] = sign
-			}
-			var out any
-			if err := c.NewPostRequest("/api/report/store", body, &out); err != nil {
+			report, err := c.SubmitReport(dootask.SubmitReportRequest{
+				Type:    typ,
+				Title:   title,
+				Content: content,
+				Sign:    sign,
+				Receive: receiveIDs,
+				Offset:  offset,
+			})
+			if err != nil {
 				return err
 			}
 			if cli.Opts.JSON {
-				return cli.Output(out, nil)
+				return cli.Output(report, nil)
 			}
 			cli.OK("✓ 已提交报告：%s", title)
 			return nil
@@ -335,8 +310,7 @@ func newReportMarkCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			var out any
-			if err := c.NewGetRequest("/api/report/mark", map[string]any{"id": ids, "action": action}, &out); err != nil {
+			if err := c.MarkReports(ids, action); err != nil {
 				return err
 			}
 			cli.OK("✓ 已标记 %d 份报告为 %s", len(ids), action)
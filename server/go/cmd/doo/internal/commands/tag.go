@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 
+	dootask "github.com/dootask/tools/server/go"
 	"github.com/dootask/tools/server/go/cmd/doo/internal/cli"
 	"github.com/spf13/cobra"
 )
@@ -31,11 +32,11 @@ func newTagListCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			var out any
-			if err := c.NewGetRequest("/api/project/tag/list", map[string]any{"project_id": project}, &out); err != nil {
+			tags, err := c.ListProjectTags(project)
+			if err != nil {
 				return err
 			}
-			return cli.Output(out, []string{"id", "name", "color"})
+			return cli.Output(tags, []string{"id", "name", "color", "desc"})
 		},
 	}
 	cmd.Flags().IntVar(&project, "project", 0, "项目 ID（必填）")
@@ -62,22 +63,20 @@ func newTagSaveCmd(update bool) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			params := map[string]any{"project_id": project, "name": name, "color": color, "desc": desc}
+			req := dootask.SaveProjectTagRequest{ProjectID: project, Name: name, Color: color, Desc: desc}
 			if update {
-				id, err := cli.ParseInt(args[0], "标签ID")
-				if err != nil {
+				if req.ID, err = cli.ParseInt(args[0], "标签ID"); err != nil {
 					return err
 				}
-				params["id"] = id
 			}
-			var out any
-			if err := c.NewGetRequest("/api/project/tag/save", params, &out); err != nil {
+			tag, err := c.SaveProjectTag(req)
+			if err != nil {
 				return err
 			}
 			if cli.Opts.JSON {
-				return cli.Output(out, nil)
+				return cli.Output(tag, nil)
 			}
-			cli.OK("✓ 已保存标签：%s", name)
+			cli.OK("✓ 已保存标签 #%d：%s", tag.ID, tag.Name)
 			return nil
 		},
 	}
@@ -103,7 +102,7 @@ func newTagDeleteCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			if err := c.NewGetRequest("/api/project/tag/delete", map[string]any{"id": id}, nil); err != nil {
+			if err := c.DeleteProjectTag(id); err != nil {
 				return err
 			}
 			cli.OK("✓ 已删除标签 #%d", id)
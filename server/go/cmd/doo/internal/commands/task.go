@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"time"
 
 	dootask "github.com/dootask/tools/server/go"
 	"github.com/dootask/tools/server/go/cmd/doo/internal/cli"
@@ -23,6 +22,7 @@ func newTaskCmd() *cobra.Command {
 		newTaskSubtaskCmd(),
 		newTaskUpdateCmd(),
 		newTaskMoveCmd(),
+		newTaskTransitionCmd(),
 		newTaskDoneCmd(false),
 		newTaskDoneCmd(true),
 		newTaskDialogCmd(),
@@ -52,15 +52,7 @@ func newTaskListCmd() *cobra.Command {
 			if parent != 0 {
 				params["parent_id"] = parent
 			}
-			if status != "" {
-				params["keys[status]"] = status
-			}
-			if search != "" {
-				params["keys[name]"] = search
-			}
-			if tag != "" {
-				params["keys[tag]"] = tag
-			}
+			params["keys"] = dootask.TaskListKeys{Name: search, Status: status, Tag: tag}
 			if archived != "" {
 				params["archived"] = archived
 			}
@@ -264,7 +256,7 @@ func newTaskSubtaskCmd() *cobra.Command {
 }
 
 func newTaskUpdateCmd() *cobra.Command {
-	var name, content, start, end, owner, assist, color, tag, visibility string
+	var name, content, start, end, owner, assist, color, tag, addTag, removeTag, visibility string
 	var flow int
 	cmd := &cobra.Command{
 		Use:   "update <任务ID>",
@@ -279,61 +271,77 @@ func newTaskUpdateCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			// 仅提交用户实际修改的字段：SDK 结构体无 omitempty，整体提交会误清空字段。
+			// 仅提交用户实际修改的字段（未 Set 的字段不会发送）
 			f := cmd.Flags()
-			params := map[string]any{"task_id": id}
+			req := dootask.UpdateTaskRequest{TaskID: id}
+			changed := false
 			if f.Changed("name") {
-				params["name"] = name
+				req.Name, changed = dootask.Set(name), true
 			}
 			if f.Changed("content") {
-				params["content"] = content
+				req.Content, changed = dootask.Set(content), true
 			}
 			if f.Changed("start") || f.Changed("end") {
-				params["times"] = cli.BuildTimes(start, end)
+				req.Times, changed = dootask.Set(cli.BuildTimes(start, end)), true
 			}
 			if f.Changed("owner") {
 				ids, err := cli.ParseIDList(owner)
 				if err != nil {
 					return err
 				}
-				params["owner"] = ids
+				req.Owner, changed = dootask.Set(ids), true
 			}
 			if f.Changed("assist") {
 				ids, err := cli.ParseIDList(assist)
 				if err != nil {
 					return err
 				}
-				params["assist"] = ids
+				req.Assist, changed = dootask.Set(ids), true
 			}
 			if f.Changed("color") {
-				params["color"] = color
+				req.Color, changed = dootask.Set(color), true
 			}
 			if f.Changed("tag") {
-				// 后端 task_tag 收 [{name,color}] 对象数组（每任务自由标签），非 palette tag id。
-				// 入参形如 "紧急:#FF0000,重要"；空串表示清空全部标签。
-				var tags []map[string]string
-				for _, tok := range strings.Split(tag, ",") {
-					tok = strings.TrimSpace(tok)
-					if tok == "" {
-						continue
-					}
-					name, color, _ := strings.Cut(tok, ":")
-					tags = append(tags, map[string]string{"name": strings.TrimSpace(name), "color": strings.TrimSpace(color)})
+				// 后端 task_tag 收 [{name,color}] 对象数组（每任务自由标签），非 palette tag id；空串表示清空全部标签。
+				if f.Changed("add-tag") || f.Changed("remove-tag") {
+					return fmt.Errorf("--tag 不能与 --add-tag/--remove-tag 同时使用")
 				}
-				params["task_tag"] = tags
+				req.TaskTag, changed = dootask.Set(parseTagItems(tag)), true
 			}
 			if f.Changed("flow") {
-				params["flow_item_id"] = flow
+				req.FlowItemID, changed = dootask.Set(flow), true
 			}
 			if f.Changed("visibility") {
-				params["visibility"] = visibility
+				v, err := cli.ParseInt(visibility, "可见性")
+				if err != nil {
+					return err
+				}
+				req.Visibility, changed = dootask.Set(v), true
 			}
-			if len(params) == 1 {
+			editTags := f.Changed("add-tag") || f.Changed("remove-tag")
+			if !changed && !editTags {
 				return fmt.Errorf("没有要更新的字段")
 			}
-			var out map[string]any
-			if err := c.NewPostRequest("/api/project/task/update", params, &out); err != nil {
-				return err
+			var out any
+			if changed {
+				if out, err = c.UpdateTask(req); err != nil {
+					return err
+				}
+			}
+			// 增删标签在服务端当前标签上合并，不影响其余标签
+			if f.Changed("add-tag") {
+				if out, err = c.AddTaskTags(id, parseTagItems(addTag)...); err != nil {
+					return err
+				}
+			}
+			if f.Changed("remove-tag") {
+				var names []string
+				for _, t := range parseTagItems(removeTag) {
+					names = append(names, t.Name)
+				}
+				if out, err = c.RemoveTaskTags(id, names...); err != nil {
+					return err
+				}
 			}
 			if cli.Opts.JSON {
 				return cli.Output(out, nil)
@@ -350,12 +358,28 @@ func newTaskUpdateCmd() *cobra.Command {
 	f.StringVar(&owner, "owner", "", "负责人 ID 列表")
 	f.StringVar(&assist, "assist", "", "协助者 ID 列表")
 	f.StringVar(&color, "color", "", "颜色")
-	f.StringVar(&tag, "tag", "", "任务标签，逗号分隔 name[:color]（如 紧急:#FF0000,重要；空串清空）")
-	f.IntVar(&flow, "flow", 0, "工作流状态 ID（来自 flow list）")
-	f.StringVar(&visibility, "visibility", "", "可见性")
+	f.StringVar(&tag, "tag", "", "任务标签（整体替换），逗号分隔 name[:color]（如 紧急:#FF0000,重要；空串清空）")
+	f.StringVar(&addTag, "add-tag", "", "添加标签并保留已有标签，逗号分隔 name[:color]")
+	f.StringVar(&removeTag, "remove-tag", "", "按名称移除标签，逗号分隔")
+	f.IntVar(&flow, "flow", 0, "工作流状态 ID（来自 flow list；按名称流转用 task transition）")
+	f.StringVar(&visibility, "visibility", "", "可见性 1 项目人员|2 任务人员|3 指定成员")
 	return cmd
 }
 
+// parseTagItems 解析形如 "紧急:#FF0000,重要" 的标签列表
+func parseTagItems(s string) []dootask.TaskTagItem {
+	tags := []dootask.TaskTagItem{}
+	for _, tok := range strings.Split(s, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		name, color, _ := strings.Cut(tok, ":")
+		tags = append(tags, dootask.TaskTagItem{Name: strings.TrimSpace(name), Color: strings.TrimSpace(color)})
+	}
+	return tags
+}
+
 func newTaskMoveCmd() *cobra.Command {
 	var project, column, flow int
 	var owner, assist string
@@ -373,43 +397,36 @@ func newTaskMoveCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			// 未指定 --project 时 MoveTask 自动取任务当前所在项目（同项目内跨列移动只传 --column）
 			f := cmd.Flags()
-			// 后端 move 需要 project_id + column_id 定位目标列；未指定 --project 时
-			// 自动取任务当前所在项目（支持同项目内跨列移动只传 --column）。
-			if !f.Changed("project") {
-				one, err := c.GetTask(dootask.GetTaskRequest{TaskID: id})
-				if err != nil {
-					return err
-				}
-				project = one.ProjectID
-			}
-			params := map[string]any{"task_id": id, "project_id": project}
-			if f.Changed("column") {
-				params["column_id"] = column
-			}
+			req := dootask.MoveTaskRequest{TaskID: id, ProjectID: project, ColumnID: column}
 			if f.Changed("flow") {
-				params["flow_item_id"] = flow
+				req.FlowItemID = dootask.Set(flow)
 			}
 			if f.Changed("owner") {
 				ids, err := cli.ParseIDList(owner)
 				if err != nil {
 					return err
 				}
-				params["owner"] = ids
+				req.Owner = dootask.Set(ids)
 			}
 			if f.Changed("assist") {
 				ids, err := cli.ParseIDList(assist)
 				if err != nil {
 					return err
 				}
-				params["assist"] = ids
+				req.Assist = dootask.Set(ids)
 			}
 			if f.Changed("completed") {
-				params["completed"] = completed
+				req.Completed = dootask.Set(completed)
 			}
-			if err := c.NewGetRequest("/api/project/task/move", params, nil); err != nil {
+			task, err := c.MoveTask(req)
+			if err != nil {
 				return err
 			}
+			if cli.Opts.JSON {
+				return cli.Output(task, nil)
+			}
 			cli.OK("✓ 已移动任务 #%d", id)
 			return nil
 		},
@@ -417,18 +434,45 @@ func newTaskMoveCmd() *cobra.Command {
 	f := cmd.Flags()
 	f.IntVar(&project, "project", 0, "目标项目 ID")
 	f.IntVar(&column, "column", 0, "目标看板列 ID")
-	f.IntVar(&flow, "flow", 0, "目标工作流状态 ID（来自 flow list）")
+	f.IntVar(&flow, "flow", 0, "目标工作流状态 ID（来自 flow list；按名称流转用 task transition）")
 	f.StringVar(&owner, "owner", "", "负责人 ID 列表")
 	f.StringVar(&assist, "assist", "", "协助者 ID 列表")
 	f.BoolVar(&completed, "completed", false, "同时标记完成/未完成")
 	return cmd
 }
 
+func newTaskTransitionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "transition <任务ID> <状态名称>",
+		Short: "按名称流转任务的工作流状态（本地校验是否允许流转）",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := cli.ParseInt(args[0], "任务ID")
+			if err != nil {
+				return err
+			}
+			c, err := cli.Opts.Client()
+			if err != nil {
+				return err
+			}
+			task, err := c.TransitionTask(id, args[1])
+			if err != nil {
+				return err
+			}
+			if cli.Opts.JSON {
+				return cli.Output(task, nil)
+			}
+			cli.OK("✓ 任务 #%d 已流转到 %s", id, task.FlowItemName)
+			return nil
+		},
+	}
+}
+
 // newTaskDoneCmd 复用一个构造器实现 done / undone。
 func newTaskDoneCmd(undone bool) *cobra.Command {
 	use, short := "done <任务ID>", "标记任务完成"
 	if undone {
-		use, short = "undone <任务ID>", "取消任务完成（实验性）"
+		use, short = "undone <任务ID>", "取消任务完成"
 	}
 	return &cobra.Command{
 		Use:   use,
@@ -443,16 +487,17 @@ func newTaskDoneCmd(undone bool) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			// 服务端要求 complete_at 为日期字符串才标记完成（实际取 now()）；
-			// 传 false（非日期）则标记未完成。仅提交 task_id+complete_at，避免清空其它字段。
-			var completeAt any = false
-			if !undone {
-				completeAt = time.Now().Format("2006-01-02 15:04:05")
+			complete := c.CompleteTask
+			if undone {
+				complete = c.ReopenTask
 			}
-			params := map[string]any{"task_id": id, "complete_at": completeAt}
-			if err := c.NewPostRequest("/api/project/task/update", params, nil); err != nil {
+			task, err := complete(id)
+			if err != nil {
 				return err
 			}
+			if cli.Opts.JSON {
+				return cli.Output(task, nil)
+			}
 			if undone {
 				cli.OK("✓ 已取消完成 #%d", id)
 			} else {
@@ -0,0 +1,156 @@
+package dootasktest
+
+import (
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ------------------------------------------------------------------------------------------
+// 种子数据
+// ------------------------------------------------------------------------------------------
+
+// 默认种子数据中的 token 与 ID
+const (
+	AdminToken  = "test-admin-token"  // 管理员 token
+	MemberToken = "test-member-token" // 普通成员 token
+	GuestToken  = "test-guest-token"  // 访客 token
+	BotToken    = "test-bot-token"    // 机器人 token
+
+	Password    = "test-password" // 种子用户（机器人除外）的登录密码
+	CaptchaCode = "8888"          // 登录验证码的答案（见 Fixtures.LoginCaptcha）
+
+	AdminUserID  = 1  // 管理员用户ID
+	MemberUserID = 2  // 普通成员用户ID
+	GuestUserID  = 3  // 访客用户ID
+	BotUserID    = 10 // 机器人用户ID（同时是机器人ID）
+
+	DirectDialogID  = 1 // 管理员与普通成员的单聊
+	GroupDialogID   = 2 // 三人群聊
+	ProjectDialogID = 3 // 默认项目的项目群
+
+	ProjectID     = 1 // 默认项目
+	TodoColumnID  = 1 // 默认项目的「待办」列表
+	DoingColumnID = 2 // 默认项目的「进行中」列表
+	DoneColumnID  = 3 // 默认项目的「已完成」列表
+	TaskID        = 1 // 默认项目中的任务
+
+	ReportID = 1 // 普通成员发给管理员的周报
+
+	FolderID = 1 // 管理员的「文档」文件夹
+	FileID   = 2 // 「文档」文件夹中的 readme.txt
+)
+
+// User 用户
+type User struct {
+	dootask.UserInfo
+	Token          string    // 登录 token，空表示无法调用接口
+	TokenExpiredAt time.Time // token 过期时间，零值表示不过期
+	Password       string    // 登录密码，空表示无法用密码登录
+	Online         bool      // 是否在线
+}
+
+// Dialog 对话
+type Dialog struct {
+	dootask.DialogInfo
+	Members []int // 成员ID
+}
+
+// Project 项目
+type Project struct {
+	dootask.Project
+	Members []int // 成员ID
+}
+
+// Task 任务
+type Task struct {
+	dootask.ProjectTask
+	Content string // 任务详细内容
+	Owners  []int  // 负责人ID
+	Assists []int  // 协助人ID
+	Deleted bool   // 是否已删除
+}
+
+// File 文件或文件夹
+type File struct {
+	dootask.File
+	Data   []byte              // 文件内容
+	Shares []dootask.FileShare // 共享成员
+	Link   string              // 分享链接，空表示尚未生成
+}
+
+// Report 工作报告
+type Report struct {
+	dootask.Report
+	ReadBy   []int  // 已读的接收人ID
+	Analysis string // AI 分析结果
+}
+
+// Fixtures 服务启动时载入的数据，ID 为 0 的条目自动分配ID
+type Fixtures struct {
+	Users       []User
+	Departments []dootask.Department
+	Bots        []dootask.Bot
+	Dialogs     []Dialog
+	Messages    []dootask.DialogMessage
+	Projects    []Project
+	Columns     []dootask.ProjectColumn
+	Tasks       []Task
+	Flows       []dootask.ProjectFlow
+	Tags        []dootask.ProjectTag
+	Reports     []Report
+	Files       []File
+	Settings    dootask.SystemSettings
+	Version     string
+
+	LoginCaptcha bool // 密码登录是否需要验证码
+}
+
+// DefaultFixtures 返回默认种子数据：三个用户与一个机器人、一个单聊与一个群聊、
+// 一个带三个列表与一个任务的项目、一份周报、一个包含文本文件的文件夹
+func DefaultFixtures() Fixtures {
+	reg, alias := "open", "DooTask"
+	return Fixtures{
+		Users: []User{
+			{UserInfo: dootask.UserInfo{UserID: AdminUserID, Identity: []string{"admin"}, Email: "admin@dootask.com", Nickname: "管理员", Department: []int{1}, DepartmentName: "总部"}, Token: AdminToken, Password: Password, Online: true},
+			{UserInfo: dootask.UserInfo{UserID: MemberUserID, Email: "member@dootask.com", Nickname: "成员", Department: []int{1}, DepartmentName: "总部"}, Token: MemberToken, Password: Password, Online: true},
+			{UserInfo: dootask.UserInfo{UserID: GuestUserID, Email: "guest@dootask.com", Nickname: "访客"}, Token: GuestToken, Password: Password},
+			{UserInfo: dootask.UserInfo{UserID: BotUserID, Email: "bot@bot.system", Nickname: "测试机器人", Bot: 1}, Token: BotToken, Online: true},
+		},
+		Departments: []dootask.Department{
+			{ID: 1, Name: "总部", OwnerUserID: AdminUserID},
+		},
+		Bots: []dootask.Bot{
+			{ID: BotUserID, Name: "测试机器人", ClearDay: 30},
+		},
+		Dialogs: []Dialog{
+			{DialogInfo: dootask.DialogInfo{ID: DirectDialogID, Type: "user", Name: "成员"}, Members: []int{AdminUserID, MemberUserID}},
+			{DialogInfo: dootask.DialogInfo{ID: GroupDialogID, Type: "group", GroupType: "user", Name: "测试群组", OwnerID: AdminUserID}, Members: []int{AdminUserID, MemberUserID, GuestUserID}},
+			{DialogInfo: dootask.DialogInfo{ID: ProjectDialogID, Type: "group", GroupType: "project", Name: "默认项目", OwnerID: AdminUserID}, Members: []int{AdminUserID, MemberUserID}},
+		},
+		Messages: []dootask.DialogMessage{
+			{DialogID: DirectDialogID, UserID: MemberUserID, Type: "text", Msg: map[string]any{"text": "你好", "type": "md"}},
+			{DialogID: GroupDialogID, UserID: AdminUserID, Type: "text", Msg: map[string]any{"text": "欢迎加入", "type": "md"}},
+		},
+		Projects: []Project{
+			{Project: dootask.Project{ID: ProjectID, Name: "默认项目", Desc: "种子数据", UserID: AdminUserID, DialogID: ProjectDialogID, OwnerUserID: AdminUserID}, Members: []int{AdminUserID, MemberUserID}},
+		},
+		Columns: []dootask.ProjectColumn{
+			{ID: TodoColumnID, ProjectID: ProjectID, Name: "待办", Sort: 0},
+			{ID: DoingColumnID, ProjectID: ProjectID, Name: "进行中", Sort: 1},
+			{ID: DoneColumnID, ProjectID: ProjectID, Name: "已完成", Sort: 2},
+		},
+		Tasks: []Task{
+			{ProjectTask: dootask.ProjectTask{ID: TaskID, ProjectID: ProjectID, ColumnID: TodoColumnID, Name: "默认任务", UserID: AdminUserID}, Content: "任务内容", Owners: []int{AdminUserID}},
+		},
+		Reports: []Report{
+			{Report: dootask.Report{ID: ReportID, Title: "成员的周报", Type: "weekly", UserID: MemberUserID, Sign: "weekly-member", Content: "<p>本周完成默认任务</p>", Receives: []int{AdminUserID}}},
+		},
+		Files: []File{
+			{File: dootask.File{ID: FolderID, Name: "文档", Type: "folder", UserID: AdminUserID, CreatedID: AdminUserID}},
+			{File: dootask.File{ID: FileID, PID: FolderID, Name: "readme", Type: "file", Ext: "txt", Size: 13, UserID: AdminUserID, CreatedID: AdminUserID}, Data: []byte("Hello DooTask")},
+		},
+		Settings: dootask.SystemSettings{Reg: &reg, SystemAlias: &alias},
+		Version:  "1.0.0",
+	}
+}
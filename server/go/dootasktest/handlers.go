@@ -0,0 +1,2531 @@
+package dootasktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// registerRoutes 注册内置接口
+func (s *Server) registerRoutes() {
+	s.routes = make(map[string]HandlerFunc)
+
+	// 用户
+	s.route("/api/users/login", s.userLogin)
+	s.route("/api/users/login/codejson", s.userLoginCaptcha)
+	s.route("/api/users/token/expire", s.userTokenExpire)
+	s.route("/api/users/info", s.userInfo)
+	s.route("/api/users/info/departments", s.userDepartments)
+	s.route("/api/users/basic", s.userBasic)
+
+	// 机器人
+	s.route("/api/users/bot/list", s.botList)
+	s.route("/api/users/bot/info", s.botInfo)
+	s.route("/api/users/bot/edit", s.botEdit)
+	s.route("/api/users/bot/delete", s.botDelete)
+
+	// 消息
+	s.route("/api/dialog/msg/sendtext", s.msgSendText)
+	s.route("/api/dialog/msg/sendbot", s.msgSendToUser)
+	s.route("/api/dialog/msg/sendanon", s.msgSendToUser)
+	s.route("/api/dialog/msg/stream", s.msgSendToUser)
+	s.route("/api/dialog/msg/sendnotice", s.msgSendNotice)
+	s.route("/api/dialog/msg/sendtemplate", s.msgSendTemplate)
+	s.route("/api/dialog/msg/list", s.msgList)
+	s.route("/api/dialog/msg/one", s.msgOne)
+	s.route("/api/dialog/msg/detail", s.msgOne)
+	s.route("/api/dialog/msg/withdraw", s.msgWithdraw)
+	s.route("/api/dialog/msg/forward", s.msgForward)
+	s.route("/api/dialog/msg/todo", s.msgTodo)
+	s.route("/api/dialog/msg/todolist", s.msgTodoList)
+	s.route("/api/dialog/msg/done", s.msgDone)
+	s.route("/api/dialog/msg/webhookmsg2ai", s.msgToAI)
+	s.route("/api/search/message", s.msgSearch)
+	s.route("/api/search/task", s.searchTask)
+	s.route("/api/search/project", s.searchProject)
+	s.route("/api/search/file", s.searchFile)
+	s.route("/api/search/contact", s.searchContact)
+
+	// 对话
+	s.route("/api/dialog/lists", s.dialogList)
+	s.route("/api/dialog/search", s.dialogSearch)
+	s.route("/api/dialog/one", s.dialogOne)
+	s.route("/api/dialog/user", s.dialogUser)
+	s.route("/api/dialog/open/user", s.dialogOpenUser)
+
+	// 群组
+	s.route("/api/dialog/group/add", s.groupAdd)
+	s.route("/api/dialog/group/edit", s.groupEdit)
+	s.route("/api/dialog/group/adduser", s.groupAddUser)
+	s.route("/api/dialog/group/deluser", s.groupDelUser)
+	s.route("/api/dialog/group/transfer", s.groupTransfer)
+	s.route("/api/dialog/group/disband", s.groupDisband)
+
+	// 项目
+	s.route("/api/project/lists", s.projectList)
+	s.route("/api/project/one", s.projectOne)
+	s.route("/api/project/add", s.projectAdd)
+	s.route("/api/project/update", s.projectUpdate)
+	s.route("/api/project/exit", s.projectExit)
+	s.route("/api/project/remove", s.projectRemove)
+
+	// 列表
+	s.route("/api/project/column/lists", s.columnList)
+	s.route("/api/project/column/add", s.columnAdd)
+	s.route("/api/project/column/update", s.columnUpdate)
+	s.route("/api/project/column/remove", s.columnRemove)
+
+	// 任务
+	s.route("/api/project/task/lists", s.taskList)
+	s.route("/api/project/task/one", s.taskOne)
+	s.route("/api/project/task/content", s.taskContent)
+	s.route("/api/project/task/files", s.taskFiles)
+	s.route("/api/project/task/add", s.taskAdd)
+	s.route("/api/project/task/addsub", s.taskAddSub)
+	s.route("/api/project/task/update", s.taskUpdate)
+	s.route("/api/project/task/dialog", s.taskDialog)
+	s.route("/api/project/task/archived", s.taskArchived)
+	s.route("/api/project/task/remove", s.taskRemove)
+	s.route("/api/project/task/move", s.taskMove)
+
+	// 工作流
+	s.route("/api/project/flow/list", s.flowList)
+	s.route("/api/project/flow/save", s.flowSave)
+	s.route("/api/project/flow/delete", s.flowDelete)
+
+	// 标签
+	s.route("/api/project/tag/list", s.tagList)
+	s.route("/api/project/tag/save", s.tagSave)
+	s.route("/api/project/tag/delete", s.tagDelete)
+
+	// 报告
+	s.route("/api/report/receive", s.reportReceive)
+	s.route("/api/report/my", s.reportMy)
+	s.route("/api/report/detail", s.reportDetail)
+	s.route("/api/report/template", s.reportTemplate)
+	s.route("/api/report/store", s.reportStore)
+	s.route("/api/report/mark", s.reportMark)
+	s.route("/api/report/unread", s.reportUnread)
+	s.route("/api/report/analysave", s.reportAnalysave)
+	s.route("/api/report/share", s.reportShare)
+
+	// 文件
+	s.route("/api/file/lists", s.fileList)
+	s.route("/api/file/search", s.fileSearch)
+	s.route("/api/file/one", s.fileOne)
+	s.route("/api/file/content", s.fileContent)
+	s.route("/api/file/content/upload", s.fileUpload)
+	s.route("/api/file/fetch", s.fileFetch)
+	s.route("/api/file/add", s.fileAdd)
+	s.route("/api/file/move", s.fileMove)
+	s.route("/api/file/remove", s.fileRemove)
+	s.route("/api/file/link", s.fileLink)
+	s.route("/api/file/share", s.fileShare)
+	s.route("/api/file/share/update", s.fileShareUpdate)
+	s.route("/api/file/share/out", s.fileShareOut)
+
+	// 系统
+	s.route("/api/system/setting", s.systemSetting)
+	s.route("/api/system/version", s.systemVersion)
+	s.public["/api/users/login"] = true
+	s.public["/api/users/login/codejson"] = true
+	s.public["/api/system/setting"] = true
+	s.public["/api/system/version"] = true
+}
+
+// ------------------------------------------------------------------------------------------
+// 用户
+// ------------------------------------------------------------------------------------------
+
+// captchaImage 验证码图片（1x1 PNG）
+const captchaImage = "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+
+func (s *Server) userLogin(r *Request) (any, error) {
+	if r.Params.String("type") != "login" {
+		return nil, Errorf("仅支持登录")
+	}
+	if s.fixtures.LoginCaptcha {
+		key := r.Params.String("code_key")
+		if r.Params.String("code") == "" {
+			return nil, &Error{Msg: "请输入验证码", Data: map[string]any{"code": "need"}}
+		}
+		if !s.captchas[key] || !strings.EqualFold(r.Params.String("code"), CaptchaCode) {
+			delete(s.captchas, key)
+			return nil, &Error{Msg: "请输入正确的验证码", Data: map[string]any{"code": "need"}}
+		}
+		delete(s.captchas, key)
+	}
+	var user *User
+	for _, u := range s.users {
+		if u.Email == r.Params.String("email") {
+			user = u
+		}
+	}
+	if user == nil || user.Password == "" || user.Password != r.Params.String("password") {
+		return nil, Errorf("帐号或密码错误")
+	}
+	if user.Token == "" || user.tokenExpired() {
+		user.Token = fmt.Sprintf("test-token-%d", s.nextID("token"))
+		user.TokenExpiredAt = time.Time{}
+	}
+	return dootask.LoginResult{UserInfo: user.UserInfo, Token: user.Token}, nil
+}
+
+func (s *Server) userLoginCaptcha(r *Request) (any, error) {
+	key := fmt.Sprintf("captcha-%d", s.nextID("captcha"))
+	s.captchas[key] = true
+	return dootask.Captcha{Key: key, Image: captchaImage}, nil
+}
+
+func (s *Server) userTokenExpire(r *Request) (any, error) {
+	u := s.users[r.UserID]
+	out := dootask.TokenExpire{}
+	if !u.TokenExpiredAt.IsZero() {
+		out.ExpiredAt = u.TokenExpiredAt.Format(time.DateTime)
+		out.RemainingSeconds = int64(time.Until(u.TokenExpiredAt).Seconds())
+	}
+	return out, nil
+}
+
+func (s *Server) userInfo(r *Request) (any, error) {
+	return s.users[r.UserID].UserInfo, nil
+}
+
+func (s *Server) userDepartments(r *Request) (any, error) {
+	u := s.users[r.UserID]
+	out := []dootask.Department{}
+	for _, d := range s.fixtures.Departments {
+		if slices.Contains(u.Department, d.ID) {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (s *Server) userBasic(r *Request) (any, error) {
+	out := []dootask.UserBasic{}
+	for _, id := range r.Params.Ints("userid") {
+		u, ok := s.users[id]
+		if !ok {
+			continue
+		}
+		out = append(out, dootask.UserBasic{
+			UserID:         u.UserID,
+			Email:          u.Email,
+			Nickname:       u.Nickname,
+			Profession:     u.Profession,
+			UserImg:        u.UserImg,
+			Bot:            u.Bot,
+			Online:         u.Online,
+			Department:     u.Department,
+			DepartmentName: u.DepartmentName,
+		})
+	}
+	return out, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 机器人
+// ------------------------------------------------------------------------------------------
+
+func (s *Server) botList(r *Request) (any, error) {
+	list := []dootask.Bot{}
+	for _, id := range sortedIDs(s.bots) {
+		list = append(list, *s.bots[id])
+	}
+	return dootask.BotListResponse{List: list}, nil
+}
+
+func (s *Server) botInfo(r *Request) (any, error) {
+	b, ok := s.bots[r.Params.Int("id")]
+	if !ok {
+		return nil, Errorf("机器人不存在")
+	}
+	return *b, nil
+}
+
+func (s *Server) botEdit(r *Request) (any, error) {
+	p := r.Params
+	b, ok := s.bots[p.Int("id")]
+	if p.Int("id") == 0 {
+		if p.String("name") == "" {
+			return nil, Errorf("请输入机器人名称")
+		}
+		id := s.nextID("user")
+		b = &dootask.Bot{ID: id}
+		s.bots[id] = b
+		s.users[id] = &User{
+			UserInfo: dootask.UserInfo{UserID: uint(id), Email: fmt.Sprintf("bot-%d@bot.system", id), Bot: 1},
+			Token:    fmt.Sprintf("test-bot-token-%d", id),
+			Online:   true,
+		}
+	} else if !ok {
+		return nil, Errorf("机器人不存在")
+	}
+	if p.Has("name") && p.String("name") != "" {
+		b.Name = p.String("name")
+		s.users[b.ID].Nickname = b.Name
+	}
+	if p.Has("avatar") {
+		b.Avatar = p.String("avatar")
+	}
+	if p.Has("clear_day") {
+		b.ClearDay = p.Int("clear_day")
+	}
+	if p.Has("webhook_url") {
+		b.WebhookURL = p.String("webhook_url")
+	}
+	return *b, nil
+}
+
+func (s *Server) botDelete(r *Request) (any, error) {
+	id := r.Params.Int("id")
+	if _, ok := s.bots[id]; !ok {
+		return nil, Errorf("机器人不存在")
+	}
+	if r.Params.String("remark") == "" {
+		return nil, Errorf("请输入删除备注")
+	}
+	delete(s.bots, id)
+	delete(s.users, id)
+	return nil, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 消息
+// ------------------------------------------------------------------------------------------
+
+// dialogFor 返回当前用户所在的对话
+func (s *Server) dialogFor(r *Request, dialogID int) (*Dialog, error) {
+	d, ok := s.dialogs[dialogID]
+	if !ok {
+		return nil, Errorf("会话不存在或已被删除")
+	}
+	if !slices.Contains(d.Members, r.UserID) && !s.isAdmin(r.UserID) {
+		return nil, Errorf("无权访问此会话")
+	}
+	return d, nil
+}
+
+// messageFor 返回当前用户可见的消息
+func (s *Server) messageFor(r *Request, msgID int) (*dootask.DialogMessage, error) {
+	m, ok := s.messages[msgID]
+	if !ok {
+		return nil, Errorf("消息不存在或已被删除")
+	}
+	if _, err := s.dialogFor(r, m.DialogID); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *Server) isAdmin(userID int) bool {
+	u, ok := s.users[userID]
+	return ok && slices.Contains(u.Identity, "admin")
+}
+
+// dialogMessages 返回对话中的消息（按ID升序），调用时需持有锁
+func (s *Server) dialogMessages(dialogID int) []*dootask.DialogMessage {
+	var out []*dootask.DialogMessage
+	for _, id := range sortedIDs(s.messages) {
+		if m := s.messages[id]; m.DialogID == dialogID {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// addMessage 在对话中新增消息
+func (s *Server) addMessage(dialogID, userID int, msgType string, msg any) *dootask.DialogMessage {
+	m := &dootask.DialogMessage{
+		ID:        s.nextID("message"),
+		DialogID:  dialogID,
+		UserID:    userID,
+		Type:      msgType,
+		Msg:       msg,
+		CreatedAt: now(),
+	}
+	if u, ok := s.users[userID]; ok {
+		m.Bot = u.Bot
+	}
+	s.messages[m.ID] = m
+	if d, ok := s.dialogs[dialogID]; ok {
+		d.LastAt = m.CreatedAt
+	}
+	return m
+}
+
+// messageText 返回消息的文本内容
+func messageText(m *dootask.DialogMessage) string {
+	if text, ok := m.AsText(); ok {
+		return text
+	}
+	if body, ok := m.Msg.(map[string]any); ok {
+		for _, key := range []string{"text", "notice", "title", "name"} {
+			if v, ok := body[key].(string); ok {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func (s *Server) msgSendText(r *Request) (any, error) {
+	p := r.Params
+	d, err := s.dialogFor(r, p.Int("dialog_id"))
+	if err != nil {
+		return nil, err
+	}
+	if p.String("text") == "" {
+		return nil, Errorf("消息内容不能为空")
+	}
+	textType := p.String("text_type")
+	if textType == "" {
+		textType = "md"
+	}
+	body := map[string]any{"text": p.String("text"), "type": textType}
+
+	if updateID := p.Int("update_id"); updateID > 0 {
+		m, ok := s.messages[updateID]
+		if !ok || m.DialogID != d.ID {
+			return nil, Errorf("消息不存在或已被删除")
+		}
+		if m.UserID != r.UserID {
+			return nil, Errorf("仅限修改自己的消息")
+		}
+		m.Msg = body
+		if p.String("update_mark") != "no" {
+			m.Modify = 1
+		}
+		return *m, nil
+	}
+
+	m := s.addMessage(d.ID, r.UserID, "text", body)
+	if replyID := p.Int("reply_id"); replyID > 0 {
+		if target, ok := s.messages[replyID]; ok && target.DialogID == d.ID {
+			m.ReplyID = replyID
+			target.ReplyNum++
+		}
+	}
+	return *m, nil
+}
+
+// msgSendToUser 发送给指定用户的消息（机器人消息、匿名消息、流式消息通知），只校验并记录
+func (s *Server) msgSendToUser(r *Request) (any, error) {
+	if _, ok := s.users[r.Params.Int("userid")]; !ok {
+		return nil, Errorf("用户不存在")
+	}
+	if r.Path == "/api/dialog/msg/stream" {
+		if r.Params.String("stream_url") == "" {
+			return nil, Errorf("stream_url 不能为空")
+		}
+	} else if r.Params.String("text") == "" {
+		return nil, Errorf("消息内容不能为空")
+	}
+	return nil, nil
+}
+
+// targetDialogs 解析 dialog_ids（逗号分隔，优先）或 dialog_id
+func (s *Server) targetDialogs(r *Request) ([]*Dialog, error) {
+	var ids []int
+	if v := r.Params.String("dialog_ids"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			ids = append(ids, Params{"id": strings.TrimSpace(part)}.Int("id"))
+		}
+	} else {
+		ids = []int{r.Params.Int("dialog_id")}
+	}
+	var out []*Dialog
+	for _, id := range ids {
+		d, err := s.dialogFor(r, id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func (s *Server) msgSendNotice(r *Request) (any, error) {
+	dialogs, err := s.targetDialogs(r)
+	if err != nil {
+		return nil, err
+	}
+	notice := r.Params.String("notice")
+	if notice == "" {
+		return nil, Errorf("通知内容不能为空")
+	}
+	for _, d := range dialogs {
+		s.addMessage(d.ID, r.UserID, "notice", map[string]any{"notice": notice})
+	}
+	return nil, nil
+}
+
+func (s *Server) msgSendTemplate(r *Request) (any, error) {
+	dialogs, err := s.targetDialogs(r)
+	if err != nil {
+		return nil, err
+	}
+	content, _ := r.Params["content"].([]any)
+	if len(content) == 0 {
+		return nil, Errorf("模板内容不能为空")
+	}
+	for _, d := range dialogs {
+		s.addMessage(d.ID, r.UserID, "template", map[string]any{"type": "content", "title": r.Params.String("title"), "content": content})
+	}
+	return nil, nil
+}
+
+func (s *Server) msgList(r *Request) (any, error) {
+	p := r.Params
+	d, err := s.dialogFor(r, p.Int("dialog_id"))
+	if err != nil {
+		return nil, err
+	}
+	take := p.Int("take")
+	if take <= 0 {
+		take = 50
+	}
+	take = min(take, 100)
+
+	msgType := p.String("msg_type")
+	var all []*dootask.DialogMessage
+	for _, m := range s.dialogMessages(d.ID) {
+		if msgType == "" || m.Type == msgType || (msgType == "tag" && m.Tag > 0) || (msgType == "todo" && m.Todo > 0) {
+			all = append(all, m)
+		}
+	}
+
+	list := []dootask.DialogMessage{}
+	if nextID := p.Int("next_id"); nextID > 0 {
+		// 新于 next_id 的消息，按ID升序
+		for _, m := range all {
+			if m.ID > nextID && len(list) < take {
+				list = append(list, *m)
+			}
+		}
+	} else {
+		// 早于 prev_id 的消息（缺省为最新），按ID降序
+		prevID := p.Int("prev_id")
+		for i := len(all) - 1; i >= 0 && len(list) < take; i-- {
+			if prevID <= 0 || all[i].ID < prevID {
+				list = append(list, *all[i])
+			}
+		}
+	}
+	return dootask.DialogMessageListResponse{List: list, Dialog: s.dialogInfo(d, r.UserID), Todo: []any{}}, nil
+}
+
+func (s *Server) msgOne(r *Request) (any, error) {
+	m, err := s.messageFor(r, r.Params.Int("msg_id"))
+	if err != nil {
+		return nil, err
+	}
+	return *m, nil
+}
+
+func (s *Server) msgWithdraw(r *Request) (any, error) {
+	m, err := s.messageFor(r, r.Params.Int("msg_id"))
+	if err != nil {
+		return nil, err
+	}
+	if m.UserID != r.UserID {
+		return nil, Errorf("仅限撤回自己的消息")
+	}
+	delete(s.messages, m.ID)
+	return nil, nil
+}
+
+func (s *Server) msgForward(r *Request) (any, error) {
+	m, err := s.messageFor(r, r.Params.Int("msg_id"))
+	if err != nil {
+		return nil, err
+	}
+	dialogIDs := r.Params.Ints("dialogids")
+	for _, uid := range r.Params.Ints("userids") {
+		dialogIDs = append(dialogIDs, s.openUserDialog(r.UserID, uid).ID)
+	}
+	if len(dialogIDs) == 0 {
+		return nil, Errorf("请选择转发对话或成员")
+	}
+	for _, id := range dialogIDs {
+		d, err := s.dialogFor(r, id)
+		if err != nil {
+			return nil, err
+		}
+		fm := s.addMessage(d.ID, r.UserID, m.Type, m.Msg)
+		fm.ForwardID = m.ID
+		m.ForwardNum++
+	}
+	return nil, nil
+}
+
+func (s *Server) msgTodo(r *Request) (any, error) {
+	m, err := s.messageFor(r, r.Params.Int("msg_id"))
+	if err != nil {
+		return nil, err
+	}
+	// 已有待办时取消，否则为对话成员（或指定用户）设置待办
+	var existing []int
+	for _, id := range sortedIDs(s.todos) {
+		if s.todos[id].MsgID == m.ID {
+			existing = append(existing, id)
+		}
+	}
+	if len(existing) > 0 {
+		for _, id := range existing {
+			delete(s.todos, id)
+		}
+		m.Todo = 0
+		return nil, nil
+	}
+
+	userIDs := s.dialogs[m.DialogID].Members
+	if t := r.Params.String("type"); t != "" && t != "all" {
+		userIDs = r.Params.Ints("userids")
+	}
+	for _, uid := range userIDs {
+		id := s.nextID("todo")
+		s.todos[id] = &dootask.TodoItem{ID: id, DialogID: m.DialogID, MsgID: m.ID, UserID: uid, CreatedAt: now()}
+	}
+	m.Todo = r.UserID
+	return nil, nil
+}
+
+func (s *Server) msgTodoList(r *Request) (any, error) {
+	m, err := s.messageFor(r, r.Params.Int("msg_id"))
+	if err != nil {
+		return nil, err
+	}
+	out := []dootask.TodoItem{}
+	for _, id := range sortedIDs(s.todos) {
+		if t := s.todos[id]; t.MsgID == m.ID {
+			out = append(out, *t)
+		}
+	}
+	return out, nil
+}
+
+func (s *Server) msgDone(r *Request) (any, error) {
+	t, ok := s.todos[r.Params.Int("id")]
+	if !ok {
+		return nil, Errorf("待办不存在或已被删除")
+	}
+	if t.DoneAt == "" {
+		t.DoneAt = now()
+	}
+	return nil, nil
+}
+
+func (s *Server) msgToAI(r *Request) (any, error) {
+	return dootask.ConvertWebhookMessageResponse{Msg: strings.TrimSpace(r.Params.String("msg"))}, nil
+}
+
+func (s *Server) msgSearch(r *Request) (any, error) {
+	key := r.Params.String("key")
+	if key == "" {
+		return nil, Errorf("请输入搜索关键词")
+	}
+	dialogID := r.Params.Int("dialog_id")
+	out := []dootask.MessageSearchItem{}
+	ids := sortedIDs(s.messages)
+	for i := len(ids) - 1; i >= 0; i-- {
+		m := s.messages[ids[i]]
+		if dialogID > 0 && m.DialogID != dialogID {
+			continue
+		}
+		if _, err := s.dialogFor(r, m.DialogID); err != nil {
+			continue
+		}
+		text := messageText(m)
+		if !strings.Contains(text, key) {
+			continue
+		}
+		out = append(out, dootask.MessageSearchItem{
+			ID:             m.ID,
+			MsgID:          m.ID,
+			DialogID:       m.DialogID,
+			UserID:         m.UserID,
+			Type:           m.Type,
+			Msg:            m.Msg,
+			CreatedAt:      m.CreatedAt,
+			Relevance:      relevance(text, key),
+			ContentPreview: text,
+		})
+	}
+	return searchPage(r, out), nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 统一搜索
+// ------------------------------------------------------------------------------------------
+
+// relevance 模拟相关度：关键词占文本的比例，不匹配为 0
+func relevance(text, key string) float64 {
+	text, key = strings.ToLower(text), strings.ToLower(key)
+	if key == "" || !strings.Contains(text, key) {
+		return 0
+	}
+	return float64(len([]rune(key))) / float64(len([]rune(text)))
+}
+
+// searchPage 按 take（默认20，最大50）与 page 截取搜索结果
+func searchPage[T any](r *Request, items []T) []T {
+	take := r.Params.Int("take")
+	if take <= 0 {
+		take = 20
+	}
+	take = min(take, 50)
+	page := max(r.Params.Int("page"), 1)
+	start := min((page-1)*take, len(items))
+	return items[start:min(start+take, len(items))]
+}
+
+// searchKey 返回搜索关键词
+func searchKey(r *Request) (string, error) {
+	key := strings.TrimSpace(r.Params.String("key"))
+	if key == "" {
+		return "", Errorf("请输入搜索关键词")
+	}
+	return key, nil
+}
+
+func (s *Server) searchTask(r *Request) (any, error) {
+	key, err := searchKey(r)
+	if err != nil {
+		return nil, err
+	}
+	out := []dootask.SearchTaskHit{}
+	for _, id := range sortedIDs(s.tasks) {
+		t := s.tasks[id]
+		if t.Deleted {
+			continue
+		}
+		if _, err := s.projectFor(r, t.ProjectID, false); err != nil {
+			continue
+		}
+		if rel := max(relevance(t.Name, key), relevance(t.Content, key)/2); rel > 0 {
+			out = append(out, dootask.SearchTaskHit{ProjectTask: s.taskInfo(t), Relevance: dootask.FlexFloat(rel)})
+		}
+	}
+	return searchPage(r, out), nil
+}
+
+func (s *Server) searchProject(r *Request) (any, error) {
+	key, err := searchKey(r)
+	if err != nil {
+		return nil, err
+	}
+	out := []dootask.SearchProjectHit{}
+	for _, id := range sortedIDs(s.projects) {
+		p := s.projects[id]
+		if !slices.Contains(p.Members, r.UserID) {
+			continue
+		}
+		if rel := max(relevance(p.Name, key), relevance(p.Desc, key)/2); rel > 0 {
+			out = append(out, dootask.SearchProjectHit{Project: s.projectInfo(p, r.UserID), Relevance: dootask.FlexFloat(rel)})
+		}
+	}
+	return searchPage(r, out), nil
+}
+
+func (s *Server) searchFile(r *Request) (any, error) {
+	key, err := searchKey(r)
+	if err != nil {
+		return nil, err
+	}
+	out := []dootask.SearchFileHit{}
+	for _, id := range sortedIDs(s.files) {
+		f := s.files[id]
+		if s.filePermission(f, r.UserID) < 0 {
+			continue
+		}
+		if rel := relevance(fullName(f), key); rel > 0 {
+			out = append(out, dootask.SearchFileHit{File: fileInfo(f), Relevance: dootask.FlexFloat(rel)})
+		}
+	}
+	return searchPage(r, out), nil
+}
+
+func (s *Server) searchContact(r *Request) (any, error) {
+	key, err := searchKey(r)
+	if err != nil {
+		return nil, err
+	}
+	out := []dootask.SearchContactHit{}
+	for _, id := range sortedIDs(s.users) {
+		u := s.users[id]
+		if u.Bot == 1 || id == r.UserID {
+			continue
+		}
+		if rel := max(relevance(u.Nickname, key), relevance(u.Email, key)); rel > 0 {
+			out = append(out, dootask.SearchContactHit{
+				UserBasic: dootask.UserBasic{
+					UserID:         u.UserID,
+					Email:          u.Email,
+					Nickname:       u.Nickname,
+					Profession:     u.Profession,
+					UserImg:        u.UserImg,
+					Online:         u.Online,
+					Department:     u.Department,
+					DepartmentName: u.DepartmentName,
+				},
+				Relevance: dootask.FlexFloat(rel),
+			})
+		}
+	}
+	return searchPage(r, out), nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 对话
+// ------------------------------------------------------------------------------------------
+
+// dialogInfo 以 userID 视角生成对话信息
+func (s *Server) dialogInfo(d *Dialog, userID int) dootask.DialogInfo {
+	info := d.DialogInfo
+	info.People, info.PeopleUser, info.PeopleBot = len(d.Members), 0, 0
+	for _, uid := range d.Members {
+		if u, ok := s.users[uid]; ok && u.Bot == 1 {
+			info.PeopleBot++
+		} else {
+			info.PeopleUser++
+		}
+	}
+	if d.Type == "user" {
+		// 单聊以对方昵称为名称
+		for _, uid := range d.Members {
+			if u, ok := s.users[uid]; ok && uid != userID {
+				info.Name = u.Nickname
+				info.Bot = u.Bot
+			}
+		}
+	}
+	if msgs := s.dialogMessages(d.ID); len(msgs) > 0 {
+		info.LastMsg = *msgs[len(msgs)-1]
+		info.LastAt = msgs[len(msgs)-1].CreatedAt
+	}
+	return info
+}
+
+// userDialogs 返回用户所在的对话（按ID升序）
+func (s *Server) userDialogs(userID int) []*Dialog {
+	var out []*Dialog
+	for _, id := range sortedIDs(s.dialogs) {
+		if d := s.dialogs[id]; slices.Contains(d.Members, userID) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func (s *Server) dialogList(r *Request) (any, error) {
+	var infos []dootask.DialogInfo
+	for _, d := range s.userDialogs(r.UserID) {
+		infos = append(infos, s.dialogInfo(d, r.UserID))
+	}
+	return paginate(r, "/api/dialog/lists", infos, 50), nil
+}
+
+func (s *Server) dialogSearch(r *Request) (any, error) {
+	key := r.Params.String("key")
+	out := []dootask.DialogInfo{}
+	for _, d := range s.userDialogs(r.UserID) {
+		info := s.dialogInfo(d, r.UserID)
+		if key != "" && strings.Contains(info.Name, key) {
+			out = append(out, info)
+		}
+	}
+	return out, nil
+}
+
+func (s *Server) dialogOne(r *Request) (any, error) {
+	d, err := s.dialogFor(r, r.Params.Int("dialog_id"))
+	if err != nil {
+		return nil, err
+	}
+	return s.dialogInfo(d, r.UserID), nil
+}
+
+func (s *Server) dialogUser(r *Request) (any, error) {
+	d, err := s.dialogFor(r, r.Params.Int("dialog_id"))
+	if err != nil {
+		return nil, err
+	}
+	getUser := r.Params.Int("getuser") == 1
+	out := []dootask.DialogMember{}
+	for i, uid := range d.Members {
+		member := dootask.DialogMember{ID: i + 1, DialogID: d.ID, UserID: uid}
+		if u, ok := s.users[uid]; ok {
+			member.Bot = u.Bot
+			if getUser {
+				member.Nickname, member.Email, member.UserImg, member.Online = u.Nickname, u.Email, u.UserImg, u.Online
+			}
+		}
+		out = append(out, member)
+	}
+	return out, nil
+}
+
+// openUserDialog 返回两个用户之间的单聊，不存在时创建
+func (s *Server) openUserDialog(userID, otherID int) *Dialog {
+	for _, id := range sortedIDs(s.dialogs) {
+		d := s.dialogs[id]
+		if d.Type == "user" && len(d.Members) == 2 && slices.Contains(d.Members, userID) && slices.Contains(d.Members, otherID) {
+			return d
+		}
+	}
+	d := &Dialog{
+		DialogInfo: dootask.DialogInfo{ID: s.nextID("dialog"), Type: "user", CreatedAt: now()},
+		Members:    []int{userID, otherID},
+	}
+	s.dialogs[d.ID] = d
+	return d
+}
+
+func (s *Server) dialogOpenUser(r *Request) (any, error) {
+	otherID := r.Params.Int("userid")
+	other, ok := s.users[otherID]
+	if !ok {
+		return nil, Errorf("用户不存在")
+	}
+	d := s.openUserDialog(r.UserID, otherID)
+	return dootask.DialogOpenUserResponse{
+		DialogUser: dootask.DialogUserResponse{DialogID: d.ID, UserID: otherID, Bot: other.Bot},
+	}, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 群组
+// ------------------------------------------------------------------------------------------
+
+// groupFor 返回当前用户所在的群组，owner 为 true 时要求当前用户为群主
+func (s *Server) groupFor(r *Request, owner bool) (*Dialog, error) {
+	d, err := s.dialogFor(r, r.Params.Int("dialog_id"))
+	if err != nil {
+		return nil, err
+	}
+	if d.Type != "group" {
+		return nil, Errorf("仅限群组操作")
+	}
+	if owner && d.OwnerID != r.UserID && !s.isAdmin(r.UserID) {
+		return nil, Errorf("仅限群主操作")
+	}
+	return d, nil
+}
+
+func (s *Server) groupAdd(r *Request) (any, error) {
+	members := []int{r.UserID}
+	for _, uid := range r.Params.Ints("userids") {
+		if _, ok := s.users[uid]; ok && !slices.Contains(members, uid) {
+			members = append(members, uid)
+		}
+	}
+	if len(members) < 2 {
+		return nil, Errorf("请选择群成员")
+	}
+	name := r.Params.String("chat_name")
+	if name == "" {
+		name = "群聊"
+	}
+	d := &Dialog{
+		DialogInfo: dootask.DialogInfo{
+			ID:        s.nextID("dialog"),
+			Type:      "group",
+			GroupType: "user",
+			Name:      name,
+			Avatar:    r.Params.String("avatar"),
+			OwnerID:   r.UserID,
+			CreatedAt: now(),
+		},
+		Members: members,
+	}
+	s.dialogs[d.ID] = d
+	return s.dialogInfo(d, r.UserID), nil
+}
+
+func (s *Server) groupEdit(r *Request) (any, error) {
+	d, err := s.groupFor(r, true)
+	if err != nil {
+		return nil, err
+	}
+	if v := r.Params.String("chat_name"); v != "" {
+		d.Name = v
+	}
+	if r.Params.Has("avatar") {
+		d.Avatar = r.Params.String("avatar")
+	}
+	d.UpdatedAt = now()
+	return nil, nil
+}
+
+func (s *Server) groupAddUser(r *Request) (any, error) {
+	d, err := s.groupFor(r, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, uid := range r.Params.Ints("userids") {
+		if _, ok := s.users[uid]; !ok {
+			return nil, Errorf("用户 %d 不存在", uid)
+		}
+		if !slices.Contains(d.Members, uid) {
+			d.Members = append(d.Members, uid)
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) groupDelUser(r *Request) (any, error) {
+	userIDs := r.Params.Ints("userids")
+	d, err := s.groupFor(r, len(userIDs) > 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(userIDs) == 0 {
+		// 自己退出
+		if d.OwnerID == r.UserID {
+			return nil, Errorf("群主不可退出群组")
+		}
+		userIDs = []int{r.UserID}
+	}
+	d.Members = slices.DeleteFunc(d.Members, func(uid int) bool {
+		return slices.Contains(userIDs, uid) && uid != d.OwnerID
+	})
+	return nil, nil
+}
+
+func (s *Server) groupTransfer(r *Request) (any, error) {
+	d, err := s.groupFor(r, r.Params.String("check_owner") != "no")
+	if err != nil {
+		return nil, err
+	}
+	uid := r.Params.Int("userid")
+	if !slices.Contains(d.Members, uid) {
+		return nil, Errorf("新群主不在群组内")
+	}
+	d.OwnerID = uid
+	return nil, nil
+}
+
+func (s *Server) groupDisband(r *Request) (any, error) {
+	d, err := s.groupFor(r, true)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.dialogs, d.ID)
+	for id, m := range s.messages {
+		if m.DialogID == d.ID {
+			delete(s.messages, id)
+		}
+	}
+	return nil, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 项目
+// ------------------------------------------------------------------------------------------
+
+// projectFor 返回当前用户所在的项目，owner 为 true 时要求当前用户为负责人
+func (s *Server) projectFor(r *Request, projectID int, owner bool) (*Project, error) {
+	p, ok := s.projects[projectID]
+	if !ok {
+		return nil, Errorf("项目不存在或已被删除")
+	}
+	if !slices.Contains(p.Members, r.UserID) {
+		return nil, Errorf("项目不存在或不在成员列表内")
+	}
+	if owner && p.OwnerUserID != r.UserID {
+		return nil, Errorf("仅限项目负责人操作")
+	}
+	return p, nil
+}
+
+// projectInfo 以 userID 视角生成项目信息（含任务统计）
+func (s *Server) projectInfo(p *Project, userID int) dootask.Project {
+	info := p.Project
+	info.Owner = 0
+	if p.OwnerUserID == userID {
+		info.Owner = 1
+	}
+	info.TaskNum, info.TaskComplete, info.TaskMyNum, info.TaskMyComplete = 0, 0, 0, 0
+	for _, t := range s.tasks {
+		if t.ProjectID != p.ID || t.ParentID != 0 || t.Deleted || t.ArchivedAt != "" {
+			continue
+		}
+		info.TaskNum++
+		mine := slices.Contains(t.Owners, userID)
+		if mine {
+			info.TaskMyNum++
+		}
+		if t.CompleteAt != "" {
+			info.TaskComplete++
+			if mine {
+				info.TaskMyComplete++
+			}
+		}
+	}
+	if info.TaskNum > 0 {
+		info.TaskPercent = info.TaskComplete * 100 / info.TaskNum
+	}
+	if info.TaskMyNum > 0 {
+		info.TaskMyPercent = info.TaskMyComplete * 100 / info.TaskMyNum
+	}
+	return info
+}
+
+func (s *Server) projectList(r *Request) (any, error) {
+	archived := r.Params.String("archived")
+	if archived == "" {
+		archived = "no"
+	}
+	typ := r.Params.String("type")
+	var out []dootask.Project
+	for _, id := range sortedIDs(s.projects) {
+		p := s.projects[id]
+		if !slices.Contains(p.Members, r.UserID) {
+			continue
+		}
+		if (archived == "no" && p.ArchivedAt != "") || (archived == "yes" && p.ArchivedAt == "") {
+			continue
+		}
+		if (typ == "personal" && p.Personal != 1) || (typ == "team" && p.Personal == 1) {
+			continue
+		}
+		out = append(out, s.projectInfo(p, r.UserID))
+	}
+	return paginate(r, "/api/project/lists", out, 50), nil
+}
+
+func (s *Server) projectOne(r *Request) (any, error) {
+	p, err := s.projectFor(r, r.Params.Int("project_id"), false)
+	if err != nil {
+		return nil, err
+	}
+	return s.projectInfo(p, r.UserID), nil
+}
+
+func (s *Server) projectAdd(r *Request) (any, error) {
+	name := strings.TrimSpace(r.Params.String("name"))
+	if name == "" {
+		return nil, Errorf("项目名称不能为空")
+	}
+	d := &Dialog{
+		DialogInfo: dootask.DialogInfo{ID: s.nextID("dialog"), Type: "group", GroupType: "project", Name: name, OwnerID: r.UserID, CreatedAt: now()},
+		Members:    []int{r.UserID},
+	}
+	s.dialogs[d.ID] = d
+	p := &Project{
+		Project: dootask.Project{
+			ID:          s.nextID("project"),
+			Name:        name,
+			Desc:        r.Params.String("desc"),
+			UserID:      r.UserID,
+			DialogID:    d.ID,
+			OwnerUserID: r.UserID,
+			Personal:    r.Params.Int("personal"),
+			CreatedAt:   now(),
+		},
+		Members: []int{r.UserID},
+	}
+	s.projects[p.ID] = p
+
+	columns := r.Params.String("columns")
+	if columns == "" {
+		columns = "Backlog,Doing,Done"
+	}
+	for i, col := range strings.Split(columns, ",") {
+		if col = strings.TrimSpace(col); col != "" {
+			c := &dootask.ProjectColumn{ID: s.nextID("column"), ProjectID: p.ID, Name: col, Sort: i, CreatedAt: now()}
+			s.columns[c.ID] = c
+		}
+	}
+	if r.Params.String("flow") == "open" {
+		s.flows[p.ID] = s.defaultFlow(p.ID)
+	}
+	return s.projectInfo(p, r.UserID), nil
+}
+
+func (s *Server) projectUpdate(r *Request) (any, error) {
+	p, err := s.projectFor(r, r.Params.Int("project_id"), true)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(r.Params.String("name"))
+	if name == "" {
+		return nil, Errorf("项目名称不能为空")
+	}
+	p.Name = name
+	if r.Params.Has("desc") {
+		p.Desc = r.Params.String("desc")
+	}
+	p.UpdatedAt = now()
+	return s.projectInfo(p, r.UserID), nil
+}
+
+func (s *Server) projectExit(r *Request) (any, error) {
+	p, err := s.projectFor(r, r.Params.Int("project_id"), false)
+	if err != nil {
+		return nil, err
+	}
+	if p.OwnerUserID == r.UserID {
+		return nil, Errorf("项目负责人不可退出项目")
+	}
+	p.Members = slices.DeleteFunc(p.Members, func(uid int) bool { return uid == r.UserID })
+	return nil, nil
+}
+
+func (s *Server) projectRemove(r *Request) (any, error) {
+	p, err := s.projectFor(r, r.Params.Int("project_id"), true)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.projects, p.ID)
+	delete(s.flows, p.ID)
+	for id, tag := range s.tags {
+		if tag.ProjectID == p.ID {
+			delete(s.tags, id)
+		}
+	}
+	for id, c := range s.columns {
+		if c.ProjectID == p.ID {
+			delete(s.columns, id)
+		}
+	}
+	for id, t := range s.tasks {
+		if t.ProjectID == p.ID {
+			delete(s.tasks, id)
+		}
+	}
+	return nil, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 列表
+// ------------------------------------------------------------------------------------------
+
+// columnFor 返回当前用户可操作的列表
+func (s *Server) columnFor(r *Request, columnID int) (*dootask.ProjectColumn, error) {
+	c, ok := s.columns[columnID]
+	if !ok {
+		return nil, Errorf("列表不存在或已被删除")
+	}
+	if _, err := s.projectFor(r, c.ProjectID, false); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *Server) columnList(r *Request) (any, error) {
+	p, err := s.projectFor(r, r.Params.Int("project_id"), false)
+	if err != nil {
+		return nil, err
+	}
+	var out []dootask.ProjectColumn
+	for _, id := range sortedIDs(s.columns) {
+		if c := s.columns[id]; c.ProjectID == p.ID {
+			out = append(out, *c)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b dootask.ProjectColumn) int { return a.Sort - b.Sort })
+	return paginate(r, "/api/project/column/lists", out, 100), nil
+}
+
+func (s *Server) columnAdd(r *Request) (any, error) {
+	p, err := s.projectFor(r, r.Params.Int("project_id"), false)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(r.Params.String("name"))
+	if name == "" {
+		return nil, Errorf("列表名称不能为空")
+	}
+	sort := 0
+	for _, c := range s.columns {
+		if c.ProjectID == p.ID {
+			sort = max(sort, c.Sort+1)
+		}
+	}
+	c := &dootask.ProjectColumn{ID: s.nextID("column"), ProjectID: p.ID, Name: name, Sort: sort, CreatedAt: now()}
+	s.columns[c.ID] = c
+	return *c, nil
+}
+
+func (s *Server) columnUpdate(r *Request) (any, error) {
+	c, err := s.columnFor(r, r.Params.Int("column_id"))
+	if err != nil {
+		return nil, err
+	}
+	if v := r.Params.String("name"); v != "" {
+		c.Name = v
+	}
+	if r.Params.Has("color") {
+		c.Color = r.Params.String("color")
+	}
+	c.UpdatedAt = now()
+	return *c, nil
+}
+
+func (s *Server) columnRemove(r *Request) (any, error) {
+	c, err := s.columnFor(r, r.Params.Int("column_id"))
+	if err != nil {
+		return nil, err
+	}
+	delete(s.columns, c.ID)
+	for id, t := range s.tasks {
+		if t.ColumnID == c.ID {
+			delete(s.tasks, id)
+		}
+	}
+	return nil, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 任务
+// ------------------------------------------------------------------------------------------
+
+// taskFor 返回当前用户可操作的任务
+func (s *Server) taskFor(r *Request, taskID int) (*Task, error) {
+	t, ok := s.tasks[taskID]
+	if !ok || (t.Deleted && r.Params.String("type") != "recovery") {
+		return nil, Errorf("任务不存在或已被删除")
+	}
+	if _, err := s.projectFor(r, t.ProjectID, false); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// taskInfo 生成任务信息（含子任务统计与关联名称）
+func (s *Server) taskInfo(t *Task) dootask.ProjectTask {
+	info := t.ProjectTask
+	info.SubNum, info.SubComplete = 0, 0
+	for _, sub := range s.tasks {
+		if sub.ParentID == t.ID && !sub.Deleted {
+			info.SubNum++
+			if sub.CompleteAt != "" {
+				info.SubComplete++
+			}
+		}
+	}
+	if p, ok := s.projects[t.ProjectID]; ok {
+		info.ProjectName = p.Name
+	}
+	if c, ok := s.columns[t.ColumnID]; ok {
+		info.ColumnName = c.Name
+	}
+	if info.CompleteAt != "" {
+		info.Percent = 100
+	} else if info.SubNum > 0 {
+		info.Percent = info.SubComplete * 100 / info.SubNum
+	}
+	if info.TaskTag == nil {
+		info.TaskTag = []dootask.TaskTag{}
+	}
+	return info
+}
+
+func (s *Server) taskList(r *Request) (any, error) {
+	p := r.Params
+	projectID := p.Int("project_id")
+	if projectID > 0 {
+		if _, err := s.projectFor(r, projectID, false); err != nil {
+			return nil, err
+		}
+	}
+	archived, deleted := p.String("archived"), p.String("deleted")
+	if archived == "" {
+		archived = "no"
+	}
+	if deleted == "" {
+		deleted = "no"
+	}
+	parentID := p.Int("parent_id")
+
+	var out []dootask.ProjectTask
+	for _, id := range sortedIDs(s.tasks) {
+		t := s.tasks[id]
+		if projectID > 0 && t.ProjectID != projectID {
+			continue
+		}
+		if projectID == 0 {
+			if proj, ok := s.projects[t.ProjectID]; !ok || !slices.Contains(proj.Members, r.UserID) {
+				continue
+			}
+		}
+		if t.ParentID != parentID && !(parentID == -1 && t.ParentID == 0) {
+			continue
+		}
+		if (archived == "no" && t.ArchivedAt != "") || (archived == "yes" && t.ArchivedAt == "") {
+			continue
+		}
+		if (deleted == "no" && t.Deleted) || (deleted == "yes" && !t.Deleted) {
+			continue
+		}
+		out = append(out, s.taskInfo(t))
+	}
+	return paginate(r, "/api/project/task/lists", out, 100), nil
+}
+
+func (s *Server) taskOne(r *Request) (any, error) {
+	t, err := s.taskFor(r, r.Params.Int("task_id"))
+	if err != nil {
+		return nil, err
+	}
+	return s.taskInfo(t), nil
+}
+
+func (s *Server) taskContent(r *Request) (any, error) {
+	t, err := s.taskFor(r, r.Params.Int("task_id"))
+	if err != nil {
+		return nil, err
+	}
+	return dootask.TaskContent{Content: t.Content, Type: "html"}, nil
+}
+
+func (s *Server) taskFiles(r *Request) (any, error) {
+	if _, err := s.taskFor(r, r.Params.Int("task_id")); err != nil {
+		return nil, err
+	}
+	return []dootask.TaskFile{}, nil
+}
+
+// applyTimes 写入计划时间 times（[开始, 结束]）
+func applyTimes(t *Task, times []string) {
+	if len(times) >= 2 {
+		t.StartAt, t.EndAt = times[0], times[1]
+	} else {
+		t.StartAt, t.EndAt = "", ""
+	}
+}
+
+func (s *Server) taskAdd(r *Request) (any, error) {
+	p := r.Params
+	proj, err := s.projectFor(r, p.Int("project_id"), false)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(p.String("name"))
+	if name == "" {
+		return nil, Errorf("任务描述不能为空")
+	}
+
+	// column_id 可以是列表ID或新列表名称
+	var column *dootask.ProjectColumn
+	if id := p.Int("column_id"); id > 0 {
+		c, ok := s.columns[id]
+		if !ok || c.ProjectID != proj.ID {
+			return nil, Errorf("列表不存在或已被删除")
+		}
+		column = c
+	} else if colName := p.String("column_id"); colName != "" {
+		column = &dootask.ProjectColumn{ID: s.nextID("column"), ProjectID: proj.ID, Name: colName, CreatedAt: now()}
+		s.columns[column.ID] = column
+	} else {
+		for _, id := range sortedIDs(s.columns) {
+			if c := s.columns[id]; c.ProjectID == proj.ID {
+				column = c
+				break
+			}
+		}
+		if column == nil {
+			return nil, Errorf("请先创建列表")
+		}
+	}
+
+	t := &Task{
+		ProjectTask: dootask.ProjectTask{
+			ID:        s.nextID("task"),
+			ProjectID: proj.ID,
+			ColumnID:  column.ID,
+			Name:      name,
+			UserID:    r.UserID,
+			CreatedAt: now(),
+		},
+		Content: p.String("content"),
+		Owners:  p.Ints("owner"),
+	}
+	if len(t.Owners) == 0 {
+		t.Owners = []int{r.UserID}
+	}
+	if flow, ok := s.flows[proj.ID]; ok {
+		// 开启工作流的项目中，新任务处于第一个开始状态
+		for _, item := range flow.Items {
+			if item.Status == dootask.FlowStatusStart {
+				t.FlowItemID, t.FlowItemName = item.ID, item.Name
+				break
+			}
+		}
+	}
+	applyTimes(t, p.Strings("times"))
+	s.tasks[t.ID] = t
+	return s.taskInfo(t), nil
+}
+
+func (s *Server) taskAddSub(r *Request) (any, error) {
+	parent, err := s.taskFor(r, r.Params.Int("task_id"))
+	if err != nil {
+		return nil, err
+	}
+	if parent.ParentID != 0 {
+		return nil, Errorf("子任务不能再添加子任务")
+	}
+	name := strings.TrimSpace(r.Params.String("name"))
+	if name == "" {
+		return nil, Errorf("任务描述不能为空")
+	}
+	t := &Task{
+		ProjectTask: dootask.ProjectTask{
+			ID:        s.nextID("task"),
+			ProjectID: parent.ProjectID,
+			ColumnID:  parent.ColumnID,
+			ParentID:  parent.ID,
+			Name:      name,
+			UserID:    r.UserID,
+			CreatedAt: now(),
+		},
+		Owners: []int{r.UserID},
+	}
+	s.tasks[t.ID] = t
+	return s.taskInfo(t), nil
+}
+
+// given 参数是否出现且不为 null（SDK 只发送已设置的可选字段，空字符串与空数组表示清空）
+func given(p Params, key string) bool {
+	v, ok := p[key]
+	return ok && v != nil
+}
+
+func (s *Server) taskUpdate(r *Request) (any, error) {
+	p := r.Params
+	t, err := s.taskFor(r, p.Int("task_id"))
+	if err != nil {
+		return nil, err
+	}
+	if given(p, "name") {
+		name := strings.TrimSpace(p.String("name"))
+		if name == "" {
+			return nil, Errorf("任务描述不能为空")
+		}
+		t.Name = name
+	}
+	if given(p, "content") {
+		t.Content = p.String("content")
+	}
+	if given(p, "times") {
+		applyTimes(t, p.Strings("times"))
+	}
+	if given(p, "owner") {
+		t.Owners = p.Ints("owner")
+	}
+	if given(p, "assist") {
+		t.Assists = p.Ints("assist")
+	}
+	if given(p, "task_tag") {
+		t.TaskTag = nil
+		items, _ := p["task_tag"].([]any)
+		for _, item := range items {
+			if tag, ok := item.(map[string]any); ok {
+				name, _ := tag["name"].(string)
+				color, _ := tag["color"].(string)
+				t.TaskTag = append(t.TaskTag, dootask.TaskTag{ID: len(t.TaskTag) + 1, ProjectID: t.ProjectID, TaskID: t.ID, Name: name, Color: color})
+			}
+		}
+	}
+	if given(p, "color") {
+		t.Color = p.String("color")
+	}
+	if given(p, "visibility") {
+		t.Visibility = p.Int("visibility")
+	}
+	if given(p, "column_id") {
+		c, err := s.columnFor(r, p.Int("column_id"))
+		if err != nil {
+			return nil, err
+		}
+		t.ColumnID = c.ID
+	}
+	if v, ok := p["complete_at"]; ok && v != nil {
+		// false 表示标记未完成，true 或时间表示完成
+		switch v := v.(type) {
+		case bool:
+			if v {
+				t.CompleteAt = now()
+			} else {
+				t.CompleteAt = ""
+			}
+		default:
+			t.CompleteAt = p.String("complete_at")
+		}
+	}
+	t.UpdatedAt = now()
+	return s.taskInfo(t), nil
+}
+
+func (s *Server) taskDialog(r *Request) (any, error) {
+	t, err := s.taskFor(r, r.Params.Int("task_id"))
+	if err != nil {
+		return nil, err
+	}
+	if t.DialogID == 0 {
+		members := slices.Clone(t.Owners)
+		if !slices.Contains(members, r.UserID) {
+			members = append(members, r.UserID)
+		}
+		d := &Dialog{
+			DialogInfo: dootask.DialogInfo{ID: s.nextID("dialog"), Type: "group", GroupType: "task", Name: t.Name, CreatedAt: now()},
+			Members:    members,
+		}
+		s.dialogs[d.ID] = d
+		t.DialogID = d.ID
+	}
+	return dootask.CreateTaskDialogResponse{
+		ID:         t.ID,
+		DialogID:   t.DialogID,
+		DialogData: s.dialogInfo(s.dialogs[t.DialogID], r.UserID),
+	}, nil
+}
+
+func (s *Server) taskArchived(r *Request) (any, error) {
+	t, err := s.taskFor(r, r.Params.Int("task_id"))
+	if err != nil {
+		return nil, err
+	}
+	if r.Params.String("type") == "recovery" {
+		t.ArchivedAt = ""
+	} else {
+		t.ArchivedAt = now()
+	}
+	return s.taskInfo(t), nil
+}
+
+func (s *Server) taskRemove(r *Request) (any, error) {
+	t, err := s.taskFor(r, r.Params.Int("task_id"))
+	if err != nil {
+		return nil, err
+	}
+	t.Deleted = r.Params.String("type") != "recovery"
+	return nil, nil
+}
+
+func (s *Server) taskMove(r *Request) (any, error) {
+	p := r.Params
+	t, err := s.taskFor(r, p.Int("task_id"))
+	if err != nil {
+		return nil, err
+	}
+	proj, err := s.projectFor(r, p.Int("project_id"), false)
+	if err != nil {
+		return nil, err
+	}
+	columnID := t.ColumnID
+	if given(p, "column_id") {
+		columnID = p.Int("column_id")
+	} else if proj.ID != t.ProjectID {
+		return nil, Errorf("请选择目标列表")
+	}
+	if c, ok := s.columns[columnID]; !ok || c.ProjectID != proj.ID {
+		return nil, Errorf("列表不存在或已被删除")
+	}
+
+	var item *dootask.FlowItem
+	if id := p.Int("flow_item_id"); id > 0 {
+		flow, ok := s.flows[proj.ID]
+		if !ok {
+			return nil, Errorf("项目未开启工作流")
+		}
+		if item, ok = flow.ItemByID(id); !ok {
+			return nil, Errorf("工作流状态不存在")
+		}
+		if current, ok := flow.ItemByID(t.FlowItemID); ok && current.ID != item.ID && !current.CanTurnTo(item.ID) {
+			return nil, Errorf("当前状态[%s]不可流转到[%s]", current.Name, item.Name)
+		}
+		if item.UserLimit == 1 && !slices.Contains(item.UserIDs, r.UserID) {
+			return nil, Errorf("仅限状态负责人流转到[%s]", item.Name)
+		}
+		if item.ColumnID > 0 {
+			columnID = item.ColumnID
+		}
+	}
+
+	if proj.ID != t.ProjectID {
+		t.ProjectID = proj.ID
+		t.FlowItemID, t.FlowItemName = 0, ""
+	}
+	t.ColumnID = columnID
+	if item != nil && item.ID != t.FlowItemID {
+		t.FlowItemID, t.FlowItemName = item.ID, item.Name
+		if item.Status == dootask.FlowStatusEnd {
+			t.CompleteAt = now()
+		} else {
+			t.CompleteAt = ""
+		}
+		if len(item.UserIDs) > 0 {
+			switch item.UserType {
+			case "replace":
+				t.Owners = slices.Clone(item.UserIDs)
+			case "merge":
+				for _, uid := range t.Owners {
+					if !slices.Contains(t.Assists, uid) && !slices.Contains(item.UserIDs, uid) {
+						t.Assists = append(t.Assists, uid)
+					}
+				}
+				t.Owners = slices.Clone(item.UserIDs)
+			default:
+				for _, uid := range item.UserIDs {
+					if !slices.Contains(t.Owners, uid) {
+						t.Owners = append(t.Owners, uid)
+					}
+				}
+			}
+		}
+	}
+	if given(p, "owner") {
+		t.Owners = p.Ints("owner")
+	}
+	if given(p, "assist") {
+		t.Assists = p.Ints("assist")
+	}
+	if given(p, "completed") {
+		if p.Bool("completed") {
+			t.CompleteAt = now()
+		} else {
+			t.CompleteAt = ""
+		}
+	}
+	t.UpdatedAt = now()
+	return s.taskInfo(t), nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 工作流
+// ------------------------------------------------------------------------------------------
+
+// cloneFlow 深拷贝工作流
+func cloneFlow(f *dootask.ProjectFlow) dootask.ProjectFlow {
+	c := *f
+	c.Items = slices.Clone(f.Items)
+	for i := range c.Items {
+		c.Items[i].Turns = slices.Clone(c.Items[i].Turns)
+		c.Items[i].UserIDs = slices.Clone(c.Items[i].UserIDs)
+	}
+	return c
+}
+
+// defaultFlow 生成默认工作流：待处理 → 进行中 → 待测试 → 已完成，任意状态可取消
+func (s *Server) defaultFlow(projectID int) *dootask.ProjectFlow {
+	flow := &dootask.ProjectFlow{ID: s.nextID("flow"), ProjectID: projectID, Name: "Default", CreatedAt: now()}
+	names := []string{"待处理", "进行中", "待测试", "已完成", "已取消"}
+	status := []dootask.FlowStatus{dootask.FlowStatusStart, dootask.FlowStatusProgress, dootask.FlowStatusTest, dootask.FlowStatusEnd, dootask.FlowStatusEnd}
+	ids := make([]int, len(names))
+	for i := range ids {
+		ids[i] = s.nextID("flow_item")
+	}
+	for i, name := range names {
+		item := dootask.FlowItem{ID: ids[i], ProjectID: projectID, FlowID: flow.ID, Name: name, Status: status[i], UserType: "add", Sort: i}
+		for j, id := range ids {
+			if j != i {
+				item.Turns = append(item.Turns, id)
+			}
+		}
+		flow.Items = append(flow.Items, item)
+	}
+	return flow
+}
+
+func (s *Server) flowList(r *Request) (any, error) {
+	proj, err := s.projectFor(r, r.Params.Int("project_id"), false)
+	if err != nil {
+		return nil, err
+	}
+	out := []dootask.ProjectFlow{}
+	if flow, ok := s.flows[proj.ID]; ok {
+		out = append(out, cloneFlow(flow))
+	}
+	return out, nil
+}
+
+func (s *Server) flowSave(r *Request) (any, error) {
+	proj, err := s.projectFor(r, r.Params.Int("project_id"), true)
+	if err != nil {
+		return nil, err
+	}
+	var items []dootask.FlowItem
+	b, _ := json.Marshal(r.Params["flows"])
+	if err := json.Unmarshal(b, &items); err != nil || len(items) == 0 {
+		return nil, Errorf("请添加流程状态")
+	}
+	var hasStart, hasEnd bool
+	for _, item := range items {
+		switch item.Status {
+		case dootask.FlowStatusStart:
+			hasStart = true
+		case dootask.FlowStatusEnd:
+			hasEnd = true
+		case dootask.FlowStatusProgress, dootask.FlowStatusTest:
+		default:
+			return nil, Errorf("状态[%s]类型错误", item.Name)
+		}
+	}
+	if !hasStart || !hasEnd {
+		return nil, Errorf("至少需要一个开始状态和一个结束状态")
+	}
+
+	flow, ok := s.flows[proj.ID]
+	if !ok {
+		flow = &dootask.ProjectFlow{ID: s.nextID("flow"), ProjectID: proj.ID, Name: "Default", CreatedAt: now()}
+	}
+	// 新状态（ID <= 0）分配正式ID，Turns 中的临时ID随之替换
+	ids := make(map[int]int, len(items))
+	names := make(map[string]bool, len(items))
+	for i, item := range items {
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			return nil, Errorf("状态名称不能为空")
+		}
+		if names[name] {
+			return nil, Errorf("状态名称[%s]重复", name)
+		}
+		names[name] = true
+		if item.ID > 0 {
+			if _, exists := flow.ItemByID(item.ID); !exists {
+				return nil, Errorf("状态[%s]不存在", name)
+			}
+			ids[item.ID] = item.ID
+		} else {
+			ids[item.ID] = s.nextID("flow_item")
+		}
+		items[i].Name = name
+	}
+	for i := range items {
+		item := &items[i]
+		item.ID, item.ProjectID, item.FlowID, item.Sort = ids[item.ID], proj.ID, flow.ID, i
+		var turns []int
+		for _, id := range item.Turns {
+			if to, ok := ids[id]; ok && to != item.ID {
+				turns = append(turns, to)
+			}
+		}
+		item.Turns = turns
+		if item.ColumnID > 0 {
+			if c, ok := s.columns[item.ColumnID]; !ok || c.ProjectID != proj.ID {
+				return nil, Errorf("状态[%s]的列表不存在", item.Name)
+			}
+		}
+	}
+	flow.Items = items
+	flow.UpdatedAt = now()
+	s.flows[proj.ID] = flow
+
+	// 已删除状态上的任务清空状态，保留的状态同步名称
+	for _, t := range s.tasks {
+		if t.ProjectID != proj.ID || t.FlowItemID == 0 {
+			continue
+		}
+		if item, ok := flow.ItemByID(t.FlowItemID); ok {
+			t.FlowItemName = item.Name
+		} else {
+			t.FlowItemID, t.FlowItemName = 0, ""
+		}
+	}
+	return []dootask.ProjectFlow{cloneFlow(flow)}, nil
+}
+
+func (s *Server) flowDelete(r *Request) (any, error) {
+	proj, err := s.projectFor(r, r.Params.Int("project_id"), true)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.flows[proj.ID]; !ok {
+		return nil, Errorf("项目未开启工作流")
+	}
+	delete(s.flows, proj.ID)
+	for _, t := range s.tasks {
+		if t.ProjectID == proj.ID {
+			t.FlowItemID, t.FlowItemName = 0, ""
+		}
+	}
+	return nil, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 标签
+// ------------------------------------------------------------------------------------------
+
+func (s *Server) tagList(r *Request) (any, error) {
+	proj, err := s.projectFor(r, r.Params.Int("project_id"), false)
+	if err != nil {
+		return nil, err
+	}
+	out := []dootask.ProjectTag{}
+	for _, id := range sortedIDs(s.tags) {
+		if tag := s.tags[id]; tag.ProjectID == proj.ID {
+			out = append(out, *tag)
+		}
+	}
+	return out, nil
+}
+
+func (s *Server) tagSave(r *Request) (any, error) {
+	p := r.Params
+	proj, err := s.projectFor(r, p.Int("project_id"), false)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(p.String("name"))
+	if name == "" {
+		return nil, Errorf("标签名称不能为空")
+	}
+	var tag *dootask.ProjectTag
+	if id := p.Int("id"); id > 0 {
+		t, ok := s.tags[id]
+		if !ok || t.ProjectID != proj.ID {
+			return nil, Errorf("标签不存在或已被删除")
+		}
+		tag = t
+	}
+	for _, t := range s.tags {
+		if t.ProjectID == proj.ID && t.Name == name && t != tag {
+			return nil, Errorf("标签[%s]已存在", name)
+		}
+	}
+	if tag == nil {
+		tag = &dootask.ProjectTag{ID: s.nextID("tag"), ProjectID: proj.ID, UserID: r.UserID, CreatedAt: now()}
+		s.tags[tag.ID] = tag
+	}
+	tag.Name, tag.Color, tag.Desc = name, p.String("color"), p.String("desc")
+	tag.UpdatedAt = now()
+	return *tag, nil
+}
+
+func (s *Server) tagDelete(r *Request) (any, error) {
+	tag, ok := s.tags[r.Params.Int("id")]
+	if !ok {
+		return nil, Errorf("标签不存在或已被删除")
+	}
+	if _, err := s.projectFor(r, tag.ProjectID, false); err != nil {
+		return nil, err
+	}
+	delete(s.tags, tag.ID)
+	return nil, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 报告
+// ------------------------------------------------------------------------------------------
+
+// reportFor 返回汇报人或接收人可见的报告
+func (s *Server) reportFor(r *Request, reportID int) (*Report, error) {
+	rep, ok := s.reports[reportID]
+	if !ok || (rep.UserID != r.UserID && !slices.Contains(rep.Receives, r.UserID)) {
+		return nil, Errorf("报告不存在或已被删除")
+	}
+	return rep, nil
+}
+
+// reportInfo 生成报告信息（含汇报人名称），withContent 为 false 时不含内容
+func (s *Server) reportInfo(rep *Report, withContent bool) dootask.Report {
+	info := rep.Report
+	info.Receives = slices.Clone(rep.Receives)
+	if u, ok := s.users[rep.UserID]; ok {
+		info.Username = u.Nickname
+	}
+	if !withContent {
+		info.Content = ""
+	}
+	return info
+}
+
+// reportList 按 keys 筛选报告（按ID倒序）并分页
+func (s *Server) reportList(r *Request, path string, match func(rep *Report) bool) any {
+	p := r.Params
+	typ, status, key := p.String("keys[type]"), p.String("keys[status]"), p.String("keys[key]")
+	ids := sortedIDs(s.reports)
+	slices.Reverse(ids)
+	items := []dootask.Report{}
+	for _, id := range ids {
+		rep := s.reports[id]
+		if !match(rep) {
+			continue
+		}
+		if typ != "" && typ != "all" && rep.Type != typ {
+			continue
+		}
+		read := slices.Contains(rep.ReadBy, r.UserID)
+		if (status == "read" && !read) || (status == "unread" && read) {
+			continue
+		}
+		if key != "" && !strings.Contains(rep.Title, key) {
+			continue
+		}
+		items = append(items, s.reportInfo(rep, false))
+	}
+	return paginate(r, path, items, 20)
+}
+
+func (s *Server) reportReceive(r *Request) (any, error) {
+	return s.reportList(r, "/api/report/receive", func(rep *Report) bool {
+		return slices.Contains(rep.Receives, r.UserID)
+	}), nil
+}
+
+func (s *Server) reportMy(r *Request) (any, error) {
+	return s.reportList(r, "/api/report/my", func(rep *Report) bool {
+		return rep.UserID == r.UserID
+	}), nil
+}
+
+func (s *Server) reportDetail(r *Request) (any, error) {
+	rep, err := s.reportFor(r, r.Params.Int("id"))
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(rep.Receives, r.UserID) && !slices.Contains(rep.ReadBy, r.UserID) {
+		rep.ReadBy = append(rep.ReadBy, r.UserID)
+	}
+	return s.reportInfo(rep, true), nil
+}
+
+// reportSign 返回周期签名与周期名称：日报按天、周报按周（周一开始），offset 为周期偏移
+func reportSign(typ string, userID, offset int) (string, string) {
+	day := time.Now()
+	if typ == "weekly" {
+		day = day.AddDate(0, 0, -(int(day.Weekday())+6)%7+offset*7)
+	} else {
+		day = day.AddDate(0, 0, offset)
+	}
+	date := day.Format(time.DateOnly)
+	return fmt.Sprintf("%s-%d-%s", typ, userID, date), date
+}
+
+func (s *Server) reportTemplate(r *Request) (any, error) {
+	typ := r.Params.String("type")
+	if typ != "weekly" && typ != "daily" {
+		return nil, Errorf("参数错误")
+	}
+	sign, date := reportSign(typ, r.UserID, r.Params.Int("offset"))
+	name := map[string]string{"weekly": "周报", "daily": "日报"}[typ]
+	tmpl := dootask.ReportTemplate{
+		Sign:  sign,
+		Title: fmt.Sprintf("%s的%s[%s]", s.users[r.UserID].Nickname, name, date),
+	}
+	var content strings.Builder
+	content.WriteString("<h2>已完成工作</h2><ol>")
+	for _, id := range sortedIDs(s.tasks) {
+		t := s.tasks[id]
+		if !t.Deleted && t.CompleteAt != "" && slices.Contains(t.Owners, r.UserID) {
+			content.WriteString("<li>" + t.Name + "</li>")
+		}
+	}
+	content.WriteString("</ol>")
+	tmpl.Content = content.String()
+	for _, rep := range s.reports {
+		if rep.UserID == r.UserID && rep.Sign == sign {
+			tmpl.ID = rep.ID
+		}
+	}
+	return tmpl, nil
+}
+
+func (s *Server) reportStore(r *Request) (any, error) {
+	p := r.Params
+	typ, title, content := p.String("type"), strings.TrimSpace(p.String("title")), p.String("content")
+	if typ != "weekly" && typ != "daily" {
+		return nil, Errorf("参数错误")
+	}
+	if title == "" {
+		return nil, Errorf("请填写标题")
+	}
+	if content == "" {
+		return nil, Errorf("请填写内容")
+	}
+	sign := p.String("sign")
+	if sign == "" {
+		sign, _ = reportSign(typ, r.UserID, p.Int("offset"))
+	}
+
+	var rep *Report
+	if id := p.Int("id"); id > 0 {
+		existing, ok := s.reports[id]
+		if !ok || existing.UserID != r.UserID {
+			return nil, Errorf("报告不存在或已被删除")
+		}
+		rep = existing
+	} else {
+		for _, existing := range s.reports {
+			if existing.UserID == r.UserID && existing.Sign == sign {
+				rep = existing
+			}
+		}
+	}
+	if rep == nil {
+		rep = &Report{Report: dootask.Report{ID: s.nextID("report"), UserID: r.UserID, CreatedAt: now()}}
+		s.reports[rep.ID] = rep
+	}
+	rep.Type, rep.Title, rep.Content, rep.Sign = typ, title, content, sign
+	rep.Receives = slices.DeleteFunc(p.Ints("receive"), func(id int) bool { return id == r.UserID })
+	rep.ReadBy = nil
+	rep.UpdatedAt = now()
+	return s.reportInfo(rep, true), nil
+}
+
+func (s *Server) reportMark(r *Request) (any, error) {
+	action := r.Params.String("action")
+	if action != "read" && action != "unread" {
+		return nil, Errorf("参数错误")
+	}
+	var reports []*Report
+	for _, id := range r.Params.Ints("id") {
+		rep, ok := s.reports[id]
+		if !ok || !slices.Contains(rep.Receives, r.UserID) {
+			return nil, Errorf("报告不存在或已被删除")
+		}
+		reports = append(reports, rep)
+	}
+	for _, rep := range reports {
+		rep.ReadBy = slices.DeleteFunc(rep.ReadBy, func(id int) bool { return id == r.UserID })
+		if action == "read" {
+			rep.ReadBy = append(rep.ReadBy, r.UserID)
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) reportUnread(r *Request) (any, error) {
+	total := 0
+	for _, rep := range s.reports {
+		if slices.Contains(rep.Receives, r.UserID) && !slices.Contains(rep.ReadBy, r.UserID) {
+			total++
+		}
+	}
+	return map[string]int{"total": total}, nil
+}
+
+func (s *Server) reportAnalysave(r *Request) (any, error) {
+	rep, err := s.reportFor(r, r.Params.Int("id"))
+	if err != nil {
+		return nil, err
+	}
+	text := r.Params.String("text")
+	if text == "" {
+		return nil, Errorf("分析内容不能为空")
+	}
+	rep.Analysis = text
+	return nil, nil
+}
+
+func (s *Server) reportShare(r *Request) (any, error) {
+	p := r.Params
+	ids := p.Ints("id")
+	if len(ids) == 0 || len(ids) > 20 {
+		return nil, Errorf("最多分享 20 份报告")
+	}
+	var reports []*Report
+	for _, id := range ids {
+		rep, err := s.reportFor(r, id)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, rep)
+	}
+	var dialogs []*Dialog
+	for _, id := range p.Ints("dialogids") {
+		d, err := s.dialogFor(r, id)
+		if err != nil {
+			return nil, err
+		}
+		dialogs = append(dialogs, d)
+	}
+	for _, userID := range p.Ints("userids") {
+		if _, ok := s.users[userID]; !ok {
+			return nil, Errorf("用户不存在")
+		}
+		dialogs = append(dialogs, s.openUserDialog(r.UserID, userID))
+	}
+	if len(dialogs) == 0 {
+		return nil, Errorf("请选择分享对象")
+	}
+	for _, d := range dialogs {
+		if msg := p.String("leave_message"); msg != "" {
+			s.addMessage(d.ID, r.UserID, "text", map[string]any{"text": msg, "type": "md"})
+		}
+		for _, rep := range reports {
+			s.addMessage(d.ID, r.UserID, "text", map[string]any{"text": fmt.Sprintf("[%s](%s/single/report/detail/%d)", rep.Title, s.URL, rep.ID), "type": "md"})
+		}
+	}
+	return nil, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 文件
+// ------------------------------------------------------------------------------------------
+
+// filePermission 用户对文件的权限：-1 无权访问、0 只读、1 读写（所有者为读写），
+// 共享权限沿上级文件夹继承
+func (s *Server) filePermission(f *File, userID int) int {
+	for cur := f; cur != nil; cur = s.files[cur.PID] {
+		if cur.UserID == userID {
+			return 1
+		}
+		for _, share := range cur.Shares {
+			if share.UserID == 0 || share.UserID == userID {
+				return share.Permission
+			}
+		}
+	}
+	return -1
+}
+
+func (s *Server) fileFor(r *Request, fileID int, write bool) (*File, error) {
+	f, ok := s.files[fileID]
+	if !ok {
+		return nil, Errorf("文件不存在或已被删除")
+	}
+	perm := s.filePermission(f, r.UserID)
+	if perm < 0 {
+		return nil, Errorf("文件不存在或已被删除")
+	}
+	if write && perm < 1 {
+		return nil, Errorf("没有修改权限")
+	}
+	return f, nil
+}
+
+// folderFor 返回可写入的目标文件夹，pid 为 0 表示根目录（返回 nil）
+func (s *Server) folderFor(r *Request, pid int) (*File, error) {
+	if pid == 0 {
+		return nil, nil
+	}
+	f, err := s.fileFor(r, pid, true)
+	if err != nil {
+		return nil, err
+	}
+	if !f.IsFolder() {
+		return nil, Errorf("参数错误")
+	}
+	return f, nil
+}
+
+// fileInfo 生成文件信息（共享状态按共享成员计算）
+func fileInfo(f *File) dootask.File {
+	info := f.File
+	info.Share = 0
+	if len(f.Shares) > 0 {
+		info.Share = 1
+	}
+	return info
+}
+
+// fullName 返回带扩展名的文件名
+func fullName(f *File) string {
+	if f.Ext == "" {
+		return f.Name
+	}
+	return f.Name + "." + f.Ext
+}
+
+// childFiles 返回文件夹下用户可见的文件（按ID升序），pid 为 0 时返回用户的根目录与共享给用户的文件
+func (s *Server) childFiles(pid, userID int) []dootask.File {
+	out := []dootask.File{}
+	for _, id := range sortedIDs(s.files) {
+		f := s.files[id]
+		if f.PID != pid || s.filePermission(f, userID) < 0 {
+			continue
+		}
+		if pid == 0 && f.UserID != userID && len(f.Shares) == 0 {
+			continue
+		}
+		out = append(out, fileInfo(f))
+	}
+	return out
+}
+
+func (s *Server) fileList(r *Request) (any, error) {
+	pid := r.Params.Int("pid")
+	if pid > 0 {
+		if _, err := s.fileFor(r, pid, false); err != nil {
+			return nil, err
+		}
+	}
+	return s.childFiles(pid, r.UserID), nil
+}
+
+func (s *Server) fileSearch(r *Request) (any, error) {
+	key := strings.ToLower(r.Params.String("key"))
+	take := r.Params.Int("take")
+	if take <= 0 || take > 100 {
+		take = 50
+	}
+	out := []dootask.File{}
+	for _, id := range sortedIDs(s.files) {
+		f := s.files[id]
+		if len(out) >= take {
+			break
+		}
+		if s.filePermission(f, r.UserID) >= 0 && strings.Contains(strings.ToLower(fullName(f)), key) {
+			out = append(out, fileInfo(f))
+		}
+	}
+	return out, nil
+}
+
+func (s *Server) fileOne(r *Request) (any, error) {
+	f, err := s.fileFor(r, r.Params.Int("id"), false)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo(f), nil
+}
+
+func (s *Server) fileContent(r *Request) (any, error) {
+	f, err := s.fileFor(r, r.Params.Int("id"), false)
+	if err != nil {
+		return nil, err
+	}
+	if f.IsFolder() {
+		return nil, Errorf("文件夹无法查看内容")
+	}
+	if r.Params.Bool("down") {
+		return Attachment{Name: fullName(f), Data: slices.Clone(f.Data)}, nil
+	}
+	return dootask.FileContent{
+		FID:       f.ID,
+		Content:   map[string]any{"content": string(f.Data)},
+		Text:      string(f.Data),
+		Size:      int64(len(f.Data)),
+		UserID:    f.UserID,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}, nil
+}
+
+func (s *Server) fileUpload(r *Request) (any, error) {
+	if len(r.Files) == 0 {
+		return nil, Errorf("请选择上传的文件")
+	}
+	pid := r.Params.Int("pid")
+	if _, err := s.folderFor(r, pid); err != nil {
+		return nil, err
+	}
+	upload := r.Files[0]
+	name, ext := upload.Name, ""
+	if i := strings.LastIndex(name, "."); i > 0 {
+		name, ext = name[:i], strings.ToLower(name[i+1:])
+	}
+	for _, id := range sortedIDs(s.files) {
+		f := s.files[id]
+		if f.PID == pid && f.UserID == r.UserID && f.Name == name && f.Ext == ext && !f.IsFolder() {
+			if !r.Params.Bool("cover") {
+				return nil, Errorf("文件已存在")
+			}
+			f.Data = upload.Data
+			f.Size = int64(len(upload.Data))
+			f.UpdatedAt = now()
+			return fileInfo(f), nil
+		}
+	}
+	f := &File{
+		File: dootask.File{
+			ID:        s.nextID("file"),
+			PID:       pid,
+			Name:      name,
+			Type:      "file",
+			Ext:       ext,
+			Size:      int64(len(upload.Data)),
+			UserID:    r.UserID,
+			CreatedID: r.UserID,
+			CreatedAt: now(),
+		},
+		Data: upload.Data,
+	}
+	f.UpdatedAt = f.CreatedAt
+	s.files[f.ID] = f
+	return fileInfo(f), nil
+}
+
+func (s *Server) fileFetch(r *Request) (any, error) {
+	var f *File
+	pid := 0
+	for _, name := range strings.Split(strings.Trim(r.Params.String("path"), "/"), "/") {
+		f = nil
+		for _, id := range sortedIDs(s.files) {
+			c := s.files[id]
+			if c.PID == pid && fullName(c) == name && s.filePermission(c, r.UserID) >= 0 {
+				f = c
+				break
+			}
+		}
+		if f == nil {
+			return nil, Errorf("文件不存在或已被删除")
+		}
+		pid = f.ID
+	}
+	if f == nil || f.IsFolder() {
+		return nil, Errorf("文件不存在或已被删除")
+	}
+	text := []rune(string(f.Data))
+	offset := min(max(r.Params.Int("offset"), 0), len(text))
+	limit := r.Params.Int("limit")
+	if limit <= 0 {
+		limit = 10000
+	}
+	end := min(offset+limit, len(text))
+	return dootask.FileText{Content: string(text[offset:end]), Offset: offset, Limit: limit, Total: len(text)}, nil
+}
+
+func (s *Server) fileAdd(r *Request) (any, error) {
+	p := r.Params
+	name := strings.TrimSpace(p.String("name"))
+	if name == "" {
+		return nil, Errorf("名称不能为空")
+	}
+	if id := p.Int("id"); id > 0 {
+		f, err := s.fileFor(r, id, true)
+		if err != nil {
+			return nil, err
+		}
+		f.Name = name
+		f.UpdatedAt = now()
+		return fileInfo(f), nil
+	}
+	pid := p.Int("pid")
+	if _, err := s.folderFor(r, pid); err != nil {
+		return nil, err
+	}
+	f := &File{File: dootask.File{
+		ID:        s.nextID("file"),
+		PID:       pid,
+		Name:      name,
+		Type:      p.String("type"),
+		UserID:    r.UserID,
+		CreatedID: r.UserID,
+		CreatedAt: now(),
+	}}
+	if f.Type == "" {
+		return nil, Errorf("参数错误")
+	}
+	f.UpdatedAt = f.CreatedAt
+	s.files[f.ID] = f
+	return fileInfo(f), nil
+}
+
+func (s *Server) fileMove(r *Request) (any, error) {
+	pid := r.Params.Int("pid")
+	if _, err := s.folderFor(r, pid); err != nil {
+		return nil, err
+	}
+	var files []*File
+	for _, id := range r.Params.Ints("ids") {
+		f, err := s.fileFor(r, id, true)
+		if err != nil {
+			return nil, err
+		}
+		// 不能移动到自身或其子文件夹中
+		for cur := s.files[pid]; cur != nil; cur = s.files[cur.PID] {
+			if cur.ID == f.ID {
+				return nil, Errorf("不能移动到自身或子文件夹中")
+			}
+		}
+		files = append(files, f)
+	}
+	out := []dootask.File{}
+	for _, f := range files {
+		f.PID = pid
+		out = append(out, fileInfo(f))
+	}
+	return out, nil
+}
+
+func (s *Server) fileRemove(r *Request) (any, error) {
+	ids := r.Params.Ints("ids")
+	for _, id := range ids {
+		if _, err := s.fileFor(r, id, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range ids {
+		s.removeFile(id)
+	}
+	return nil, nil
+}
+
+// removeFile 删除文件，文件夹连同其内容
+func (s *Server) removeFile(id int) {
+	for _, childID := range sortedIDs(s.files) {
+		if s.files[childID].PID == id {
+			s.removeFile(childID)
+		}
+	}
+	delete(s.files, id)
+}
+
+func (s *Server) fileLink(r *Request) (any, error) {
+	f, err := s.fileFor(r, r.Params.Int("id"), false)
+	if err != nil {
+		return nil, err
+	}
+	if f.IsFolder() {
+		return nil, Errorf("文件夹不支持分享链接")
+	}
+	if f.Link == "" || r.Params.Bool("refresh") {
+		f.Link = fmt.Sprintf("%s/single/file/%d-%d", s.URL, f.ID, s.nextID("link"))
+	}
+	return dootask.FileLink{ID: f.ID, URL: f.Link}, nil
+}
+
+func (s *Server) fileShare(r *Request) (any, error) {
+	f, err := s.fileFor(r, r.Params.Int("id"), false)
+	if err != nil {
+		return nil, err
+	}
+	return dootask.FileShareInfo{ID: f.ID, List: append([]dootask.FileShare{}, f.Shares...)}, nil
+}
+
+func (s *Server) fileShareUpdate(r *Request) (any, error) {
+	f, err := s.fileFor(r, r.Params.Int("id"), false)
+	if err != nil {
+		return nil, err
+	}
+	if f.UserID != r.UserID {
+		return nil, Errorf("仅限所有者操作")
+	}
+	permission := r.Params.Int("permission")
+	for _, userID := range r.Params.Ints("userids") {
+		f.Shares = slices.DeleteFunc(f.Shares, func(share dootask.FileShare) bool { return share.UserID == userID })
+		if permission >= 0 {
+			f.Shares = append(f.Shares, dootask.FileShare{ID: s.nextID("share"), FileID: f.ID, UserID: userID, Permission: min(permission, 1)})
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) fileShareOut(r *Request) (any, error) {
+	f, err := s.fileFor(r, r.Params.Int("id"), false)
+	if err != nil {
+		return nil, err
+	}
+	if f.UserID == r.UserID {
+		return nil, Errorf("不能退出自己的文件")
+	}
+	n := len(f.Shares)
+	f.Shares = slices.DeleteFunc(f.Shares, func(share dootask.FileShare) bool { return share.UserID == r.UserID })
+	if len(f.Shares) == n {
+		return nil, Errorf("无法退出共享")
+	}
+	return nil, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 系统
+// ------------------------------------------------------------------------------------------
+
+func (s *Server) systemSetting(r *Request) (any, error) {
+	return s.fixtures.Settings, nil
+}
+
+func (s *Server) systemVersion(r *Request) (any, error) {
+	return dootask.VersionInfo{DeviceCount: 1, Version: s.fixtures.Version}, nil
+}
@@ -0,0 +1,701 @@
+// Package dootasktest 提供用于测试的内存版 DooTask 服务：基于 httptest.Server 实现 SDK 调用的
+// {ret,msg,data} 接口（用户、对话、消息、群组、项目、列表、任务、工作流、报告、文件、机器人、系统），
+// 并记录收到的请求以便断言。
+//
+//	srv := dootasktest.NewServer(t)
+//	client := srv.Client()
+//	client.SendMessage(dootask.SendMessageRequest{DialogID: dootasktest.GroupDialogID, Text: "hi"})
+//	req := srv.AssertCalled(t, "/api/dialog/msg/sendtext")
+package dootasktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ------------------------------------------------------------------------------------------
+// 服务
+// ------------------------------------------------------------------------------------------
+
+// HandlerFunc 接口处理函数，返回值作为 data 输出；返回 *Error 时输出对应的 ret/msg
+type HandlerFunc func(r *Request) (any, error)
+
+// Error 接口错误
+type Error struct {
+	Status int    // HTTP 状态码，缺省 200
+	Ret    int    // 业务状态码，缺省 0
+	Msg    string // 错误信息
+	Data   any    // 附加数据
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// Errorf 返回 ret 为 0 的业务错误
+func Errorf(format string, a ...any) *Error {
+	return &Error{Msg: fmt.Sprintf(format, a...)}
+}
+
+// errUnauthorized 未登录或 token 无效
+var errUnauthorized = &Error{Ret: -1, Msg: "请登录后继续..."}
+
+// Option 服务选项
+type Option func(*Server)
+
+// WithFixtures 使用指定种子数据替换 DefaultFixtures
+func WithFixtures(f Fixtures) Option {
+	return func(s *Server) {
+		s.fixtures = f
+	}
+}
+
+// WithHandler 注册或覆盖接口，等同于启动后调用 Handle
+func WithHandler(path string, fn HandlerFunc) Option {
+	return func(s *Server) {
+		s.custom[path] = fn
+	}
+}
+
+// Server 内存版 DooTask 服务
+type Server struct {
+	*httptest.Server
+
+	fixtures Fixtures
+	custom   map[string]HandlerFunc // 自定义接口，优先于内置实现
+	routes   map[string]HandlerFunc // 内置接口
+	public   map[string]bool        // 无需登录的接口
+
+	mu       sync.Mutex
+	requests []Request
+	ids      map[string]int // 各类数据的自增ID
+
+	users    map[int]*User
+	bots     map[int]*dootask.Bot
+	dialogs  map[int]*Dialog
+	messages map[int]*dootask.DialogMessage
+	todos    map[int]*dootask.TodoItem
+	projects map[int]*Project
+	columns  map[int]*dootask.ProjectColumn
+	tasks    map[int]*Task
+	flows    map[int]*dootask.ProjectFlow // 按项目ID
+	tags     map[int]*dootask.ProjectTag
+	reports  map[int]*Report
+	files    map[int]*File
+	captchas map[string]bool // 已下发且未使用的验证码标识
+}
+
+// NewServer 启动服务并载入种子数据，测试结束时自动关闭
+func NewServer(tb testing.TB, opts ...Option) *Server {
+	s := &Server{
+		fixtures: DefaultFixtures(),
+		custom:   make(map[string]HandlerFunc),
+		public:   make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.load(s.fixtures)
+	s.registerRoutes()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	if tb != nil {
+		tb.Cleanup(s.Close)
+	}
+	return s
+}
+
+// Client 返回以 AdminToken 连接本服务的客户端
+func (s *Server) Client(opts ...dootask.ClientOption) *dootask.Client {
+	return s.ClientFor(AdminToken, opts...)
+}
+
+// ClientFor 返回以指定 token 连接本服务的客户端
+func (s *Server) ClientFor(token string, opts ...dootask.ClientOption) *dootask.Client {
+	return dootask.NewClient(token, append([]dootask.ClientOption{dootask.WithServer(s.URL)}, opts...)...)
+}
+
+// Handle 注册或覆盖接口（如模拟错误），自定义接口不持有服务锁，可调用服务的查询方法
+func (s *Server) Handle(path string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.custom[path] = fn
+}
+
+// route 注册内置接口
+func (s *Server) route(path string, fn HandlerFunc) {
+	s.routes[path] = fn
+}
+
+// serveHTTP 解析请求、鉴权并分发
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := newRequest(r)
+	if err != nil {
+		writeResponse(w, nil, &Error{Status: http.StatusBadRequest, Msg: err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	if u := s.userByToken(req.Token); u != nil {
+		req.UserID = int(u.UserID)
+	}
+	s.requests = append(s.requests, *req)
+	custom, isCustom := s.custom[req.Path]
+	route, isRoute := s.routes[req.Path]
+	public := s.public[req.Path]
+	s.mu.Unlock()
+
+	switch {
+	case isCustom:
+		data, err := custom(req)
+		writeResponse(w, data, err)
+	case !isRoute:
+		writeResponse(w, nil, &Error{Status: http.StatusNotFound, Msg: "接口不存在：" + req.Path})
+	case req.UserID == 0 && !public:
+		writeResponse(w, nil, errUnauthorized)
+	default:
+		s.mu.Lock()
+		data, err := route(req)
+		s.mu.Unlock()
+		writeResponse(w, data, err)
+	}
+}
+
+// Attachment 以附件（而非 {ret,msg,data}）输出的文件内容，用于下载接口
+type Attachment struct {
+	Name        string // 文件名
+	ContentType string // 缺省 application/octet-stream
+	Data        []byte // 文件内容
+}
+
+// writeResponse 输出 {ret,msg,data}，data 为 Attachment 时输出文件内容
+func writeResponse(w http.ResponseWriter, data any, err error) {
+	if a, ok := data.(Attachment); ok && err == nil {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+		w.Header().Set("Content-Length", strconv.Itoa(len(a.Data)))
+		w.Write(a.Data)
+		return
+	}
+	resp := map[string]any{"ret": 1, "msg": "success", "data": data}
+	status := http.StatusOK
+	if err != nil {
+		e, ok := err.(*Error)
+		if !ok {
+			e = &Error{Msg: err.Error()}
+		}
+		resp = map[string]any{"ret": e.Ret, "msg": e.Msg, "data": e.Data}
+		if e.Status != 0 {
+			status = e.Status
+		}
+	}
+	if resp["data"] == nil {
+		resp["data"] = map[string]any{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ------------------------------------------------------------------------------------------
+// 请求记录
+// ------------------------------------------------------------------------------------------
+
+// Request 服务收到的请求
+type Request struct {
+	Method string      // 请求方法
+	Path   string      // 接口路径
+	Header http.Header // 请求头
+	Token  string      // Token 请求头
+	UserID int         // token 对应的用户ID，未登录为 0
+	Params Params      // 查询参数与 JSON 请求体（或表单字段）合并后的参数
+	Files  []FormFile  // multipart 请求上传的文件
+}
+
+// FormFile multipart 请求上传的文件
+type FormFile struct {
+	Field string // 表单字段名
+	Name  string // 文件名
+	Data  []byte // 文件内容
+}
+
+// Params 请求参数；数组参数（如 userid[]）以去掉 [] 的键保存为切片
+type Params map[string]any
+
+// Has 是否包含参数
+func (p Params) Has(key string) bool {
+	_, ok := p[key]
+	return ok
+}
+
+// String 返回字符串参数
+func (p Params) String(key string) string {
+	switch v := p[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case []any:
+		if len(v) > 0 {
+			return fmt.Sprint(v[0])
+		}
+		return ""
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+// Int 返回整数参数，无法转换时为 0
+func (p Params) Int(key string) int {
+	n, _ := strconv.Atoi(p.String(key))
+	return n
+}
+
+// Bool 返回布尔参数（true、1、yes 视为 true）
+func (p Params) Bool(key string) bool {
+	switch strings.ToLower(p.String(key)) {
+	case "true", "1", "yes":
+		return true
+	}
+	return false
+}
+
+// Strings 返回数组参数，单值参数返回只含一个元素的切片
+func (p Params) Strings(key string) []string {
+	switch v := p[key].(type) {
+	case nil:
+		return nil
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			out = append(out, Params{"v": item}.String("v"))
+		}
+		return out
+	default:
+		if s := p.String(key); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+}
+
+// Ints 返回整数数组参数
+func (p Params) Ints(key string) []int {
+	var out []int
+	for _, s := range p.Strings(key) {
+		if n, err := strconv.Atoi(s); err == nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// maxUploadSize multipart 请求体的大小上限
+const maxUploadSize = 32 << 20
+
+// newRequest 解析查询参数与 JSON 请求体或 multipart 表单
+func newRequest(r *http.Request) (*Request, error) {
+	req := &Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Token:  r.Header.Get("Token"),
+		Params: Params{},
+	}
+	for key, values := range r.URL.Query() {
+		name, isArray := strings.CutSuffix(key, "[]")
+		if isArray || len(values) > 1 {
+			list := make([]any, len(values))
+			for i, v := range values {
+				list[i] = v
+			}
+			req.Params[name] = list
+		} else {
+			req.Params[name] = values[0]
+		}
+	}
+	if r.Body != nil && strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("invalid json body: %w", err)
+		}
+		for k, v := range body {
+			req.Params[k] = v
+		}
+	}
+	if r.Body != nil && strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+			return nil, fmt.Errorf("invalid multipart body: %w", err)
+		}
+		for k, values := range r.MultipartForm.Value {
+			req.Params[k] = values[0]
+		}
+		for field, headers := range r.MultipartForm.File {
+			for _, fh := range headers {
+				f, err := fh.Open()
+				if err != nil {
+					return nil, err
+				}
+				data, err := io.ReadAll(f)
+				f.Close()
+				if err != nil {
+					return nil, err
+				}
+				req.Files = append(req.Files, FormFile{Field: field, Name: fh.Filename, Data: data})
+			}
+		}
+	}
+	return req, nil
+}
+
+// Requests 返回收到的请求（按时间顺序），path 为空时返回全部
+func (s *Server) Requests(path string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Request
+	for _, r := range s.requests {
+		if path == "" || r.Path == path {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// LastRequest 返回指定接口收到的最后一个请求
+func (s *Server) LastRequest(path string) (Request, bool) {
+	reqs := s.Requests(path)
+	if len(reqs) == 0 {
+		return Request{}, false
+	}
+	return reqs[len(reqs)-1], true
+}
+
+// ResetRequests 清空请求记录
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// AssertCalled 断言接口被调用过，返回最后一个请求
+func (s *Server) AssertCalled(tb testing.TB, path string) Request {
+	tb.Helper()
+	req, ok := s.LastRequest(path)
+	if !ok {
+		tb.Fatalf("dootasktest: %s was not called", path)
+	}
+	return req
+}
+
+// AssertCalledTimes 断言接口被调用 n 次
+func (s *Server) AssertCalledTimes(tb testing.TB, path string, n int) {
+	tb.Helper()
+	if got := len(s.Requests(path)); got != n {
+		tb.Fatalf("dootasktest: %s called %d times, want %d", path, got, n)
+	}
+}
+
+// AssertNotCalled 断言接口未被调用
+func (s *Server) AssertNotCalled(tb testing.TB, path string) {
+	tb.Helper()
+	if got := len(s.Requests(path)); got != 0 {
+		tb.Fatalf("dootasktest: %s called %d times, want none", path, got)
+	}
+}
+
+// ------------------------------------------------------------------------------------------
+// 数据
+// ------------------------------------------------------------------------------------------
+
+// load 载入种子数据
+func (s *Server) load(f Fixtures) {
+	s.ids = make(map[string]int)
+	s.users = make(map[int]*User)
+	s.bots = make(map[int]*dootask.Bot)
+	s.dialogs = make(map[int]*Dialog)
+	s.messages = make(map[int]*dootask.DialogMessage)
+	s.todos = make(map[int]*dootask.TodoItem)
+	s.projects = make(map[int]*Project)
+	s.columns = make(map[int]*dootask.ProjectColumn)
+	s.tasks = make(map[int]*Task)
+	s.flows = make(map[int]*dootask.ProjectFlow)
+	s.tags = make(map[int]*dootask.ProjectTag)
+	s.reports = make(map[int]*Report)
+	s.files = make(map[int]*File)
+	s.captchas = make(map[string]bool)
+
+	for _, u := range f.Users {
+		u.UserID = uint(s.assignID("user", int(u.UserID)))
+		s.users[int(u.UserID)] = &u
+	}
+	for _, b := range f.Bots {
+		b.ID = s.assignID("user", b.ID)
+		s.bots[b.ID] = &b
+	}
+	for _, d := range f.Dialogs {
+		d.ID = s.assignID("dialog", d.ID)
+		d.Members = slices.Clone(d.Members)
+		s.dialogs[d.ID] = &d
+	}
+	for _, m := range f.Messages {
+		m.ID = s.assignID("message", m.ID)
+		if m.CreatedAt == "" {
+			m.CreatedAt = now()
+		}
+		s.messages[m.ID] = &m
+	}
+	for _, p := range f.Projects {
+		p.ID = s.assignID("project", p.ID)
+		p.Members = slices.Clone(p.Members)
+		s.projects[p.ID] = &p
+	}
+	for _, c := range f.Columns {
+		c.ID = s.assignID("column", c.ID)
+		s.columns[c.ID] = &c
+	}
+	for _, t := range f.Tasks {
+		t.ID = s.assignID("task", t.ID)
+		s.tasks[t.ID] = &t
+	}
+	for _, flow := range f.Flows {
+		flow.ID = s.assignID("flow", flow.ID)
+		flow.Items = slices.Clone(flow.Items)
+		for i := range flow.Items {
+			item := &flow.Items[i]
+			item.ID = s.assignID("flow_item", item.ID)
+			item.ProjectID, item.FlowID = flow.ProjectID, flow.ID
+		}
+		s.flows[flow.ProjectID] = &flow
+	}
+	for _, tag := range f.Tags {
+		tag.ID = s.assignID("tag", tag.ID)
+		s.tags[tag.ID] = &tag
+	}
+	for _, rep := range f.Reports {
+		rep.ID = s.assignID("report", rep.ID)
+		rep.Receives = slices.Clone(rep.Receives)
+		rep.ReadBy = slices.Clone(rep.ReadBy)
+		if rep.CreatedAt == "" {
+			rep.CreatedAt = now()
+			rep.UpdatedAt = rep.CreatedAt
+		}
+		s.reports[rep.ID] = &rep
+	}
+	for _, file := range f.Files {
+		file.ID = s.assignID("file", file.ID)
+		file.Data = slices.Clone(file.Data)
+		file.Shares = slices.Clone(file.Shares)
+		if file.CreatedAt == "" {
+			file.CreatedAt = now()
+			file.UpdatedAt = file.CreatedAt
+		}
+		s.files[file.ID] = &file
+	}
+}
+
+// assignID 使用指定ID（非 0 时）并推进自增值，返回最终ID
+func (s *Server) assignID(kind string, id int) int {
+	if id == 0 {
+		return s.nextID(kind)
+	}
+	s.ids[kind] = max(s.ids[kind], id)
+	return id
+}
+
+// nextID 分配自增ID
+func (s *Server) nextID(kind string) int {
+	s.ids[kind]++
+	return s.ids[kind]
+}
+
+func (s *Server) userByToken(token string) *User {
+	if token == "" {
+		return nil
+	}
+	for _, u := range s.users {
+		if u.Token == token && !u.tokenExpired() {
+			return u
+		}
+	}
+	return nil
+}
+
+// tokenExpired token 是否已过期
+func (u *User) tokenExpired() bool {
+	return !u.TokenExpiredAt.IsZero() && !time.Now().Before(u.TokenExpiredAt)
+}
+
+// now 返回当前时间字符串
+func now() string {
+	return time.Now().Format(time.DateTime)
+}
+
+// ExpireToken 使 token 立即过期：之后用它调用接口返回未登录，该用户再次登录时下发新 token。
+// token 不存在时返回 false
+func (s *Server) ExpireToken(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if token != "" && u.Token == token {
+			u.TokenExpiredAt = time.Now()
+			return true
+		}
+	}
+	return false
+}
+
+// Message 返回消息
+func (s *Server) Message(id int) (dootask.DialogMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.messages[id]; ok {
+		return *m, true
+	}
+	return dootask.DialogMessage{}, false
+}
+
+// Messages 返回对话中的消息（按ID升序）
+func (s *Server) Messages(dialogID int) []dootask.DialogMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []dootask.DialogMessage
+	for _, m := range s.dialogMessages(dialogID) {
+		out = append(out, *m)
+	}
+	return out
+}
+
+// Dialog 返回对话
+func (s *Server) Dialog(id int) (Dialog, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.dialogs[id]; ok {
+		c := *d
+		c.Members = slices.Clone(d.Members)
+		return c, true
+	}
+	return Dialog{}, false
+}
+
+// Project 返回项目
+func (s *Server) Project(id int) (Project, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.projects[id]; ok {
+		c := *p
+		c.Members = slices.Clone(p.Members)
+		return c, true
+	}
+	return Project{}, false
+}
+
+// Task 返回任务
+func (s *Server) Task(id int) (Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.tasks[id]; ok {
+		return *t, true
+	}
+	return Task{}, false
+}
+
+// Flow 返回项目的工作流
+func (s *Server) Flow(projectID int) (dootask.ProjectFlow, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.flows[projectID]; ok {
+		return cloneFlow(f), true
+	}
+	return dootask.ProjectFlow{}, false
+}
+
+// Report 返回报告
+func (s *Server) Report(id int) (Report, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rep, ok := s.reports[id]; ok {
+		c := *rep
+		c.Receives = slices.Clone(rep.Receives)
+		c.ReadBy = slices.Clone(rep.ReadBy)
+		return c, true
+	}
+	return Report{}, false
+}
+
+// File 返回文件
+func (s *Server) File(id int) (File, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.files[id]; ok {
+		c := *f
+		c.Data = slices.Clone(f.Data)
+		c.Shares = slices.Clone(f.Shares)
+		return c, true
+	}
+	return File{}, false
+}
+
+// sortedIDs 返回 map 的键（升序）
+func sortedIDs[T any](m map[int]T) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// paginate 按 page/pagesize 分页
+func paginate[T any](r *Request, path string, items []T, defaultSize int) dootask.ResponsePaginate[T] {
+	page := max(r.Params.Int("page"), 1)
+	size := r.Params.Int("pagesize")
+	if size <= 0 {
+		size = defaultSize
+	}
+	start := min((page-1)*size, len(items))
+	end := min(start+size, len(items))
+
+	resp := dootask.ResponsePaginate[T]{
+		CurrentPage: page,
+		Data:        items[start:end],
+		Path:        path,
+		PerPage:     dootask.FlexInt(size),
+		To:          dootask.FlexInt(end),
+		Total:       len(items),
+	}
+	if resp.Data == nil {
+		resp.Data = []T{}
+	}
+	if end < len(items) {
+		next := fmt.Sprintf("%s?page=%d", path, page+1)
+		resp.NextPageUrl = &next
+	}
+	if page > 1 {
+		prev := fmt.Sprintf("%s?page=%d", path, page-1)
+		resp.PrevPageUrl = &prev
+	}
+	return resp
+}
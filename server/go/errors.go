@@ -0,0 +1,140 @@
+package dootask
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ------------------------------------------------------------------------------------------
+// 错误定义
+// ------------------------------------------------------------------------------------------
+
+// 哨兵错误，配合 errors.Is 使用，例如 errors.Is(err, dootask.ErrUnauthorized)
+var (
+	ErrUnauthorized     = errors.New("dootask: unauthorized")      // 未登录或 token 失效
+	ErrPermissionDenied = errors.New("dootask: permission denied") // 权限不足
+	ErrNotFound         = errors.New("dootask: not found")         // 资源不存在
+	ErrCaptchaRequired  = errors.New("dootask: captcha required")  // 需要验证码
+	ErrRateLimited      = errors.New("dootask: rate limited")      // 请求过于频繁（HTTP 429）
+	ErrServerError      = errors.New("dootask: server error")      // 服务端或反代异常（HTTP 5xx）
+
+	ErrInvalidTransition = errors.New("dootask: invalid flow transition")  // 工作流状态不允许此流转（客户端校验）
+	ErrPageTimeout       = errors.New("dootask: page operation timed out") // 浏览器未在限定时间内回包
+)
+
+// APIError 接口错误：HTTP 状态码非 200，或业务状态 ret != 1
+type APIError struct {
+	Ret        int             // 业务状态码（HTTP 层失败且响应体不是标准结构时为 0）
+	Msg        string          // 错误信息（HTTP 层失败时为响应体文本）
+	Data       json.RawMessage // 错误附带数据，如 {"code":"need"}
+	StatusCode int             // HTTP 状态码
+	Method     string          // 请求方法
+	Endpoint   string          // 请求接口，如 /api/users/info
+
+	kind error // 对应的哨兵错误，可能为 nil
+}
+
+// Error 实现 error 接口；业务错误直接返回服务端信息
+func (e *APIError) Error() string {
+	if e.StatusCode != 0 && e.StatusCode != http.StatusOK {
+		if e.Msg != "" {
+			return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Msg)
+		}
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if e.Msg != "" {
+		return e.Msg
+	}
+	return fmt.Sprintf("API error: %d", e.Ret)
+}
+
+// Is 支持 errors.Is 匹配哨兵错误
+func (e *APIError) Is(target error) bool {
+	return e.kind != nil && target == e.kind
+}
+
+// newAPIError 创建接口错误并按状态码、ret、data 与错误信息归类
+func newAPIError(method, endpoint string, statusCode, ret int, msg string, data json.RawMessage) *APIError {
+	e := &APIError{
+		Ret:        ret,
+		Msg:        msg,
+		Data:       data,
+		StatusCode: statusCode,
+		Method:     method,
+		Endpoint:   endpoint,
+	}
+	e.kind = classifyAPIError(e)
+	return e
+}
+
+// classifyAPIError 归类接口错误，无法归类时返回 nil。依次按 HTTP 状态码、ret、
+// data.code 判断，最后才按错误信息中的关键词尽力归类
+func classifyAPIError(e *APIError) error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized, e.Ret == -1:
+		// 主程序以 ret=-1 表示身份失效，需要重新登录
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrPermissionDenied
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServerError
+	}
+
+	// 登录接口以 data.code=need 表示需要验证码
+	if len(e.Data) > 0 {
+		var d struct {
+			Code any `json:"code"`
+		}
+		if json.Unmarshal(e.Data, &d) == nil && d.Code == "need" {
+			return ErrCaptchaRequired
+		}
+	}
+
+	// 其余业务错误（ret=0）主程序只给出中文信息，按关键词尽力归类：
+	// 信息措辞变化时可能无法归类，需要精确判断的调用方应检查 Ret 与 Msg
+	if e.Ret != 0 {
+		return nil
+	}
+	switch {
+	case containsAny(e.Msg, "请登录", "身份已失效", "登录已过期"):
+		return ErrUnauthorized
+	case containsAny(e.Msg, "权限", "仅限", "无权"):
+		return ErrPermissionDenied
+	case containsAny(e.Msg, "不存在", "已被删除"):
+		return ErrNotFound
+	}
+	return nil
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// TransportError 传输层错误：连接失败、读取响应失败等，未拿到有效的 HTTP 响应
+type TransportError struct {
+	Method   string // 请求方法
+	Endpoint string // 请求接口
+	Err      error  // 底层错误
+}
+
+// Error 实现 error 接口
+func (e *TransportError) Error() string {
+	return "request failed: " + e.Err.Error()
+}
+
+// Unwrap 返回底层错误
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,305 @@
+package dootask
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+)
+
+// ------------------------------------------------------------------------------------------
+// 文件相关接口
+// ------------------------------------------------------------------------------------------
+
+// ListFiles 获取文件夹下的文件列表，pid 为 0 表示根目录
+func (c *Client) ListFiles(pid int) ([]File, error) {
+	var response []File
+	err := c.NewGetRequest("/api/file/lists", map[string]any{"pid": pid}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// SearchFiles 搜索文件
+func (c *Client) SearchFiles(params SearchFilesRequest) ([]File, error) {
+	var response []File
+	err := c.NewGetRequest("/api/file/search", params, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetFile 获取文件详情
+func (c *Client) GetFile(id int) (*File, error) {
+	var response File
+	err := c.NewGetRequest("/api/file/one", map[string]any{"id": id}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetFileContent 获取在线文档内容（文件夹与上传的二进制文件请用 DownloadFile）
+func (c *Client) GetFileContent(id int) (*FileContent, error) {
+	var response FileContent
+	err := c.NewGetRequest("/api/file/content", map[string]any{"id": id}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// FetchFileText 按路径读取文件的文本内容，offset、limit 为 0 时使用服务端默认值
+func (c *Client) FetchFileText(path string, offset, limit int) (*FileText, error) {
+	params := map[string]any{"path": path}
+	if offset > 0 {
+		params["offset"] = offset
+	}
+	if limit > 0 {
+		params["limit"] = limit
+	}
+	var response FileText
+	err := c.NewGetRequest("/api/file/fetch", params, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Mkdir 在 pid 下创建文件夹，pid 为 0 表示根目录
+func (c *Client) Mkdir(pid int, name string) (*File, error) {
+	var response File
+	err := c.NewGetRequest("/api/file/add", map[string]any{
+		"pid":  pid,
+		"name": name,
+		"type": "folder",
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RenameFile 重命名文件或文件夹
+func (c *Client) RenameFile(id int, name string) (*File, error) {
+	var response File
+	err := c.NewGetRequest("/api/file/add", map[string]any{
+		"id":   id,
+		"name": name,
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// MoveFiles 把文件移动到 pid 文件夹下，pid 为 0 表示根目录
+func (c *Client) MoveFiles(ids []int, pid int) ([]File, error) {
+	var response []File
+	err := c.NewGetRequest("/api/file/move", map[string]any{
+		"ids": ids,
+		"pid": pid,
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// DeleteFiles 删除文件或文件夹（文件夹连同其内容）
+func (c *Client) DeleteFiles(ids []int) error {
+	return c.NewGetRequest("/api/file/remove", map[string]any{"ids": ids}, nil)
+}
+
+// GetFileLink 获取文件分享链接，refresh 为 true 时重新生成（旧链接失效）
+func (c *Client) GetFileLink(id int, refresh bool) (*FileLink, error) {
+	params := map[string]any{"id": id, "refresh": "no"}
+	if refresh {
+		params["refresh"] = "yes"
+	}
+	var response FileLink
+	err := c.NewGetRequest("/api/file/link", params, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetFileShare 获取文件的共享成员
+func (c *Client) GetFileShare(id int) (*FileShareInfo, error) {
+	var response FileShareInfo
+	err := c.NewGetRequest("/api/file/share", map[string]any{"id": id}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateFileShare 设置或取消文件共享
+func (c *Client) UpdateFileShare(params UpdateFileShareRequest) error {
+	return c.NewGetRequest("/api/file/share/update", params, nil)
+}
+
+// ExitFileShare 退出他人共享给自己的文件
+func (c *Client) ExitFileShare(id int) error {
+	return c.NewGetRequest("/api/file/share/out", map[string]any{"id": id}, nil)
+}
+
+// UploadFile 上传文件，内容从 r 流式读取并以 multipart 发送，不会整体读入内存。
+// 传输受 WithTimeout 限制，上传大文件时应调大超时或改用 WithContext 控制。
+// 请求体不可重放，上传不会重试；r 实现 io.Seeker 时，token 失效并刷新后会从原位置重新上传一次
+func (c *Client) UploadFile(params UploadFileRequest, r io.Reader) (*File, error) {
+	if params.Name == "" {
+		return nil, fmt.Errorf("upload file: name is required")
+	}
+	ctx := c.Context()
+	// r 可定位时记下起始位置，token 刷新后从该位置重新上传
+	seeker, replayable := r.(io.Seeker)
+	var offset int64
+	if replayable {
+		var err error
+		if offset, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			replayable = false
+		}
+	}
+	attempt := 0
+
+	var response File
+	_, err := c.authorized(ctx, replayable, func(token string) error {
+		if attempt++; attempt > 1 {
+			if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+				return fmt.Errorf("rewind upload content: %w", err)
+			}
+		}
+		return c.upload(ctx, token, params, r, &response)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// upload 以 token 发送一次上传请求
+func (c *Client) upload(ctx context.Context, token string, params UploadFileRequest, r io.Reader, response *File) error {
+	const api = "/api/file/content/upload"
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(writeUpload(mw, params, r))
+	}()
+	// 服务器可能未读完请求体就响应（如 token 失效），返回前关闭管道并等待写入结束，
+	// 之后才能重新定位 r 重试
+	defer func() {
+		pr.CloseWithError(io.ErrClosedPipe)
+		<-done
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.server+api, pr)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	c.setHeaders(req, token)
+
+	resp, err := c.do(ctx, req, api)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return &TransportError{Method: "POST", Endpoint: api, Err: err}
+	}
+	defer resp.Body.Close()
+	return decodeResponse(ctx, "POST", api, resp, response)
+}
+
+// writeUpload 写入上传表单：pid、cover 与文件内容
+func writeUpload(mw *multipart.Writer, params UploadFileRequest, r io.Reader) error {
+	cover := "0"
+	if params.Cover {
+		cover = "1"
+	}
+	if err := mw.WriteField("pid", fmt.Sprint(params.PID)); err != nil {
+		return err
+	}
+	if err := mw.WriteField("cover", cover); err != nil {
+		return err
+	}
+	part, err := mw.CreateFormFile("files", params.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return fmt.Errorf("read upload content: %w", err)
+	}
+	return mw.Close()
+}
+
+// DownloadFile 下载文件内容并流式写入 w，返回写入的字节数。
+// 传输受 WithTimeout 限制，下载大文件时应调大超时或改用 WithContext 控制
+func (c *Client) DownloadFile(id int, w io.Writer) (int64, error) {
+	ctx := c.Context()
+	var n int64
+	// token 失效时尚未写入内容，可以重试
+	_, err := c.authorized(ctx, true, func(token string) (err error) {
+		n, err = c.download(ctx, token, id, w)
+		return err
+	})
+	return n, err
+}
+
+// download 以 token 发送一次下载请求
+func (c *Client) download(ctx context.Context, token string, id int, w io.Writer) (int64, error) {
+	const api = "/api/file/content"
+
+	fullURL, err := buildURL(c.server+api, map[string]any{"id": id, "down": "yes"})
+	if err != nil {
+		return 0, fmt.Errorf("build query failed: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("create request failed: %w", err)
+	}
+	c.setHeaders(req, token)
+
+	resp, err := c.do(ctx, req, api)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, ctxErr
+		}
+		return 0, &TransportError{Method: "GET", Endpoint: api, Err: err}
+	}
+	defer resp.Body.Close()
+
+	// 文件以附件返回；失败时返回 {ret,msg,data}
+	if resp.StatusCode != http.StatusOK || !isAttachment(resp) {
+		if err := decodeResponse(ctx, "GET", api, resp, nil); err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("download file: unexpected non-file response")
+	}
+
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return n, ctxErr
+		}
+		return n, &TransportError{Method: "GET", Endpoint: api, Err: fmt.Errorf("read response failed: %w", err)}
+	}
+	return n, nil
+}
+
+// isAttachment 响应是否为文件内容：带 Content-Disposition，或不是 JSON
+func isAttachment(resp *http.Response) bool {
+	if resp.Header.Get("Content-Disposition") != "" {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType != "application/json"
+}
@@ -0,0 +1,92 @@
+package dootask
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ------------------------------------------------------------------------------------------
+// 工作流相关接口
+// ------------------------------------------------------------------------------------------
+
+// GetProjectFlows 获取项目工作流列表（未开启工作流时为空）
+func (c *Client) GetProjectFlows(projectID int) ([]ProjectFlow, error) {
+	var response []ProjectFlow
+	err := c.NewGetRequest("/api/project/flow/list", map[string]any{"project_id": projectID}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetProjectFlow 获取项目工作流，未开启工作流时返回 ErrNotFound
+func (c *Client) GetProjectFlow(projectID int) (*ProjectFlow, error) {
+	flows, err := c.GetProjectFlows(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(flows) == 0 {
+		return nil, fmt.Errorf("project %d has no flow: %w", projectID, ErrNotFound)
+	}
+	return &flows[0], nil
+}
+
+// SaveProjectFlow 创建或更新项目工作流
+func (c *Client) SaveProjectFlow(params SaveProjectFlowRequest) (*ProjectFlow, error) {
+	var response []ProjectFlow
+	err := c.NewPostRequest("/api/project/flow/save", params, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, fmt.Errorf("project %d: empty flow in response", params.ProjectID)
+	}
+	return &response[0], nil
+}
+
+// DeleteProjectFlow 删除项目工作流（任务保留，状态清空）
+func (c *Client) DeleteProjectFlow(projectID int) error {
+	return c.NewGetRequest("/api/project/flow/delete", map[string]any{"project_id": projectID}, nil)
+}
+
+// TransitionTask 把任务流转到名为 flowItemName 的工作流状态。
+// 先在客户端校验状态存在且当前状态允许流转到目标状态，不允许时返回 ErrInvalidTransition；
+// 任务已处于目标状态时不发请求直接返回
+func (c *Client) TransitionTask(taskID int, flowItemName string) (*ProjectTask, error) {
+	task, err := c.GetTask(GetTaskRequest{TaskID: taskID})
+	if err != nil {
+		return nil, err
+	}
+	flow, err := c.GetProjectFlow(task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	target, ok := flow.Item(flowItemName)
+	if !ok {
+		return nil, fmt.Errorf("task %d: unknown flow item %q: %w", taskID, flowItemName, ErrInvalidTransition)
+	}
+	if task.FlowItemID == target.ID {
+		return task, nil
+	}
+	if current, ok := flow.ItemByID(task.FlowItemID); ok && !current.CanTurnTo(target.ID) {
+		var allowed []string
+		for _, id := range current.Turns {
+			if item, ok := flow.ItemByID(id); ok {
+				allowed = append(allowed, item.Name)
+			}
+		}
+		return nil, fmt.Errorf("task %d: cannot turn from %q to %q (allowed: %s): %w",
+			taskID, current.Name, target.Name, strings.Join(allowed, ", "), ErrInvalidTransition)
+	}
+
+	columnID := task.ColumnID
+	if target.ColumnID > 0 {
+		columnID = target.ColumnID
+	}
+	return c.MoveTask(MoveTaskRequest{
+		TaskID:     taskID,
+		ProjectID:  task.ProjectID,
+		ColumnID:   columnID,
+		FlowItemID: Set(target.ID),
+	})
+}
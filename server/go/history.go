@@ -0,0 +1,124 @@
+package dootask
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+)
+
+// ------------------------------------------------------------------------------------------
+// 消息历史遍历
+// ------------------------------------------------------------------------------------------
+
+// maxMessageTake 消息列表接口单次最多返回的条数
+const maxMessageTake = 100
+
+// MessageOption 消息历史遍历选项
+type MessageOption func(*messageConfig)
+
+type messageConfig struct {
+	msgType  string // 消息类型过滤
+	take     int    // 每次请求条数
+	maxItems int    // 最多返回条数，<=0 表示不限
+}
+
+// WithMessageType 只遍历指定类型的消息：tag、todo、link、text、image、file、record、meeting
+func WithMessageType(msgType string) MessageOption {
+	return func(cfg *messageConfig) {
+		cfg.msgType = msgType
+	}
+}
+
+// WithMessageBatch 设置每次请求的条数，默认且最大 100
+func WithMessageBatch(take int) MessageOption {
+	return func(cfg *messageConfig) {
+		cfg.take = take
+	}
+}
+
+// WithMessageLimit 限制最多返回的消息条数
+func WithMessageLimit(n int) MessageOption {
+	return func(cfg *messageConfig) {
+		cfg.maxItems = n
+	}
+}
+
+// MessagesBefore 从新到旧遍历对话中 ID 小于 fromMsgID 的消息；fromMsgID <= 0 时从最新消息开始
+func (c *Client) MessagesBefore(ctx context.Context, dialogID, fromMsgID int, opts ...MessageOption) iter.Seq2[DialogMessage, error] {
+	return c.messageHistory(ctx, dialogID, fromMsgID, false, opts)
+}
+
+// MessagesAfter 从旧到新遍历对话中 ID 大于 fromMsgID 的消息，fromMsgID 必须大于 0
+func (c *Client) MessagesAfter(ctx context.Context, dialogID, fromMsgID int, opts ...MessageOption) iter.Seq2[DialogMessage, error] {
+	return c.messageHistory(ctx, dialogID, fromMsgID, true, opts)
+}
+
+// messageHistory 以 prev_id / next_id 为游标逐批请求消息，
+// 每批按遍历方向排序，并丢弃不在游标之后的消息（接口可能返回游标处的边界消息）
+func (c *Client) messageHistory(ctx context.Context, dialogID, fromMsgID int, forward bool, opts []MessageOption) iter.Seq2[DialogMessage, error] {
+	cfg := messageConfig{take: maxMessageTake}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.take <= 0 || cfg.take > maxMessageTake {
+		cfg.take = maxMessageTake
+	}
+
+	return func(yield func(DialogMessage, error) bool) {
+		if forward && fromMsgID <= 0 {
+			yield(DialogMessage{}, errors.New("fromMsgID must be greater than 0"))
+			return
+		}
+
+		cursor := fromMsgID
+		count := 0
+		for {
+			params := GetMessageListRequest{
+				DialogID: dialogID,
+				MsgType:  cfg.msgType,
+				Take:     cfg.take,
+			}
+			if forward {
+				params.NextID = cursor
+			} else {
+				params.PrevID = cursor
+			}
+
+			resp, err := c.WithContext(ctx).GetMessageList(params)
+			if err != nil {
+				yield(DialogMessage{}, err)
+				return
+			}
+
+			list := slices.Clone(resp.List)
+			slices.SortFunc(list, func(a, b DialogMessage) int {
+				if forward {
+					return a.ID - b.ID
+				}
+				return b.ID - a.ID
+			})
+
+			next := cursor
+			for _, msg := range list {
+				if cursor > 0 && ((forward && msg.ID <= cursor) || (!forward && msg.ID >= cursor)) {
+					continue
+				}
+				if cfg.maxItems > 0 && count >= cfg.maxItems {
+					return
+				}
+				count++
+				next = msg.ID
+				if !yield(msg, nil) {
+					return
+				}
+			}
+
+			// 不足一批说明已到尽头；游标未前进时也结束，避免死循环
+			if len(resp.List) < cfg.take || next == cursor {
+				return
+			}
+			cursor = next
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package dootask
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ------------------------------------------------------------------------------------------
+// 消息内容解析
+// ------------------------------------------------------------------------------------------
+
+// MessageBody 消息内容，按消息类型解析为具体结构体，未知类型为 *RawMessage
+type MessageBody interface {
+	MessageType() string // 消息类型
+}
+
+// TextMessage 文本消息（text）
+type TextMessage struct {
+	Text string `json:"text"` // 消息内容（HTML 或 Markdown）
+	Type string `json:"type"` // 文本格式，如 md
+}
+
+// FileMessage 文件消息（file），图片消息（image）也使用此结构
+type FileMessage struct {
+	Name   string `json:"name"`   // 文件名
+	Size   int64  `json:"size"`   // 文件大小（字节）
+	Ext    string `json:"ext"`    // 扩展名
+	Path   string `json:"path"`   // 文件地址
+	Thumb  string `json:"thumb"`  // 缩略图地址
+	Width  int    `json:"width"`  // 图片宽度
+	Height int    `json:"height"` // 图片高度
+
+	msgType string
+}
+
+// RecordMessage 语音消息（record）
+type RecordMessage struct {
+	Path     string `json:"path"`     // 语音地址
+	Size     int64  `json:"size"`     // 文件大小（字节）
+	Duration int    `json:"duration"` // 时长（毫秒）
+	Text     string `json:"text"`     // 语音转文字结果
+}
+
+// MeetingMessage 会议消息（meeting）
+type MeetingMessage struct {
+	MeetingID string `json:"meetingid"`  // 会议ID
+	Name      string `json:"name"`       // 会议主题
+	ChannelID string `json:"channel"`    // 会议频道
+	UserID    int    `json:"userid"`     // 发起人ID
+	CreatedAt string `json:"created_at"` // 创建时间
+}
+
+// TagMessage 标注消息（tag），记录对某条消息的标注/取消标注
+type TagMessage struct {
+	Action string          `json:"action"` // 操作：add、remove
+	Data   json.RawMessage `json:"data"`   // 被标注的消息
+}
+
+// TodoMessage 待办消息（todo），记录对某条消息设置/取消待办
+type TodoMessage struct {
+	Action  string          `json:"action"`  // 操作：add、remove、done
+	Data    json.RawMessage `json:"data"`    // 被设为待办的消息
+	UserIDs []int           `json:"userids"` // 待办成员
+}
+
+// NoticeMessage 通知消息（notice）
+type NoticeMessage struct {
+	Notice string `json:"notice"` // 通知内容
+}
+
+// TemplateMessage 模板消息（template）
+type TemplateMessage struct {
+	Type    string            `json:"type"`    // 模板类型
+	Title   string            `json:"title"`   // 模板标题
+	Content []TemplateContent `json:"content"` // 模板内容
+}
+
+// BotMessage 机器人消息（bot），如机器人推送的卡片
+type BotMessage struct {
+	Type    string          `json:"type"`     // 机器人消息类型
+	Title   string          `json:"title"`    // 标题
+	Text    string          `json:"text"`     // 内容
+	Data    json.RawMessage `json:"data"`     // 附加数据
+	BotType string          `json:"bot_type"` // 机器人类型
+}
+
+// VoteMessage 投票消息（vote）
+type VoteMessage struct {
+	Text      string          `json:"text"`      // 投票主题
+	Type      string          `json:"type"`      // 类型：create、vote、finish
+	UUID      string          `json:"uuid"`      // 投票标识
+	Multiple  FlexInt         `json:"multiple"`  // 是否多选
+	Anonymous FlexInt         `json:"anonymous"` // 是否匿名
+	List      json.RawMessage `json:"list"`      // 选项
+	Votes     json.RawMessage `json:"votes"`     // 投票记录
+}
+
+// WordChainMessage 接龙消息（word-chain）
+type WordChainMessage struct {
+	Text string          `json:"text"` // 接龙主题
+	Type string          `json:"type"` // 类型：create、participate
+	UUID string          `json:"uuid"` // 接龙标识
+	List json.RawMessage `json:"list"` // 接龙列表
+}
+
+// RawMessage 未识别类型的消息，保留原始 JSON
+type RawMessage struct {
+	Type string          // 消息类型
+	Raw  json.RawMessage // 原始消息内容
+}
+
+// MessageType 实现 MessageBody 接口
+func (*TextMessage) MessageType() string      { return "text" }
+func (*RecordMessage) MessageType() string    { return "record" }
+func (*MeetingMessage) MessageType() string   { return "meeting" }
+func (*TagMessage) MessageType() string       { return "tag" }
+func (*TodoMessage) MessageType() string      { return "todo" }
+func (*NoticeMessage) MessageType() string    { return "notice" }
+func (*TemplateMessage) MessageType() string  { return "template" }
+func (*BotMessage) MessageType() string       { return "bot" }
+func (*VoteMessage) MessageType() string      { return "vote" }
+func (*WordChainMessage) MessageType() string { return "word-chain" }
+func (m *RawMessage) MessageType() string     { return m.Type }
+
+// MessageType 返回 file 或 image
+func (m *FileMessage) MessageType() string {
+	if m.msgType != "" {
+		return m.msgType
+	}
+	return "file"
+}
+
+// IsImage 是否为图片
+func (m *FileMessage) IsImage() bool {
+	return m.MessageType() == "image"
+}
+
+// DecodeMessage 按消息类型解析消息内容；msg 可以是 json.RawMessage、[]byte 或接口返回的 map
+func DecodeMessage(msgType string, msg any) (MessageBody, error) {
+	var raw json.RawMessage
+	switch v := msg.(type) {
+	case json.RawMessage:
+		raw = v
+	case []byte:
+		raw = v
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("marshal message failed: %w", err)
+		}
+		raw = b
+	}
+
+	var body MessageBody
+	switch msgType {
+	case "text":
+		body = &TextMessage{}
+	case "file", "image":
+		body = &FileMessage{msgType: msgType}
+	case "record":
+		body = &RecordMessage{}
+	case "meeting":
+		body = &MeetingMessage{}
+	case "tag":
+		body = &TagMessage{}
+	case "todo":
+		body = &TodoMessage{}
+	case "notice":
+		body = &NoticeMessage{}
+	case "template":
+		body = &TemplateMessage{}
+	case "bot":
+		body = &BotMessage{}
+	case "vote":
+		body = &VoteMessage{}
+	case "word-chain":
+		body = &WordChainMessage{}
+	default:
+		return &RawMessage{Type: msgType, Raw: raw}, nil
+	}
+
+	if len(raw) == 0 || string(raw) == "null" {
+		return body, nil
+	}
+	if err := json.Unmarshal(raw, body); err != nil {
+		return nil, fmt.Errorf("decode %s message failed: %w", msgType, err)
+	}
+	return body, nil
+}
+
+// Decode 解析消息内容
+func (m *DialogMessage) Decode() (MessageBody, error) {
+	return DecodeMessage(m.Type, m.Msg)
+}
+
+// AsText 文本消息返回其内容，其它类型返回 false
+func (m *DialogMessage) AsText() (string, bool) {
+	return messageText(m.Type, m.Msg)
+}
+
+// Decode 解析消息内容
+func (m *MessageSearchItem) Decode() (MessageBody, error) {
+	return DecodeMessage(m.Type, m.Msg)
+}
+
+// AsText 文本消息返回其内容，其它类型返回 false
+func (m *MessageSearchItem) AsText() (string, bool) {
+	return messageText(m.Type, m.Msg)
+}
+
+// LastMessage 解析最后一条消息，会话没有消息时返回 nil
+func (d *DialogInfo) LastMessage() (*DialogMessage, error) {
+	if d.LastMsg == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(d.LastMsg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal last message failed: %w", err)
+	}
+	var msg DialogMessage
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return nil, fmt.Errorf("decode last message failed: %w", err)
+	}
+	return &msg, nil
+}
+
+func messageText(msgType string, msg any) (string, bool) {
+	if msgType != "text" {
+		return "", false
+	}
+	body, err := DecodeMessage(msgType, msg)
+	if err != nil {
+		return "", false
+	}
+	return body.(*TextMessage).Text, true
+}
@@ -0,0 +1,124 @@
+package dootask
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ------------------------------------------------------------------------------------------
+// 可选字段
+// ------------------------------------------------------------------------------------------
+
+// Optional 更新请求中的可选字段：零值表示未设置，不会发送；用 Set 设置后即使是零值
+// （如空字符串、空数组）也会发送，用于清空字段
+//
+//	client.UpdateTask(dootask.UpdateTaskRequest{
+//	    TaskID: 1,
+//	    Name:   dootask.Set("新名称"),
+//	    Assist: dootask.Set([]int{}), // 清空协助人
+//	})
+type Optional[T any] struct {
+	value T
+	set   bool
+}
+
+// Set 返回已设置为 v 的可选字段
+func Set[T any](v T) Optional[T] {
+	return Optional[T]{value: v, set: true}
+}
+
+// Get 返回值以及是否已设置
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.set
+}
+
+// IsSet 是否已设置
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// IsZero 未设置时为 true（配合 Go 1.24+ 的 json omitzero 标签）
+func (o Optional[T]) IsZero() bool {
+	return !o.set
+}
+
+// MarshalJSON 实现 json.Marshaler，未设置时为 null
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，出现的字段（包括 null）视为已设置
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &o.value); err != nil {
+		return err
+	}
+	o.set = true
+	return nil
+}
+
+// String 实现 fmt.Stringer
+func (o Optional[T]) String() string {
+	if !o.set {
+		return "<unset>"
+	}
+	return fmt.Sprint(o.value)
+}
+
+// optionalValue 供查询参数与请求体编码识别 Optional
+func (o Optional[T]) optionalValue() (any, bool) {
+	return o.value, o.set
+}
+
+// optional 由 Optional[T] 实现
+type optional interface {
+	optionalValue() (any, bool)
+}
+
+var optionalType = reflect.TypeFor[optional]()
+
+// marshalPatch 序列化更新请求：按字段顺序输出，跳过未设置的 Optional 字段
+func marshalPatch(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	rt := rv.Type()
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		fv := rv.Field(i)
+		if o, ok := fv.Interface().(optional); ok {
+			if _, set := o.optionalValue(); !set {
+				continue
+			}
+		}
+		value, err := json.Marshal(fv.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s: %w", name, err)
+		}
+		key, _ := json.Marshal(name)
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,308 @@
+package dootask
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ------------------------------------------------------------------------------------------
+// 页面操作
+// ------------------------------------------------------------------------------------------
+
+// PageOpsOption 页面操作选项
+type PageOpsOption func(*PageOps)
+
+// defaultPagePollInitial 轮询结果的默认初始间隔
+const defaultPagePollInitial = 200 * time.Millisecond
+
+// WithPagePoll 设置轮询结果的间隔：从 initial 开始，每次乘以 factor，最长 maxInterval。
+// 默认 200 毫秒起、1.5 倍、最长 2 秒；factor <= 1 时固定间隔。
+// initial <= 0 时取默认的 200 毫秒，maxInterval 小于 initial 时取 initial
+func WithPagePoll(initial, maxInterval time.Duration, factor float64) PageOpsOption {
+	return func(p *PageOps) {
+		if initial <= 0 {
+			initial = defaultPagePollInitial
+		}
+		p.pollInitial, p.pollMax, p.pollFactor = initial, max(maxInterval, initial), factor
+	}
+}
+
+// WithPageTimeout 设置等待浏览器回包的超时，默认 30 秒；<= 0 时只受 ctx 控制
+func WithPageTimeout(d time.Duration) PageOpsOption {
+	return func(p *PageOps) {
+		p.timeout = d
+	}
+}
+
+// PageOps 页面操作客户端：经主程序常驻 WebSocket 向用户浏览器派发操作（获取页面上下文、
+// 执行业务操作、操作页面元素），再轮询取回浏览器的执行结果
+type PageOps struct {
+	client      *Client
+	fd          int
+	pollInitial time.Duration
+	pollMax     time.Duration
+	pollFactor  float64
+	timeout     time.Duration
+}
+
+// PageOps 创建面向会话 fd 的页面操作客户端。fd 为用户当前在线的 WebSocket 连接
+// （见 ConnectedEvent.FD），归属与在线状态由主程序校验
+func (c *Client) PageOps(fd int, opts ...PageOpsOption) *PageOps {
+	p := &PageOps{
+		client:      c,
+		fd:          fd,
+		pollInitial: defaultPagePollInitial,
+		pollMax:     2 * time.Second,
+		pollFactor:  1.5,
+		timeout:     30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// PageContextRequest 获取页面上下文
+type PageContextRequest struct {
+	Query           string // 按语义查找相关元素
+	Container       string // 限定容器
+	MaxElements     int    // 返回元素上限，0 为浏览器默认
+	Offset          int    // 元素分页偏移
+	InteractiveOnly bool   // 仅返回可交互元素
+	NoElements      bool   // 不返回元素列表
+}
+
+// PageContext 页面上下文
+type PageContext struct {
+	URL           string          `json:"url"`
+	Title         string          `json:"title"`
+	Elements      []PageElement   `json:"elements"`
+	TotalElements int             `json:"total_elements"`
+	HasMore       bool            `json:"has_more"`
+	Raw           json.RawMessage `json:"-"` // 浏览器返回的原始结果
+}
+
+// PageElement 页面元素
+type PageElement struct {
+	UID         string `json:"uid"` // 元素UID，用于 ExecuteElementAction
+	Tag         string `json:"tag"`
+	Role        string `json:"role"`
+	Name        string `json:"name"`
+	Text        string `json:"text"`
+	Value       string `json:"value"`
+	Interactive bool   `json:"interactive"`
+}
+
+// ElementActionRequest 对页面元素执行动作
+type ElementActionRequest struct {
+	ElementUID string `json:"element_uid"`     // 元素UID
+	Action     string `json:"action"`          // 动作，如 click、fill
+	Value      string `json:"value,omitempty"` // 动作的值，如 fill 的文本
+}
+
+// ElementActionResult 元素动作的执行结果
+type ElementActionResult struct {
+	ElementUID string          `json:"element_uid"`
+	Action     string          `json:"action"`
+	Message    string          `json:"message"`
+	Raw        json.RawMessage `json:"-"` // 浏览器返回的原始结果
+}
+
+// PageOpError 浏览器执行页面操作失败
+type PageOpError struct {
+	RequestID string // 派发请求ID
+	Action    string // 操作类型，如 execute_action
+	Message   string // 浏览器返回的错误信息
+}
+
+// Error 实现 error 接口
+func (e *PageOpError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("page operation %s failed", e.Action)
+	}
+	return fmt.Sprintf("page operation %s failed: %s", e.Action, e.Message)
+}
+
+// GetPageContext 派发获取页面上下文的操作
+func (p *PageOps) GetPageContext(ctx context.Context, params PageContextRequest) (*PageOp[PageContext], error) {
+	payload := map[string]any{}
+	if params.Query != "" {
+		payload["query"] = params.Query
+	}
+	if params.Container != "" {
+		payload["container"] = params.Container
+	}
+	if params.MaxElements > 0 {
+		payload["max_elements"] = params.MaxElements
+	}
+	if params.Offset > 0 {
+		payload["offset"] = params.Offset
+	}
+	if params.InteractiveOnly {
+		payload["interactive_only"] = true
+	}
+	if params.NoElements {
+		payload["include_elements"] = false
+	}
+	return dispatchPageOp(ctx, p, "get_page_context", payload, func(raw json.RawMessage) (PageContext, error) {
+		result := PageContext{Raw: raw}
+		return result, decodePageResult(raw, &result)
+	})
+}
+
+// ExecuteAction 派发业务页面操作 name，params 为操作参数；结果为浏览器返回的原始 JSON
+func (p *PageOps) ExecuteAction(ctx context.Context, name string, params map[string]any) (*PageOp[json.RawMessage], error) {
+	payload := map[string]any{"name": name}
+	if params != nil {
+		payload["params"] = params
+	}
+	return dispatchPageOp(ctx, p, "execute_action", payload, func(raw json.RawMessage) (json.RawMessage, error) {
+		return raw, nil
+	})
+}
+
+// ExecuteElementAction 派发页面元素动作
+func (p *PageOps) ExecuteElementAction(ctx context.Context, params ElementActionRequest) (*PageOp[ElementActionResult], error) {
+	if params.ElementUID == "" || params.Action == "" {
+		return nil, errors.New("execute element action: element uid and action are required")
+	}
+	return dispatchPageOp(ctx, p, "execute_element_action", params, func(raw json.RawMessage) (ElementActionResult, error) {
+		result := ElementActionResult{Raw: raw}
+		return result, decodePageResult(raw, &result)
+	})
+}
+
+// decodePageResult 解析对象形式的结果；非对象（如 null、字符串）时只保留原始结果
+func decodePageResult(raw json.RawMessage, v any) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("unmarshal page operation result failed: %w", err)
+	}
+	return nil
+}
+
+// PageOp 已派发的页面操作，在后台轮询结果，可在多个 goroutine 中等待
+type PageOp[T any] struct {
+	RequestID string // 派发请求ID
+	Action    string // 操作类型
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	result T
+	err    error
+}
+
+// dispatchPageOp 派发操作并启动后台轮询；派发失败时直接返回错误。
+// 轮询受 ctx 与 PageOps 的超时控制
+func dispatchPageOp[T any](ctx context.Context, p *PageOps, action string, payload any, decode func(json.RawMessage) (T, error)) (*PageOp[T], error) {
+	var dispatched struct {
+		RequestID string `json:"requestId"`
+	}
+	err := p.client.NewPostRequestWithContext(ctx, "/api/assistant/operation/dispatch", map[string]any{
+		"fd":      p.fd,
+		"action":  action,
+		"payload": payload,
+	}, &dispatched)
+	if err != nil {
+		return nil, err
+	}
+	if dispatched.RequestID == "" {
+		return nil, fmt.Errorf("dispatch page operation %s: empty request id", action)
+	}
+
+	var cancel context.CancelFunc
+	if p.timeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, p.timeout, fmt.Errorf("page operation %s: %w (%w)", action, ErrPageTimeout, context.DeadlineExceeded))
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	op := &PageOp[T]{
+		RequestID: dispatched.RequestID,
+		Action:    action,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	go func() {
+		defer close(op.done)
+		defer cancel()
+		raw, err := p.poll(ctx, op.RequestID, action)
+		if err == nil {
+			op.result, err = decode(raw)
+		}
+		op.err = err
+	}()
+	return op, nil
+}
+
+// poll 按退避间隔轮询操作结果，直到浏览器回包或 ctx 结束
+func (p *PageOps) poll(ctx context.Context, requestID, action string) (json.RawMessage, error) {
+	interval := p.pollInitial
+	for {
+		var res struct {
+			Status  string          `json:"status"`
+			Success bool            `json:"success"`
+			Result  json.RawMessage `json:"result"`
+			Error   string          `json:"error"`
+		}
+		err := p.client.NewGetRequestWithContext(ctx, "/api/assistant/operation/result", map[string]any{"request_id": requestID}, &res)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, pageOpCause(ctx)
+			}
+			return nil, err
+		}
+		if res.Status == "ready" {
+			if !res.Success {
+				return nil, &PageOpError{RequestID: requestID, Action: action, Message: res.Error}
+			}
+			return res.Result, nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, pageOpCause(ctx)
+		case <-timer.C:
+		}
+		if p.pollFactor > 1 {
+			interval = min(time.Duration(float64(interval)*p.pollFactor), p.pollMax)
+		}
+	}
+}
+
+// pageOpCause 返回 ctx 结束的原因：超过 WithPageTimeout 时为 ErrPageTimeout（同时匹配
+// context.DeadlineExceeded），否则为 ctx.Err()
+func pageOpCause(ctx context.Context) error {
+	if cause := context.Cause(ctx); errors.Is(cause, ErrPageTimeout) {
+		return cause
+	}
+	return ctx.Err()
+}
+
+// Wait 等待操作完成并返回结果。ctx 结束时返回 ctx.Err()，但不会取消操作，可再次等待
+func (op *PageOp[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-op.done:
+		return op.result, op.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Done 返回操作完成时关闭的通道
+func (op *PageOp[T]) Done() <-chan struct{} {
+	return op.done
+}
+
+// Cancel 停止轮询，操作以 context.Canceled 结束（浏览器端已开始的操作不会撤回）
+func (op *PageOp[T]) Cancel() {
+	op.cancel()
+}
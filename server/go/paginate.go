@@ -0,0 +1,151 @@
+package dootask
+
+import (
+	"context"
+	"iter"
+)
+
+// ------------------------------------------------------------------------------------------
+// 自动分页
+// ------------------------------------------------------------------------------------------
+
+// PageOption 自动分页选项
+type PageOption func(*pageConfig)
+
+type pageConfig struct {
+	prefetch bool // 消费当前页时预取下一页
+	maxItems int  // 最多返回条数，<=0 表示不限
+}
+
+// WithPrefetch 在消费当前页时并发预取下一页
+func WithPrefetch() PageOption {
+	return func(cfg *pageConfig) {
+		cfg.prefetch = true
+	}
+}
+
+// WithMaxItems 限制最多返回的条数
+func WithMaxItems(n int) PageOption {
+	return func(cfg *pageConfig) {
+		cfg.maxItems = n
+	}
+}
+
+// pageFetcher 获取指定页
+type pageFetcher[T any] func(ctx context.Context, page int) (*ResponsePaginate[T], error)
+
+type pageResult[T any] struct {
+	page *ResponsePaginate[T]
+	err  error
+}
+
+// paginate 从 startPage 开始逐页获取并逐条产出；出错时产出一次错误后结束。
+// 没有下一页（next_page_url 为空）或返回空页时结束
+func paginate[T any](ctx context.Context, startPage int, fetch pageFetcher[T], opts []PageOption) iter.Seq2[T, error] {
+	var cfg pageConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if startPage <= 0 {
+		startPage = 1
+	}
+
+	return func(yield func(T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var zero T
+		var pending chan pageResult[T]
+		defer func() {
+			// 提前结束时等待预取协程退出
+			if pending != nil {
+				cancel()
+				<-pending
+			}
+		}()
+
+		cur := startPage
+		page, err := fetch(ctx, cur)
+		count := 0
+		for {
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if len(page.Data) == 0 {
+				return
+			}
+
+			hasNext := page.NextPageUrl != nil && *page.NextPageUrl != ""
+			nextPage := page.CurrentPage + 1
+			if page.CurrentPage <= 0 {
+				nextPage = cur + 1
+			}
+			if hasNext && cfg.prefetch && (cfg.maxItems <= 0 || count+len(page.Data) < cfg.maxItems) {
+				pending = make(chan pageResult[T], 1)
+				go func(ch chan<- pageResult[T], n int) {
+					p, err := fetch(ctx, n)
+					ch <- pageResult[T]{page: p, err: err}
+				}(pending, nextPage)
+			}
+
+			for _, item := range page.Data {
+				if cfg.maxItems > 0 && count >= cfg.maxItems {
+					return
+				}
+				count++
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if !hasNext || (cfg.maxItems > 0 && count >= cfg.maxItems) {
+				return
+			}
+
+			if pending != nil {
+				res := <-pending
+				pending = nil
+				page, err = res.page, res.err
+			} else {
+				page, err = fetch(ctx, nextPage)
+			}
+			cur = nextPage
+		}
+	}
+}
+
+// AllProjects 遍历全部项目，按需逐页请求（params.Page 为起始页）
+func (c *Client) AllProjects(ctx context.Context, params GetProjectListRequest, opts ...PageOption) iter.Seq2[Project, error] {
+	return paginate(ctx, params.Page, func(ctx context.Context, page int) (*ResponsePaginate[Project], error) {
+		p := params
+		p.Page = page
+		return c.WithContext(ctx).GetProjectList(p)
+	}, opts)
+}
+
+// AllTasks 遍历全部任务，按需逐页请求（params.Page 为起始页）
+func (c *Client) AllTasks(ctx context.Context, params GetTaskListRequest, opts ...PageOption) iter.Seq2[ProjectTask, error] {
+	return paginate(ctx, params.Page, func(ctx context.Context, page int) (*ResponsePaginate[ProjectTask], error) {
+		p := params
+		p.Page = page
+		return c.WithContext(ctx).GetTaskList(p)
+	}, opts)
+}
+
+// AllDialogs 遍历全部对话，按需逐页请求（params.Page 为起始页）
+func (c *Client) AllDialogs(ctx context.Context, params TimeRangeRequest, opts ...PageOption) iter.Seq2[DialogInfo, error] {
+	return paginate(ctx, params.Page, func(ctx context.Context, page int) (*ResponsePaginate[DialogInfo], error) {
+		p := params
+		p.Page = page
+		return c.WithContext(ctx).GetDialogList(p)
+	}, opts)
+}
+
+// AllColumns 遍历项目的全部任务列表，按需逐页请求（params.Page 为起始页）
+func (c *Client) AllColumns(ctx context.Context, params GetColumnListRequest, opts ...PageOption) iter.Seq2[ProjectColumn, error] {
+	return paginate(ctx, params.Page, func(ctx context.Context, page int) (*ResponsePaginate[ProjectColumn], error) {
+		p := params
+		p.Page = page
+		return c.WithContext(ctx).GetColumnList(p)
+	}, opts)
+}
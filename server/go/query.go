@@ -0,0 +1,261 @@
+package dootask
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ------------------------------------------------------------------------------------------
+// 查询参数编码
+// ------------------------------------------------------------------------------------------
+
+// encodeQuery 把请求参数编码为 PHP 风格的查询字符串，输出顺序稳定：
+//   - map 按键排序，结构体按字段声明顺序（遵循 json 标签，支持 "-"、omitempty 与匿名嵌入）
+//   - 嵌套的 map/结构体编码为 key[sub]=v，标量数组编码为 key[]=v，对象数组编码为 key[0][sub]=v
+//   - nil 与空字符串始终跳过；带 omitempty 的零值字段跳过；bool 编码为 1/0
+//   - 未设置的 Optional 跳过，已设置的空字符串或空数组编码为 key=（清空字段）
+//   - 实现 json.Marshaler 或 encoding.TextMarshaler 的值按其编码结果处理（含 Optional 字段的
+//     更新请求结构体除外，仍按字段编码）
+func encodeQuery(params any) (string, error) {
+	var pairs []string
+	if err := appendQuery(&pairs, "", reflect.ValueOf(params)); err != nil {
+		return "", err
+	}
+	return strings.Join(pairs, "&"), nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// hasOptionalField 结构体是否直接包含 Optional 字段
+func hasOptionalField(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Implements(optionalType) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendQuery 把 v 以 key 为前缀追加到 pairs；key 为空表示顶层，只接受 map 或结构体
+func appendQuery(pairs *[]string, key string, v reflect.Value) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+			break
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch {
+	case v.Type().Implements(optionalType):
+		// 未设置的 Optional 跳过；已设置的空值编码为 key= 以清空字段
+		value, set := v.Interface().(optional).optionalValue()
+		if !set {
+			return nil
+		}
+		inner := reflect.ValueOf(value)
+		if key != "" && (!inner.IsValid() || isBlank(inner)) {
+			*pairs = append(*pairs, key+"=")
+			return nil
+		}
+		return appendQuery(pairs, key, inner)
+	case v.Kind() == reflect.Struct && hasOptionalField(v.Type()):
+		// 含 Optional 字段的结构体按字段编码，不走下方 MarshalJSON 分支：marshalPatch 虽保留
+		// 已设置的空值，但经 JSON 往返后 appendScalar 会跳过 ""，key= 随之丢失
+	case v.Type().Implements(jsonMarshalerType):
+		b, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", displayKey(key), err)
+		}
+		var decoded any
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			return fmt.Errorf("encode %s: %w", displayKey(key), err)
+		}
+		return appendQuery(pairs, key, reflect.ValueOf(decoded))
+	case v.Type().Implements(textMarshalerType):
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", displayKey(key), err)
+		}
+		return appendQuery(pairs, key, reflect.ValueOf(string(b)))
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("encode %s: unsupported map key type %s", displayKey(key), v.Type().Key())
+		}
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+		for _, k := range keys {
+			if err := appendQuery(pairs, childKey(key, k.String()), v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return appendStruct(pairs, key, v)
+	}
+
+	if key == "" {
+		return fmt.Errorf("encode query: unsupported params type %s", v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return appendScalar(pairs, key, string(v.Bytes()))
+		}
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			if isComposite(item) {
+				if err := appendQuery(pairs, key+"["+strconv.Itoa(i)+"]", item); err != nil {
+					return err
+				}
+			} else if err := appendQuery(pairs, key+"[]", item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String:
+		return appendScalar(pairs, key, v.String())
+	case reflect.Bool:
+		if v.Bool() {
+			return appendScalar(pairs, key, "1")
+		}
+		return appendScalar(pairs, key, "0")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendScalar(pairs, key, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendScalar(pairs, key, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		return appendScalar(pairs, key, strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()))
+	default:
+		return appendScalar(pairs, key, fmt.Sprintf("%v", v.Interface()))
+	}
+}
+
+// appendStruct 按 json 标签编码结构体字段
+func appendStruct(pairs *[]string, key string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+
+		// 未命名的匿名嵌入结构体展开到当前层级
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if fv.Kind() == reflect.Pointer {
+					if fv.IsNil() {
+						continue
+					}
+					fv = fv.Elem()
+				}
+				if err := appendStruct(pairs, key, fv); err != nil {
+					return err
+				}
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if slices.Contains(strings.Split(opts, ","), "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		if err := appendQuery(pairs, childKey(key, name), fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendScalar(pairs *[]string, key, value string) error {
+	if value != "" {
+		*pairs = append(*pairs, key+"="+url.QueryEscape(value))
+	}
+	return nil
+}
+
+// childKey 返回嵌套键：顶层为 name，否则为 key[name]
+func childKey(key, name string) string {
+	if key == "" {
+		return url.QueryEscape(name)
+	}
+	return key + "[" + url.QueryEscape(name) + "]"
+}
+
+func displayKey(key string) string {
+	if key == "" {
+		return "query"
+	}
+	return key
+}
+
+// isComposite 数组元素是否为对象（map 或结构体，编码时需要带下标）
+func isComposite(v reflect.Value) bool {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return false
+	}
+	return v.Kind() == reflect.Map || v.Kind() == reflect.Struct
+}
+
+// isBlank 是否为空字符串、空数组或空 map
+func isBlank(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len() == 0
+	}
+	return false
+}
+
+// isEmptyValue 与 encoding/json 的 omitempty 判定一致
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,517 @@
+package dootask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------------------------
+// 实时事件
+// ------------------------------------------------------------------------------------------
+
+// 实时事件类型
+const (
+	RealtimeConnected        = "connected"         // 连接（或重连）成功
+	RealtimeDisconnected     = "disconnected"      // 连接断开，随后自动重连
+	RealtimeMessage          = "message"           // 收到新消息或消息被修改
+	RealtimeMessageWithdrawn = "message_withdrawn" // 消息被撤回
+	RealtimeDialogUpdated    = "dialog_updated"    // 对话更新（已读、群组成员或信息变更等）
+	RealtimeTaskChanged      = "task_changed"      // 任务变更
+	RealtimeUserOnline       = "user_online"       // 用户上线或下线
+)
+
+// RealtimeEvent 实时事件，按类型为具体结构体，未识别的推送为 *RawEvent
+type RealtimeEvent interface {
+	EventType() string // 事件类型
+}
+
+// ConnectedEvent 连接成功
+type ConnectedEvent struct {
+	FD        int  // 连接标识（页面操作的 --session 即此值）
+	Reconnect bool // 是否为断线重连；重连后应重新拉取期间错过的数据
+}
+
+// DisconnectedEvent 连接断开
+type DisconnectedEvent struct {
+	Err error // 断开原因
+}
+
+// MessageEvent 收到新消息（mode 为 add、chat）或消息被修改（mode 为 update）
+type MessageEvent struct {
+	Mode    string        // 推送模式
+	Message DialogMessage // 消息
+}
+
+// MessageWithdrawnEvent 消息被撤回
+type MessageWithdrawnEvent struct {
+	DialogID int // 对话ID
+	MsgID    int // 消息ID
+}
+
+// DialogUpdatedEvent 对话更新
+type DialogUpdatedEvent struct {
+	Mode     string          // 推送模式，如 readed、groupUpdate、groupJoin、groupExit
+	DialogID int             // 对话ID
+	Data     json.RawMessage // 原始数据
+}
+
+// TaskChangedEvent 任务变更
+type TaskChangedEvent struct {
+	Action string      // 变更类型，如 add、update、archived、delete、restore
+	Task   ProjectTask // 任务（删除时可能只有 ID）
+}
+
+// UserOnlineEvent 用户上线或下线
+type UserOnlineEvent struct {
+	UserID int  // 用户ID
+	Online bool // 是否在线
+}
+
+// RawEvent 未识别的推送，保留原始 JSON
+type RawEvent struct {
+	Type string          // 推送类型
+	Mode string          // 推送模式（mode 或 action）
+	Raw  json.RawMessage // 原始推送
+}
+
+// EventType 实现 RealtimeEvent 接口
+func (*ConnectedEvent) EventType() string        { return RealtimeConnected }
+func (*DisconnectedEvent) EventType() string     { return RealtimeDisconnected }
+func (*MessageEvent) EventType() string          { return RealtimeMessage }
+func (*MessageWithdrawnEvent) EventType() string { return RealtimeMessageWithdrawn }
+func (*DialogUpdatedEvent) EventType() string    { return RealtimeDialogUpdated }
+func (*TaskChangedEvent) EventType() string      { return RealtimeTaskChanged }
+func (*UserOnlineEvent) EventType() string       { return RealtimeUserOnline }
+func (e *RawEvent) EventType() string            { return e.Type }
+
+// wsPacket 主程序 WebSocket 推送的数据包
+type wsPacket struct {
+	Type   string          `json:"type"`
+	Mode   string          `json:"mode"`
+	Action string          `json:"action"`
+	MsgID  string          `json:"msgId"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// decodeRealtimeEvent 把推送解析为事件，心跳、回执等内部数据包返回 nil
+func decodeRealtimeEvent(raw []byte) (RealtimeEvent, *wsPacket, error) {
+	var p wsPacket
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, nil, err
+	}
+	ids := func() (dialogID, id int) {
+		var v struct {
+			ID       FlexInt `json:"id"`
+			DialogID FlexInt `json:"dialog_id"`
+		}
+		json.Unmarshal(p.Data, &v)
+		return int(v.DialogID), int(v.ID)
+	}
+
+	switch p.Type {
+	case "open", "heartbeat", "receipt":
+		return nil, &p, nil
+	case "dialog":
+		switch p.Mode {
+		case "add", "chat", "update":
+			e := &MessageEvent{Mode: p.Mode}
+			if err := json.Unmarshal(p.Data, &e.Message); err != nil {
+				return nil, &p, err
+			}
+			return e, &p, nil
+		case "delete":
+			dialogID, id := ids()
+			return &MessageWithdrawnEvent{DialogID: dialogID, MsgID: id}, &p, nil
+		case "":
+		default:
+			dialogID, id := ids()
+			if dialogID == 0 {
+				dialogID = id
+			}
+			return &DialogUpdatedEvent{Mode: p.Mode, DialogID: dialogID, Data: p.Data}, &p, nil
+		}
+	case "projectTask":
+		e := &TaskChangedEvent{Action: p.Action}
+		if err := json.Unmarshal(p.Data, &e.Task); err != nil {
+			return nil, &p, err
+		}
+		return e, &p, nil
+	case "line":
+		var v struct {
+			UserID FlexInt `json:"userid"`
+			Online bool    `json:"online"`
+		}
+		if err := json.Unmarshal(p.Data, &v); err != nil {
+			return nil, &p, err
+		}
+		return &UserOnlineEvent{UserID: int(v.UserID), Online: v.Online}, &p, nil
+	}
+
+	mode := p.Mode
+	if mode == "" {
+		mode = p.Action
+	}
+	return &RawEvent{Type: p.Type, Mode: mode, Raw: raw}, &p, nil
+}
+
+// ------------------------------------------------------------------------------------------
+// 实时连接
+// ------------------------------------------------------------------------------------------
+
+// RealtimeOption 实时连接选项
+type RealtimeOption func(*Realtime)
+
+// WithRealtimeHeartbeat 设置心跳间隔，默认 30 秒；超过 3 个间隔未收到任何数据视为断线
+func WithRealtimeHeartbeat(interval time.Duration) RealtimeOption {
+	return func(rt *Realtime) {
+		rt.heartbeat = interval
+	}
+}
+
+// WithRealtimeReconnect 设置断线重连的退避区间，默认 1 秒起、最长 30 秒
+func WithRealtimeReconnect(minDelay, maxDelay time.Duration) RealtimeOption {
+	return func(rt *Realtime) {
+		rt.minDelay, rt.maxDelay = minDelay, maxDelay
+	}
+}
+
+// WithRealtimeHandler 以回调接收事件（在读取 goroutine 中依次调用），此时 Events 通道不再投递
+func WithRealtimeHandler(fn func(RealtimeEvent)) RealtimeOption {
+	return func(rt *Realtime) {
+		rt.handler = fn
+	}
+}
+
+// WithRealtimeBuffer 设置 Events 通道的缓冲大小，默认 64
+func WithRealtimeBuffer(size int) RealtimeOption {
+	return func(rt *Realtime) {
+		rt.events = make(chan RealtimeEvent, size)
+	}
+}
+
+// Realtime 主程序 /ws 实时事件连接，断线后自动重连
+type Realtime struct {
+	client    *Client
+	heartbeat time.Duration
+	minDelay  time.Duration
+	maxDelay  time.Duration
+	handler   func(RealtimeEvent)
+	events    chan RealtimeEvent
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu   sync.Mutex
+	ws   *wsConn
+	fd   int
+	err  error
+	seen bool
+}
+
+// Connect 以客户端 token 连接主程序 /ws 并开始接收事件。首次连接失败时直接返回错误
+// （token 无效为 ErrUnauthorized）；之后断线会按退避自动重连，直到 ctx 结束或调用 Close
+func (c *Client) Connect(ctx context.Context, opts ...RealtimeOption) (*Realtime, error) {
+	rt := &Realtime{
+		client:    c,
+		heartbeat: 30 * time.Second,
+		minDelay:  time.Second,
+		maxDelay:  30 * time.Second,
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(rt)
+	}
+	if rt.events == nil {
+		rt.events = make(chan RealtimeEvent, 64)
+	}
+	rt.ctx, rt.cancel = context.WithCancel(ctx)
+
+	ws, fd, err := rt.dial()
+	if err != nil {
+		rt.cancel()
+		return nil, err
+	}
+	go rt.run(ws, fd)
+	return rt, nil
+}
+
+// Events 返回事件通道，连接结束后关闭
+func (rt *Realtime) Events() <-chan RealtimeEvent {
+	return rt.events
+}
+
+// Done 返回连接结束时关闭的通道
+func (rt *Realtime) Done() <-chan struct{} {
+	return rt.done
+}
+
+// Err 返回连接结束的原因（ctx 结束、Close 或重连时 token 失效）
+func (rt *Realtime) Err() error {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.err
+}
+
+// FD 返回当前连接标识，未连接时为 0
+func (rt *Realtime) FD() int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.fd
+}
+
+// Send 向主程序发送数据包（JSON），未连接时返回错误
+func (rt *Realtime) Send(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	rt.mu.Lock()
+	ws := rt.ws
+	rt.mu.Unlock()
+	if ws == nil {
+		return errors.New("realtime: not connected")
+	}
+	return ws.writeFrame(wsOpText, data)
+}
+
+// Close 关闭连接并停止重连，等待事件投递结束
+func (rt *Realtime) Close() error {
+	rt.cancel()
+	<-rt.done
+	return nil
+}
+
+// wsURL 构造 /ws 地址
+func (rt *Realtime) wsURL(token string) string {
+	server := rt.client.server
+	switch {
+	case strings.HasPrefix(server, "https://"):
+		server = "wss://" + strings.TrimPrefix(server, "https://")
+	case strings.HasPrefix(server, "http://"):
+		server = "ws://" + strings.TrimPrefix(server, "http://")
+	}
+	q := url.Values{"action": {"web"}, "token": {token}}
+	return strings.TrimRight(server, "/") + "/ws?" + q.Encode()
+}
+
+// dial 建立连接；设置了 TokenSource 时，token 失效后刷新并重连一次
+func (rt *Realtime) dial() (*wsConn, int, error) {
+	var ws *wsConn
+	var fd int
+	_, err := rt.client.authorized(rt.ctx, true, func(token string) (err error) {
+		ws, fd, err = rt.dialToken(token)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return ws, fd, nil
+}
+
+// dialToken 以 token 建立连接并等待主程序的 open 数据包
+func (rt *Realtime) dialToken(token string) (*wsConn, int, error) {
+	header := http.Header{}
+	header.Set("Token", token)
+	header.Set("User-Agent", "DooTask-Go-Client/1.0")
+	if rt.client.version != "" {
+		header.Set("Version", rt.client.version)
+	}
+	ws, err := dialWebSocket(rt.ctx, rt.wsURL(token), header, rt.client.timeout)
+	if err != nil {
+		if ctxErr := rt.ctx.Err(); ctxErr != nil {
+			return nil, 0, ctxErr
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return nil, 0, err
+		}
+		return nil, 0, &TransportError{Method: http.MethodGet, Endpoint: "/ws", Err: err}
+	}
+
+	// 首个数据包为 {"type":"open","data":{"fd":...}}
+	if rt.client.timeout > 0 {
+		ws.conn.SetReadDeadline(time.Now().Add(rt.client.timeout))
+	}
+	_, raw, err := ws.readMessage()
+	if err != nil {
+		ws.conn.Close()
+		return nil, 0, &TransportError{Method: http.MethodGet, Endpoint: "/ws", Err: err}
+	}
+	var open struct {
+		Type string `json:"type"`
+		Data struct {
+			FD FlexInt `json:"fd"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &open); err != nil || open.Type != "open" {
+		ws.conn.Close()
+		return nil, 0, &TransportError{Method: http.MethodGet, Endpoint: "/ws", Err: errors.New("realtime: missing open packet")}
+	}
+	return ws, int(open.Data.FD), nil
+}
+
+// run 读取事件，断线后按退避重连
+func (rt *Realtime) run(ws *wsConn, fd int) {
+	defer close(rt.done)
+	defer close(rt.events)
+
+	for attempt := 0; ; {
+		if ws != nil {
+			attempt = 0
+			rt.setConn(ws, fd)
+			err := rt.serve(ws, fd)
+			rt.setConn(nil, 0)
+			ws.close()
+			if rt.ctx.Err() != nil {
+				rt.finish(rt.ctx.Err())
+				return
+			}
+			rt.emit(&DisconnectedEvent{Err: err})
+		}
+
+		attempt++
+		if !rt.sleep(rt.reconnectDelay(attempt)) {
+			rt.finish(rt.ctx.Err())
+			return
+		}
+		var err error
+		ws, fd, err = rt.dial()
+		if errors.Is(err, ErrUnauthorized) {
+			rt.finish(err)
+			return
+		}
+		if err != nil {
+			ws = nil
+		}
+	}
+}
+
+// serve 在单个连接上收发数据，直到出错或 ctx 结束
+func (rt *Realtime) serve(ws *wsConn, fd int) error {
+	stop := context.AfterFunc(rt.ctx, func() { ws.conn.Close() })
+	defer stop()
+
+	rt.emit(&ConnectedEvent{FD: fd, Reconnect: rt.markSeen()})
+
+	// 心跳：定期发送 heartbeat 数据包，读超时即视为断线
+	hbDone := make(chan struct{})
+	defer close(hbDone)
+	if rt.heartbeat > 0 {
+		go func() {
+			ticker := time.NewTicker(rt.heartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-hbDone:
+					return
+				case <-ticker.C:
+					if ws.writeFrame(wsOpText, []byte(`{"type":"heartbeat"}`)) != nil {
+						ws.conn.Close()
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for {
+		if rt.heartbeat > 0 {
+			ws.conn.SetReadDeadline(time.Now().Add(3 * rt.heartbeat))
+		} else {
+			ws.conn.SetReadDeadline(time.Time{})
+		}
+		op, raw, err := ws.readMessage()
+		if err != nil {
+			return err
+		}
+		if op != wsOpText {
+			continue
+		}
+		e, p, err := decodeRealtimeEvent(raw)
+		if err != nil || p == nil {
+			continue
+		}
+		// 带 msgId 的推送需要回执
+		if p.MsgID != "" && p.Type != "receipt" {
+			receipt, _ := json.Marshal(map[string]string{"type": "receipt", "msgId": p.MsgID})
+			ws.writeFrame(wsOpText, receipt)
+		}
+		if e != nil {
+			rt.emit(e)
+		}
+	}
+}
+
+// emit 投递事件
+func (rt *Realtime) emit(e RealtimeEvent) {
+	if rt.handler != nil {
+		rt.handler(e)
+		return
+	}
+	select {
+	case rt.events <- e:
+	case <-rt.ctx.Done():
+	}
+}
+
+func (rt *Realtime) setConn(ws *wsConn, fd int) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.ws = ws
+	if ws != nil {
+		rt.fd = fd
+	} else {
+		rt.fd = 0
+	}
+}
+
+// markSeen 记录已连接过，返回此前是否连接过
+func (rt *Realtime) markSeen() bool {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	seen := rt.seen
+	rt.seen = true
+	return seen
+}
+
+func (rt *Realtime) finish(err error) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.err = err
+	rt.cancel()
+}
+
+// reconnectDelay 计算第 attempt 次重连前的等待时间：指数退避加抖动
+func (rt *Realtime) reconnectDelay(attempt int) time.Duration {
+	d := rt.minDelay
+	for i := 1; i < attempt && d < rt.maxDelay; i++ {
+		d *= 2
+	}
+	if rt.maxDelay > 0 {
+		d = min(d, rt.maxDelay)
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// sleep 等待 d，ctx 结束时返回 false
+func (rt *Realtime) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-rt.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
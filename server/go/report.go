@@ -0,0 +1,85 @@
+package dootask
+
+// ------------------------------------------------------------------------------------------
+// 报告相关接口
+// ------------------------------------------------------------------------------------------
+
+// ListReceivedReports 获取我收到的报告
+func (c *Client) ListReceivedReports(params ReportListRequest) (*ResponsePaginate[Report], error) {
+	var response ResponsePaginate[Report]
+	err := c.NewGetRequest("/api/report/receive", params, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListMyReports 获取我发出的报告
+func (c *Client) ListMyReports(params ReportListRequest) (*ResponsePaginate[Report], error) {
+	var response ResponsePaginate[Report]
+	err := c.NewGetRequest("/api/report/my", params, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetReport 获取报告详情
+func (c *Client) GetReport(id int) (*Report, error) {
+	var response Report
+	err := c.NewGetRequest("/api/report/detail", map[string]any{"id": id}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetReportTemplate 生成报告模板（按周期汇总已完成与计划中的任务）
+func (c *Client) GetReportTemplate(params ReportTemplateRequest) (*ReportTemplate, error) {
+	var response ReportTemplate
+	err := c.NewGetRequest("/api/report/template", params, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SubmitReport 提交报告（同一周期签名重复提交时覆盖）
+func (c *Client) SubmitReport(params SubmitReportRequest) (*Report, error) {
+	var response Report
+	err := c.NewPostRequest("/api/report/store", params, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// MarkReports 标记报告已读或未读，action 为 read 或 unread
+func (c *Client) MarkReports(ids []int, action string) error {
+	return c.NewGetRequest("/api/report/mark", map[string]any{
+		"id":     ids,
+		"action": action,
+	}, nil)
+}
+
+// GetUnreadReportCount 获取我收到的未读报告数量
+func (c *Client) GetUnreadReportCount() (int, error) {
+	var response struct {
+		Total int `json:"total"`
+	}
+	err := c.NewGetRequest("/api/report/unread", nil, &response)
+	if err != nil {
+		return 0, err
+	}
+	return response.Total, nil
+}
+
+// SaveReportAnalysis 保存报告的 AI 分析结果
+func (c *Client) SaveReportAnalysis(params SaveReportAnalysisRequest) error {
+	return c.NewPostRequest("/api/report/analysave", params, nil)
+}
+
+// ShareReports 把报告以分享链接发送到对话或成员
+func (c *Client) ShareReports(params ShareReportsRequest) error {
+	return c.NewGetRequest("/api/report/share", params, nil)
+}
@@ -0,0 +1,211 @@
+package dootask
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------------------------------------
+// 请求重试
+// ------------------------------------------------------------------------------------------
+
+// maxRetryBackoff 指数退避的上限（Retry-After 不受此限制）
+const maxRetryBackoff = 30 * time.Second
+
+// endpointIdempotency 接口幂等性分类。SDK 中许多写操作走 GET（如 CreateProject、
+// AddGroupUser、DeleteColumn），不能按请求方法一概重试；以 / 结尾的键按路径前缀匹配
+// （用于路径带参数的接口）。未列出的接口按方法判断：GET/HEAD/PUT/DELETE 视为幂等，POST/PATCH 视为非幂等
+var endpointIdempotency = map[string]bool{
+	// 以 GET 发送的写操作
+	"/api/users/login":           false,
+	"/api/users/bot/delete":      false,
+	"/api/dialog/msg/withdraw":   false,
+	"/api/dialog/msg/forward":    false,
+	"/api/dialog/msg/todo":       false,
+	"/api/dialog/msg/done":       false,
+	"/api/dialog/open/user":      false,
+	"/api/dialog/group/add":      false,
+	"/api/dialog/group/edit":     false,
+	"/api/dialog/group/adduser":  false,
+	"/api/dialog/group/deluser":  false,
+	"/api/dialog/group/transfer": false,
+	"/api/dialog/group/disband":  false,
+	"/api/project/add":           false,
+	"/api/project/update":        false,
+	"/api/project/exit":          false,
+	"/api/project/remove":        false,
+	"/api/project/column/add":    false,
+	"/api/project/column/update": false,
+	"/api/project/column/remove": false,
+	"/api/project/flow/delete":   false,
+	"/api/project/tag/save":      false,
+	"/api/project/tag/delete":    false,
+	"/api/project/task/addsub":   false,
+	"/api/project/task/archived": false,
+	"/api/project/task/remove":   false,
+	"/api/project/task/move":     false,
+	"/api/project/task/dialog":   false,
+	"/api/report/mark":           false,
+	"/api/report/share":          false,
+	"/api/file/add":              false,
+	"/api/file/move":             false,
+	"/api/file/remove":           false,
+	"/api/file/link":             false,
+	"/api/file/share/update":     false,
+	"/api/file/share/out":        false,
+
+	// AppStore 中以 GET 发送的写操作（路径带应用ID）
+	"/appstore/api/v1/internal/uninstall/":  false,
+	"/appstore/api/v1/internal/remove/":     false,
+	"/appstore/api/v1/internal/apps/update": false,
+
+	// 无副作用的 POST
+	"/api/dialog/msg/webhookmsg2ai": true,
+}
+
+// WithRetry 开启失败重试：最多发送 maxAttempts 次（含首次），两次之间按 backoff 指数退避并加抖动，
+// 响应带 Retry-After 时以其为准。仅重试幂等请求的传输层错误与 HTTP 429/502/503/504，业务错误不重试
+func WithRetry(maxAttempts int, backoff time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retryMax = maxAttempts
+		c.retryBackoff = backoff
+	}
+}
+
+// WithIdempotentEndpoints 把指定接口标记为幂等，允许重试（如可安全重放的 POST 接口）；
+// 以 / 结尾的接口按路径前缀匹配
+func WithIdempotentEndpoints(apis ...string) ClientOption {
+	return func(c *Client) {
+		c.setIdempotency(apis, true)
+	}
+}
+
+// WithNonIdempotentEndpoints 把指定接口标记为非幂等，禁止重试（如以 GET 发送的写操作）
+func WithNonIdempotentEndpoints(apis ...string) ClientOption {
+	return func(c *Client) {
+		c.setIdempotency(apis, false)
+	}
+}
+
+func (c *Client) setIdempotency(apis []string, idempotent bool) {
+	if c.idempotency == nil {
+		c.idempotency = make(map[string]bool)
+	}
+	for _, api := range apis {
+		c.idempotency[api] = idempotent
+	}
+}
+
+// isIdempotent 判断请求是否可安全重试
+func (c *Client) isIdempotent(method, api string) bool {
+	if v, ok := lookupIdempotency(c.idempotency, api); ok {
+		return v
+	}
+	if v, ok := lookupIdempotency(endpointIdempotency, api); ok {
+		return v
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// lookupIdempotency 查找接口的幂等性分类：优先精确匹配，其次取最长的 / 结尾前缀
+func lookupIdempotency(m map[string]bool, api string) (idempotent, ok bool) {
+	if v, found := m[api]; found {
+		return v, true
+	}
+	matched := ""
+	for prefix, v := range m {
+		if strings.HasSuffix(prefix, "/") && strings.HasPrefix(api, prefix) && len(prefix) > len(matched) {
+			matched, idempotent, ok = prefix, v, true
+		}
+	}
+	return idempotent, ok
+}
+
+// retryableStatus 判断 HTTP 状态码是否为可重试的临时错误
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// do 发送请求，按重试策略重放幂等请求
+func (c *Client) do(ctx context.Context, req *http.Request, api string) (*http.Response, error) {
+	if c.retryMax <= 1 || !c.isIdempotent(req.Method, api) {
+		return c.roundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		// 中间件可能改写请求，每次重放都基于原始请求的副本
+		attemptReq := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+
+		resp, err := c.roundTrip(attemptReq)
+		if attempt >= c.retryMax || ctx.Err() != nil {
+			return resp, err
+		}
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		delay := c.retryDelay(attempt)
+		if err == nil {
+			if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = d
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay 计算第 attempt 次失败后的等待时间：指数退避，取一半固定加一半随机抖动
+func (c *Client) retryDelay(attempt int) time.Duration {
+	d := c.retryBackoff
+	for i := 1; i < attempt && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, maxRetryBackoff)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// parseRetryAfter 解析 Retry-After 头，支持秒数与 HTTP 日期两种格式
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,223 @@
+package dootask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// ------------------------------------------------------------------------------------------
+// 统一搜索
+// ------------------------------------------------------------------------------------------
+
+const (
+	defaultSearchTake        = 20 // 每类默认返回数量
+	maxSearchTake            = 50 // 每类最大返回数量
+	defaultSearchConcurrency = 3  // 默认最大并发请求数
+)
+
+// Search 在任务、项目、文件、联系人与消息中搜索 key，types 为空时搜索全部类型，
+// 各类型并发请求（最多 3 个同时进行），结果按相关度合并排序到 SearchResult.Hits
+func (c *Client) Search(ctx context.Context, key string, types ...SearchType) (*SearchResult, error) {
+	return c.SearchWithOptions(ctx, SearchRequest{Key: key, Types: types})
+}
+
+// SearchWithOptions 按请求参数执行统一搜索。单个类型失败时错误记录在 SearchResult.Errors，
+// 其余类型照常返回；全部类型失败或 ctx 结束时返回错误
+func (c *Client) SearchWithOptions(ctx context.Context, params SearchRequest) (*SearchResult, error) {
+	if params.Key == "" {
+		return nil, errors.New("search: key is required")
+	}
+	var types []SearchType
+	for _, t := range params.Types {
+		if !slices.Contains(SearchTypes, t) {
+			return nil, fmt.Errorf("search: unknown type %q", t)
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		types = SearchTypes
+	}
+	take := params.Take
+	if take <= 0 {
+		take = defaultSearchTake
+	}
+	take = min(take, maxSearchTake)
+	page := max(params.Page, 1)
+	concurrency := params.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultSearchConcurrency
+	}
+
+	result := &SearchResult{
+		Key:     params.Key,
+		Page:    page,
+		HasMore: make(map[SearchType]bool),
+		Errors:  make(map[SearchType]error),
+	}
+	query := map[string]any{"key": params.Key, "take": take, "page": page}
+	client := c.WithContext(ctx)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, t := range types {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mu.Lock()
+				result.Errors[t] = ctx.Err()
+				mu.Unlock()
+				return
+			}
+
+			n, err := client.searchType(t, query, result, &mu)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Errors[t] = err
+				return
+			}
+			result.HasMore[t] = n >= take
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(result.Errors) == len(types) {
+		errs := make([]error, 0, len(types))
+		for _, t := range types {
+			errs = append(errs, fmt.Errorf("search %s: %w", t, result.Errors[t]))
+		}
+		return nil, errors.Join(errs...)
+	}
+	result.Hits = rankSearchHits(result)
+	return result, nil
+}
+
+// searchType 请求单个类型并写入 result，返回结果条数
+func (c *Client) searchType(t SearchType, query map[string]any, result *SearchResult, mu *sync.Mutex) (int, error) {
+	api := "/api/search/" + string(t)
+	switch t {
+	case SearchTypeTask:
+		return searchInto(c, api, query, mu, &result.Tasks)
+	case SearchTypeProject:
+		return searchInto(c, api, query, mu, &result.Projects)
+	case SearchTypeFile:
+		return searchInto(c, api, query, mu, &result.Files)
+	case SearchTypeContact:
+		return searchInto(c, api, query, mu, &result.Contacts)
+	default:
+		return searchInto(c, api, query, mu, &result.Messages)
+	}
+}
+
+// searchInto 请求搜索接口，成功时在锁内写入 dst
+func searchInto[T any](c *Client, api string, query map[string]any, mu *sync.Mutex, dst *[]T) (int, error) {
+	var items []T
+	if err := c.NewGetRequest(api, query, &items); err != nil {
+		return 0, err
+	}
+	mu.Lock()
+	*dst = items
+	mu.Unlock()
+	return len(items), nil
+}
+
+// rankSearchHits 合并各类型结果并按分值降序排列：相关度按类型内最大值归一化到 0~1，
+// 类型内都没有相关度时按名次计分；同分时按 SearchTypes 顺序与类型内名次排列
+func rankSearchHits(result *SearchResult) []SearchHit {
+	var hits []SearchHit
+	add := func(group []SearchHit) {
+		best := 0.0
+		for _, h := range group {
+			best = max(best, h.Relevance)
+		}
+		for i := range group {
+			if best > 0 {
+				group[i].Score = group[i].Relevance / best
+			} else {
+				group[i].Score = 1 - float64(i)/float64(len(group))
+			}
+		}
+		hits = append(hits, group...)
+	}
+
+	group := make([]SearchHit, 0, len(result.Tasks))
+	for i := range result.Tasks {
+		h := &result.Tasks[i]
+		group = append(group, SearchHit{Type: SearchTypeTask, ID: h.ID, Title: h.Name, Relevance: float64(h.Relevance), Item: h})
+	}
+	add(group)
+
+	group = make([]SearchHit, 0, len(result.Projects))
+	for i := range result.Projects {
+		h := &result.Projects[i]
+		group = append(group, SearchHit{Type: SearchTypeProject, ID: h.ID, Title: h.Name, Relevance: float64(h.Relevance), Item: h})
+	}
+	add(group)
+
+	group = make([]SearchHit, 0, len(result.Files))
+	for i := range result.Files {
+		h := &result.Files[i]
+		title := h.Name
+		if h.Ext != "" {
+			title += "." + h.Ext
+		}
+		group = append(group, SearchHit{Type: SearchTypeFile, ID: h.ID, Title: title, Relevance: float64(h.Relevance), Item: h})
+	}
+	add(group)
+
+	group = make([]SearchHit, 0, len(result.Contacts))
+	for i := range result.Contacts {
+		h := &result.Contacts[i]
+		group = append(group, SearchHit{Type: SearchTypeContact, ID: int(h.UserID), Title: h.Nickname, Relevance: float64(h.Relevance), Item: h})
+	}
+	add(group)
+
+	group = make([]SearchHit, 0, len(result.Messages))
+	for i := range result.Messages {
+		h := &result.Messages[i]
+		id := h.MsgID
+		if id == 0 {
+			id = h.ID
+		}
+		group = append(group, SearchHit{Type: SearchTypeMessage, ID: id, Title: h.ContentPreview, Relevance: relevanceValue(h.Relevance), Item: h})
+	}
+	add(group)
+
+	// hits 已按类型顺序与类型内名次排列，稳定排序保留同分时的顺序
+	slices.SortStableFunc(hits, func(a, b SearchHit) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return hits
+}
+
+// relevanceValue 把消息搜索返回的相关度（数字或数字字符串）转为 float64
+func relevanceValue(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
@@ -0,0 +1,371 @@
+// Package stream 提供 SendStreamMessage 所需的流式消息地址：Server 以 SSE 输出增量内容，
+// Go 代码向流写入片段，完成后把全文写回消息。
+//
+// 前端通过 EventSource 订阅 stream_url，依次收到 append（追加片段）、replace（替换全文）
+// 与 done（结束）事件，数据均为 {"content":"..."}。
+//
+//	srv := stream.NewServer(stream.WithBaseURL("http://bot:8080/stream"))
+//	http.Handle("/stream/", srv)
+//	err := srv.Send(ctx, client, stream.Target{DialogID: 1, UserIDs: []int{2}}, tokens)
+package stream
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ErrClosed 流已结束
+var ErrClosed = errors.New("stream: closed")
+
+// Option Server 选项
+type Option func(*Server)
+
+// WithBaseURL 设置流地址前缀（主程序与前端可访问的地址），流地址为 baseURL/<id>
+func WithBaseURL(baseURL string) Option {
+	return func(s *Server) {
+		s.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithIdleTimeout 设置流的闲置超时，默认 5 分钟；超时未写入的流自动结束并写回已有内容
+func WithIdleTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.idleTimeout = d
+	}
+}
+
+// WithRetention 设置流结束后的保留时间，默认 1 分钟，期间新的订阅者仍能取到全文
+func WithRetention(d time.Duration) Option {
+	return func(s *Server) {
+		s.retention = d
+	}
+}
+
+// Server 流式消息的 SSE 服务，实现 http.Handler
+type Server struct {
+	baseURL     string
+	idleTimeout time.Duration
+	retention   time.Duration
+
+	mu      sync.Mutex
+	streams map[string]*Stream
+}
+
+// NewServer 创建流式消息服务
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		idleTimeout: 5 * time.Minute,
+		retention:   time.Minute,
+		streams:     make(map[string]*Stream),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Target 流式消息的发送目标
+type Target struct {
+	DialogID    int    // 必填：对话ID
+	UserIDs     []int  // 必填：需要监听流的成员（通常为提问者）
+	ReplyID     int    // 可选：回复的消息ID
+	Placeholder string // 可选：占位消息内容，默认 ...
+}
+
+// Open 创建一个不关联消息的流，需要自行调用 SendStreamMessage 通知成员
+func (s *Server) Open() *Stream {
+	return s.open(&Stream{})
+}
+
+// open 生成流ID、登记流并开始闲置计时
+func (s *Server) open(st *Stream) *Stream {
+	b := make([]byte, 16)
+	rand.Read(b)
+	st.ID = hex.EncodeToString(b)
+	st.server = s
+	st.changed = make(chan struct{})
+	st.touch()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams[st.ID] = st
+	return st
+}
+
+// Start 发送占位消息、创建流并通知成员监听；结束时（Close）把全文写回占位消息
+func (s *Server) Start(ctx context.Context, client *dootask.Client, t Target) (*Stream, error) {
+	if s.baseURL == "" {
+		return nil, errors.New("stream: base url is required, see WithBaseURL")
+	}
+	if t.Placeholder == "" {
+		t.Placeholder = "..."
+	}
+	client = client.WithContext(ctx)
+
+	var msg dootask.DialogMessage
+	err := client.SendMessage(dootask.SendMessageRequest{
+		DialogID: t.DialogID,
+		Text:     t.Placeholder,
+		ReplyID:  t.ReplyID,
+		Silence:  true,
+	}, &msg)
+	if err != nil {
+		return nil, fmt.Errorf("stream: send placeholder failed: %w", err)
+	}
+
+	st := s.open(&Stream{
+		client:   client.WithContext(context.WithoutCancel(ctx)),
+		dialogID: t.DialogID,
+		msgID:    msg.ID,
+	})
+	for _, uid := range t.UserIDs {
+		err := client.SendStreamMessage(dootask.SendStreamMessageRequest{UserID: uid, StreamURL: st.URL()})
+		if err != nil {
+			st.Close()
+			return nil, fmt.Errorf("stream: notify user %d failed: %w", uid, err)
+		}
+	}
+	return st, nil
+}
+
+// Send 以一次调用把 chunks 流式发送到对话：Start 后逐个写入片段，结束时写回全文。
+// chunks 返回错误时在已有内容后追加错误说明并结束
+func (s *Server) Send(ctx context.Context, client *dootask.Client, t Target, chunks iter.Seq2[string, error]) error {
+	st, err := s.Start(ctx, client, t)
+	if err != nil {
+		return err
+	}
+	for chunk, err := range chunks {
+		if err != nil {
+			st.Append("\n\n> " + err.Error())
+			return errors.Join(err, st.Close())
+		}
+		if ctx.Err() != nil {
+			return errors.Join(ctx.Err(), st.Close())
+		}
+		if err := st.Append(chunk); err != nil {
+			return err
+		}
+	}
+	return st.Close()
+}
+
+// Get 返回指定ID的流
+func (s *Server) Get(id string) (*Stream, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.streams[id]
+	return st, ok
+}
+
+// URL 返回流地址
+func (s *Server) URL(id string) string {
+	return s.baseURL + "/" + id
+}
+
+// ServeHTTP 以 SSE 输出流，路径最后一段为流ID
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	st, ok := s.Get(path.Base(r.URL.Path))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	h.Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var sent, version, seq int
+	for {
+		text, ver, done, changed := st.snapshot()
+		switch {
+		case ver != version || sent > len(text):
+			seq++
+			writeEvent(w, seq, "replace", text)
+			sent, version = len(text), ver
+		case sent < len(text):
+			seq++
+			writeEvent(w, seq, "append", text[sent:])
+			sent = len(text)
+		}
+		if done {
+			seq++
+			writeEvent(w, seq, "done", "")
+			flusher.Flush()
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent 写入一个 SSE 事件
+func writeEvent(w http.ResponseWriter, id int, event, content string) {
+	data, _ := json.Marshal(map[string]string{"content": content})
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, data)
+}
+
+func (s *Server) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.streams, id)
+}
+
+// Stream 一条流式消息，并发安全
+type Stream struct {
+	ID string // 流ID
+
+	server   *Server
+	client   *dootask.Client
+	dialogID int
+	msgID    int
+
+	mu      sync.Mutex
+	text    strings.Builder
+	version int           // Replace 次数，订阅者据此判断是否需要替换全文
+	done    bool          // 是否已结束
+	changed chan struct{} // 内容变化时关闭并替换
+	idle    *time.Timer
+}
+
+// URL 返回流地址
+func (st *Stream) URL() string {
+	return st.server.URL(st.ID)
+}
+
+// MessageID 返回占位消息ID（Start 创建时）
+func (st *Stream) MessageID() int {
+	return st.msgID
+}
+
+// Text 返回当前全文
+func (st *Stream) Text() string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.text.String()
+}
+
+// Append 追加片段
+func (st *Stream) Append(chunk string) error {
+	return st.update(func() { st.text.WriteString(chunk) })
+}
+
+// Replace 替换全文
+func (st *Stream) Replace(text string) error {
+	return st.update(func() {
+		st.text.Reset()
+		st.text.WriteString(text)
+		st.version++
+	})
+}
+
+// Write 实现 io.Writer，等同于 Append
+func (st *Stream) Write(p []byte) (int, error) {
+	if err := st.Append(string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close 结束流：通知订阅者 done，并把全文写回占位消息（Start 创建时）。重复调用返回 nil
+func (st *Stream) Close() error {
+	st.mu.Lock()
+	if st.done {
+		st.mu.Unlock()
+		return nil
+	}
+	st.done = true
+	if st.idle != nil {
+		st.idle.Stop()
+	}
+	text := st.text.String()
+	st.notify()
+	st.mu.Unlock()
+
+	time.AfterFunc(st.server.retention, func() { st.server.remove(st.ID) })
+
+	if st.client == nil || st.msgID == 0 {
+		return nil
+	}
+	if text == "" {
+		text = "..."
+	}
+	err := st.client.SendMessage(dootask.SendMessageRequest{
+		DialogID: st.dialogID,
+		Text:     text,
+		UpdateID: st.msgID,
+		Silence:  true,
+	})
+	if err != nil {
+		return fmt.Errorf("stream: finalize message failed: %w", err)
+	}
+	return nil
+}
+
+func (st *Stream) update(fn func()) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.done {
+		return ErrClosed
+	}
+	fn()
+	st.notify()
+	st.touch()
+	return nil
+}
+
+// notify 唤醒订阅者，调用时需持有 mu
+func (st *Stream) notify() {
+	close(st.changed)
+	st.changed = make(chan struct{})
+}
+
+// touch 重置闲置计时器
+func (st *Stream) touch() {
+	if st.server.idleTimeout <= 0 {
+		return
+	}
+	if st.idle == nil {
+		st.idle = time.AfterFunc(st.server.idleTimeout, func() { st.Close() })
+		return
+	}
+	st.idle.Reset(st.server.idleTimeout)
+}
+
+func (st *Stream) snapshot() (string, int, bool, <-chan struct{}) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.text.String(), st.version, st.done, st.changed
+}
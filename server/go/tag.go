@@ -0,0 +1,82 @@
+package dootask
+
+import "slices"
+
+// ------------------------------------------------------------------------------------------
+// 标签相关接口
+// ------------------------------------------------------------------------------------------
+
+// ListProjectTags 获取项目的任务标签
+func (c *Client) ListProjectTags(projectID int) ([]ProjectTag, error) {
+	var response []ProjectTag
+	err := c.NewGetRequest("/api/project/tag/list", map[string]any{"project_id": projectID}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// SaveProjectTag 创建或修改项目标签（ID 为空时创建）
+func (c *Client) SaveProjectTag(params SaveProjectTagRequest) (*ProjectTag, error) {
+	var response ProjectTag
+	err := c.NewGetRequest("/api/project/tag/save", params, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteProjectTag 删除项目标签（已打在任务上的标签不受影响）
+func (c *Client) DeleteProjectTag(id int) error {
+	return c.NewGetRequest("/api/project/tag/delete", map[string]any{"id": id}, nil)
+}
+
+// AddTaskTags 给任务添加标签，保留已有标签；同名标签只更新颜色（Color 为空时保留原颜色）
+func (c *Client) AddTaskTags(taskID int, tags ...TaskTagItem) (*ProjectTask, error) {
+	return c.editTaskTags(taskID, func(current []TaskTagItem) []TaskTagItem {
+		for _, tag := range tags {
+			i := slices.IndexFunc(current, func(t TaskTagItem) bool { return t.Name == tag.Name })
+			switch {
+			case i < 0:
+				current = append(current, tag)
+			case tag.Color != "":
+				current[i].Color = tag.Color
+			}
+		}
+		return current
+	})
+}
+
+// RemoveTaskTags 按名称移除任务标签，保留其余标签
+func (c *Client) RemoveTaskTags(taskID int, names ...string) (*ProjectTask, error) {
+	return c.editTaskTags(taskID, func(current []TaskTagItem) []TaskTagItem {
+		return slices.DeleteFunc(current, func(t TaskTagItem) bool { return slices.Contains(names, t.Name) })
+	})
+}
+
+// SetTaskTags 把任务标签整体替换为 tags，不传时清空
+func (c *Client) SetTaskTags(taskID int, tags ...TaskTagItem) (*ProjectTask, error) {
+	return c.editTaskTags(taskID, func([]TaskTagItem) []TaskTagItem {
+		return slices.Clone(tags)
+	})
+}
+
+// editTaskTags 读取任务当前标签，经 apply 修改后提交；标签未变化时不发送更新请求
+func (c *Client) editTaskTags(taskID int, apply func(current []TaskTagItem) []TaskTagItem) (*ProjectTask, error) {
+	task, err := c.GetTask(GetTaskRequest{TaskID: taskID})
+	if err != nil {
+		return nil, err
+	}
+	current := make([]TaskTagItem, 0, len(task.TaskTag))
+	for _, t := range task.TaskTag {
+		current = append(current, TaskTagItem{Name: t.Name, Color: t.Color})
+	}
+	tags := apply(slices.Clone(current))
+	if tags == nil {
+		tags = []TaskTagItem{}
+	}
+	if slices.Equal(tags, current) {
+		return task, nil
+	}
+	return c.UpdateTask(UpdateTaskRequest{TaskID: taskID, TaskTag: Set(tags)})
+}
@@ -0,0 +1,294 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/appstore"
+)
+
+// ============================================================================
+// AppStore 相关测试
+// ============================================================================
+
+// appStoreServer 模拟主程序版本接口与 AppStore 接口，记录收到的 AppStore 请求
+type appStoreServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+	versions int
+}
+
+func newAppStoreServer(t *testing.T) *appStoreServer {
+	s := &appStoreServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/system/version" {
+			s.mu.Lock()
+			s.versions++
+			s.mu.Unlock()
+			w.Write([]byte(`{"ret":1,"msg":"","data":{"version":"1.8.0"}}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, string(body))
+		s.mu.Unlock()
+
+		switch strings.TrimPrefix(r.URL.Path, appstore.BasePath) {
+		case "/list":
+			w.Write([]byte(`{"code":200,"data":[
+				{"id":"ai","name":"AI 助手","tags":["智能"],"upgradeable":true,"versions":["2.0.0","1.0.0"],"config":{"status":"installed","install_version":"1.0.0"}},
+				{"id":"crm","name":"客户管理","versions":["1.0.0"]}]}`))
+		case "/one/ai":
+			w.Write([]byte(`{"code":200,"data":{"id":"ai","name":"AI 助手",
+				"fields":[{"name":"TOKEN","required":true},{"name":"MODEL","required":true,"default":"bge-m3"}],
+				"config":{"status":"installed","install_version":"1.0.0","params":{"TOKEN":"t1","MODEL":"m1"},"resources":{"cpu_limit":1,"memory_limit":"2G"}}}}`))
+		case "/one/crm":
+			w.Write([]byte(`{"code":200,"data":{"id":"crm","config":{"status":"not_installed"}}}`))
+		case "/internal/installed":
+			w.Write([]byte(`{"code":200,"data":[{"id":"ai","version":"1.0.0","status":"installed","install_at":"2026-01-01 00:00:00"}]}`))
+		case "/internal/install", "/internal/remove/crm", "/internal/apps/update":
+			w.Write([]byte(`{"code":200,"message":"ok"}`))
+		case "/internal/uninstall/ai":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"code":503,"message":"docker 忙"}`))
+		case "/internal/log/ai":
+			w.Write([]byte(`{"code":200,"data":{"log":"n=` + r.URL.Query().Get("n") + `"}}`))
+		case "/internal/containers/ai/logs":
+			w.Write([]byte(`{"code":200,"data":"` + r.URL.Query().Get("service") + `"}`))
+		case "/internal/apps/upload":
+			r.Body = io.NopCloser(strings.NewReader(string(body)))
+			f, h, err := r.FormFile("file")
+			if err != nil {
+				t.Errorf("读取上传文件失败: %v", err)
+				return
+			}
+			content, _ := io.ReadAll(f)
+			json.NewEncoder(w).Encode(map[string]any{"code": 200, "data": map[string]any{"id": r.FormValue("appid") + ":" + h.Filename + ":" + string(content)}})
+		case "/internal/containers/ai":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("bad gateway"))
+		default:
+			w.Write([]byte(`{"code":404,"message":"应用不存在"}`))
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// last 返回最后一个 AppStore 请求及其请求体
+func (s *appStoreServer) last() (*http.Request, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1], s.bodies[len(s.bodies)-1]
+}
+
+func TestAppStoreCatalog(t *testing.T) {
+	srv := newAppStoreServer(t)
+	store := appstore.New(dootask.NewClient("token", dootask.WithServer(srv.URL), dootask.WithVersion("1.0.0")))
+
+	apps, err := store.Catalog()
+	if err != nil {
+		t.Fatalf("获取应用市场失败: %v", err)
+	}
+	if len(apps) != 2 || apps[0].Tags[0] != "智能" || !apps[0].Config.Installed() || apps[1].Config.Installed() {
+		t.Errorf("应用列表不符: %+v", apps)
+	}
+	req, _ := srv.last()
+	if req.Header.Get("Token") != "token" || req.Header.Get("Version") != "1.8.0" {
+		t.Errorf("应带 Token 与主程序版本头: %v", req.Header)
+	}
+
+	upgradeable, err := store.Upgradeable()
+	if err != nil {
+		t.Fatalf("获取可升级应用失败: %v", err)
+	}
+	if len(upgradeable) != 1 || upgradeable[0].LatestVersion() != "2.0.0" || upgradeable[0].Config.InstallVersion != "1.0.0" {
+		t.Errorf("可升级应用不符: %+v", upgradeable)
+	}
+	if req, _ := srv.last(); req.URL.Query().Get("include") != "all" {
+		t.Errorf("应请求全部应用: %s", req.URL)
+	}
+	if srv.versions != 1 {
+		t.Errorf("主程序版本应只获取一次，实际 %d 次", srv.versions)
+	}
+
+	detail, err := store.Get("ai")
+	if err != nil {
+		t.Fatalf("获取应用详情失败: %v", err)
+	}
+	if f, ok := detail.Field("MODEL"); !ok || !f.HasDefault() {
+		t.Errorf("参数定义不符: %+v", detail.Fields)
+	}
+	if detail.Config.Resources.CPULimit != "1" || detail.Config.Resources.MemoryLimit != "2G" {
+		t.Errorf("资源限额不符: %+v", detail.Config.Resources)
+	}
+
+	installed, err := store.Installed()
+	if err != nil || len(installed) != 1 || installed[0].InstallAt == "" {
+		t.Errorf("已安装应用不符: %+v %v", installed, err)
+	}
+
+	// 指定主程序版本时不再请求版本接口
+	srv.versions = 0
+	store = appstore.New(dootask.NewClient("token", dootask.WithServer(srv.URL)), appstore.WithMainVersion("1.9.0"))
+	if _, err := store.Catalog(); err != nil {
+		t.Fatalf("获取应用市场失败: %v", err)
+	}
+	if req, _ := srv.last(); req.Header.Get("Version") != "1.9.0" || srv.versions != 0 {
+		t.Errorf("应使用指定的主程序版本: %v", req.Header)
+	}
+
+	// 版本获取失败时不带 Version 头，且短时间内不再重试
+	var versions atomic.Int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/system/version" {
+			versions.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.Header.Get("Version") != "" {
+			t.Errorf("版本获取失败时不应带 Version 头: %v", r.Header)
+		}
+		w.Write([]byte(`{"code":200,"data":[]}`))
+	}))
+	defer failing.Close()
+	store = appstore.New(dootask.NewClient("token", dootask.WithServer(failing.URL)))
+	for range 3 {
+		if _, err := store.Catalog(); err != nil {
+			t.Fatalf("获取应用市场失败: %v", err)
+		}
+	}
+	if n := versions.Load(); n != 1 {
+		t.Errorf("获取版本失败后应等待再重试，实际请求 %d 次", n)
+	}
+
+	// 调用方 ctx 已取消不影响共享的版本缓存，后续调用仍带 Version 头
+	srv = newAppStoreServer(t)
+	store = appstore.New(dootask.NewClient("token", dootask.WithServer(srv.URL)))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := store.WithContext(ctx).Catalog(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("期望 context.Canceled，实际 %v", err)
+	}
+	if _, err := store.Catalog(); err != nil {
+		t.Fatalf("获取应用市场失败: %v", err)
+	}
+	if req, _ := srv.last(); req.Header.Get("Version") != "1.8.0" {
+		t.Errorf("取消的调用之后仍应带 Version 头: %v", req.Header)
+	}
+}
+
+func TestAppStoreInstall(t *testing.T) {
+	srv := newAppStoreServer(t)
+	store := appstore.New(dootask.NewClient("token", dootask.WithServer(srv.URL)))
+
+	decode := func() map[string]any {
+		_, body := srv.last()
+		var m map[string]any
+		if err := json.Unmarshal([]byte(body), &m); err != nil {
+			t.Fatalf("解析安装请求失败: %v %s", err, body)
+		}
+		return m
+	}
+
+	if err := store.Install(appstore.InstallRequest{AppID: "crm"}); err != nil {
+		t.Fatalf("安装失败: %v", err)
+	}
+	if body := decode(); body["appid"] != "crm" || body["version"] != "latest" || body["params"] != nil || body["resources"] != nil {
+		t.Errorf("安装请求不符: %v", body)
+	}
+
+	// 升级沿用当前参数与限额，只覆盖指定的部分
+	err := store.Update(appstore.InstallRequest{
+		AppID:     "ai",
+		Version:   "2.0.0",
+		Params:    map[string]any{"MODEL": "m2"},
+		Resources: &appstore.Resources{CPULimit: "2"},
+	})
+	if err != nil {
+		t.Fatalf("升级失败: %v", err)
+	}
+	body := decode()
+	params, _ := body["params"].(map[string]any)
+	resources, _ := body["resources"].(map[string]any)
+	if params["TOKEN"] != "t1" || params["MODEL"] != "m2" || resources["cpu_limit"] != "2" || resources["memory_limit"] != "2G" {
+		t.Errorf("升级请求应沿用当前配置: %v", body)
+	}
+
+	if err := store.Update(appstore.InstallRequest{AppID: "crm"}); err == nil {
+		t.Errorf("升级未安装的应用应失败")
+	}
+	if err := store.Install(appstore.InstallRequest{}); err == nil {
+		t.Errorf("缺少应用ID应失败")
+	}
+}
+
+func TestAppStoreOperations(t *testing.T) {
+	srv := newAppStoreServer(t)
+	client := dootask.NewClient("token", dootask.WithServer(srv.URL), dootask.WithRetry(3, time.Millisecond))
+	store := appstore.New(client, appstore.WithMainVersion("1.8.0"))
+
+	// 卸载以 GET 发送但有副作用，失败时不重试
+	err := store.Uninstall("ai", true)
+	var apiErr *appstore.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 503 || err.Error() != "docker 忙" || !errors.Is(err, dootask.ErrServerError) {
+		t.Fatalf("期望 AppStore 错误，实际 %v", err)
+	}
+	req, _ := srv.last()
+	if len(srv.requests) != 1 || req.URL.Query().Get("delete_data") != "true" {
+		t.Errorf("卸载请求不符（%d 次）: %s", len(srv.requests), req.URL)
+	}
+
+	if err := store.Remove("crm"); err != nil {
+		t.Errorf("删除应用失败: %v", err)
+	}
+	if err := store.Remove("missing"); !errors.Is(err, dootask.ErrNotFound) || err.Error() != "应用不存在" {
+		t.Errorf("期望 ErrNotFound，实际 %v", err)
+	}
+	if err := store.Refresh(); err != nil {
+		t.Errorf("刷新应用列表失败: %v", err)
+	}
+
+	logs, err := store.Logs("ai", 50)
+	if err != nil || string(logs) != `{"log":"n=50"}` {
+		t.Errorf("日志不符: %s %v", logs, err)
+	}
+	logs, err = store.ContainerLogs("ai", "web", 0)
+	if err != nil || string(logs) != `"web"` {
+		t.Errorf("容器日志不符: %s %v", logs, err)
+	}
+	if _, err := store.ContainerLogs("ai", "", 0); err == nil {
+		t.Errorf("缺少服务名应失败")
+	}
+
+	// 响应不是 {code,message,data} 结构
+	_, err = store.Containers("ai")
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway || err.Error() != "HTTP 502: bad gateway" {
+		t.Errorf("期望 HTTP 错误，实际 %v", err)
+	}
+
+	id, err := store.Upload(appstore.UploadRequest{Name: "myapp-1.0.0.zip", AppID: "myapp"}, strings.NewReader("PK\x03\x04"))
+	if err != nil {
+		t.Fatalf("上传失败: %v", err)
+	}
+	if id != "myapp:myapp-1.0.0.zip:PK\x03\x04" {
+		t.Errorf("上传结果不符: %q", id)
+	}
+	if _, err := store.Upload(appstore.UploadRequest{}, strings.NewReader("")); err == nil {
+		t.Errorf("缺少文件名应失败")
+	}
+}
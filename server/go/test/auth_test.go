@@ -0,0 +1,367 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/dootasktest"
+)
+
+// ============================================================================
+// 登录与 token 相关测试
+// ============================================================================
+
+func TestLogin(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	anon := srv.ClientFor("")
+
+	result, err := anon.Login("member@dootask.com", dootasktest.Password)
+	if err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	if result.Token != dootasktest.MemberToken || result.UserID != dootasktest.MemberUserID || result.Nickname != "成员" {
+		t.Errorf("登录结果不符: %+v", result)
+	}
+	req := srv.AssertCalled(t, "/api/users/login")
+	if req.Params.String("type") != "login" || req.Params.Has("code") {
+		t.Errorf("登录参数不符: %v", req.Params)
+	}
+
+	if _, err := anon.Login("member@dootask.com", "wrong"); err == nil || err.Error() != "帐号或密码错误" {
+		t.Errorf("期望密码错误，实际 %v", err)
+	}
+	if _, err := anon.Login("", ""); err == nil {
+		t.Errorf("缺少邮箱密码应失败")
+	}
+
+	// token 过期后再次登录下发新 token
+	if !srv.ExpireToken(dootasktest.MemberToken) {
+		t.Fatalf("token 应存在")
+	}
+	if _, err := srv.ClientFor(dootasktest.MemberToken).GetUserInfo(); !errors.Is(err, dootask.ErrUnauthorized) {
+		t.Errorf("过期 token 应返回 ErrUnauthorized，实际 %v", err)
+	}
+	result, err = anon.Login("member@dootask.com", dootasktest.Password)
+	if err != nil || result.Token == "" || result.Token == dootasktest.MemberToken {
+		t.Errorf("应下发新 token: %+v %v", result, err)
+	}
+}
+
+func TestLoginWithCaptcha(t *testing.T) {
+	fixtures := dootasktest.DefaultFixtures()
+	fixtures.LoginCaptcha = true
+	srv := dootasktest.NewServer(t, dootasktest.WithFixtures(fixtures))
+	anon := srv.ClientFor("")
+
+	if _, err := anon.Login("admin@dootask.com", dootasktest.Password); !errors.Is(err, dootask.ErrCaptchaRequired) {
+		t.Fatalf("期望 ErrCaptchaRequired，实际 %v", err)
+	}
+
+	var seen *dootask.Captcha
+	result, err := anon.LoginWithCaptcha("admin@dootask.com", dootasktest.Password, func(c *dootask.Captcha) (string, error) {
+		seen = c
+		return dootasktest.CaptchaCode, nil
+	})
+	if err != nil {
+		t.Fatalf("带验证码登录失败: %v", err)
+	}
+	if result.Token != dootasktest.AdminToken {
+		t.Errorf("登录结果不符: %+v", result)
+	}
+	img, mime, err := seen.ImageBytes()
+	if err != nil || mime != "image/png" || len(img) == 0 {
+		t.Errorf("验证码图片不符: %s %v", mime, err)
+	}
+	req := srv.AssertCalled(t, "/api/users/login")
+	if req.Params.String("code_key") != seen.Key || req.Params.String("code") != dootasktest.CaptchaCode {
+		t.Errorf("验证码参数不符: %v", req.Params)
+	}
+
+	// 答错与放弃
+	_, err = anon.LoginWithCaptcha("admin@dootask.com", dootasktest.Password, func(*dootask.Captcha) (string, error) {
+		return "0000", nil
+	})
+	if !errors.Is(err, dootask.ErrCaptchaRequired) {
+		t.Errorf("答错验证码应返回 ErrCaptchaRequired，实际 %v", err)
+	}
+	canceled := errors.New("用户取消")
+	_, err = anon.LoginWithCaptcha("admin@dootask.com", dootasktest.Password, func(*dootask.Captcha) (string, error) {
+		return "", canceled
+	})
+	if !errors.Is(err, canceled) {
+		t.Errorf("期望返回 solve 的错误，实际 %v", err)
+	}
+}
+
+func TestTokenExpire(t *testing.T) {
+	fixtures := dootasktest.DefaultFixtures()
+	fixtures.Users[0].TokenExpiredAt = time.Now().Add(time.Hour)
+	srv := dootasktest.NewServer(t, dootasktest.WithFixtures(fixtures))
+
+	expire, err := srv.Client().GetTokenExpire()
+	if err != nil {
+		t.Fatalf("获取 token 有效期失败: %v", err)
+	}
+	if expire.IsExpired || expire.ExpiredAt == "" || expire.ExpiresIn() <= 59*time.Minute || !expire.ExpiresWithin(2*time.Hour) || expire.ExpiresWithin(time.Minute) {
+		t.Errorf("token 有效期不符: %+v", expire)
+	}
+
+	// 不过期的 token
+	expire, err = srv.ClientFor(dootasktest.MemberToken).GetTokenExpire()
+	if err != nil || expire.ExpiresIn() != -1 || expire.ExpiresWithin(time.Hour) {
+		t.Errorf("永久 token 有效期不符: %+v %v", expire, err)
+	}
+	if !(&dootask.TokenExpire{IsExpired: true}).ExpiresWithin(0) {
+		t.Errorf("已过期的 token 应判定为即将过期")
+	}
+}
+
+// countingSource 记录调用次数的 TokenSource，Refresh 通过重新登录获取 token
+type countingSource struct {
+	mu      sync.Mutex
+	login   dootask.TokenSource
+	tokens  int
+	refresh int
+}
+
+func (s *countingSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	s.tokens++
+	s.mu.Unlock()
+	return s.login.Token(ctx)
+}
+
+func (s *countingSource) Refresh(ctx context.Context, expired string) (string, error) {
+	s.mu.Lock()
+	s.refresh++
+	s.mu.Unlock()
+	return s.login.Refresh(ctx, expired)
+}
+
+func TestTokenSource(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	src := &countingSource{login: dootask.LoginTokenSource("member@dootask.com", dootasktest.Password, dootask.WithServer(srv.URL))}
+	client := dootask.NewClient("", dootask.WithServer(srv.URL), dootask.WithTokenSource(src))
+
+	// 没有初始 token 时先登录
+	user, err := client.GetUserInfo()
+	if err != nil {
+		t.Fatalf("获取用户信息失败: %v", err)
+	}
+	if user.UserID != dootasktest.MemberUserID || client.Token() != dootasktest.MemberToken || src.tokens != 1 {
+		t.Errorf("应登录后以成员身份请求: %+v %s", user, client.Token())
+	}
+
+	// token 失效后并发请求只刷新一次，并透明重试
+	srv.ExpireToken(dootasktest.MemberToken)
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.WithContext(context.Background()).GetUserInfo(true)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("刷新后重试失败: %v", err)
+		}
+	}
+	if src.refresh != 1 || client.Token() == dootasktest.MemberToken || client.Token() == "" {
+		t.Errorf("应只刷新一次（%d 次），当前 token %s", src.refresh, client.Token())
+	}
+	if last := srv.AssertCalled(t, "/api/users/info"); last.Token != client.Token() {
+		t.Errorf("应使用新 token 请求: %s", last.Token)
+	}
+
+	// 初始 token 有效时不登录
+	src = &countingSource{login: dootask.StaticTokenSource(dootasktest.AdminToken)}
+	client = srv.ClientFor(dootasktest.AdminToken, dootask.WithTokenSource(src))
+	if _, err := client.GetUserInfo(); err != nil || src.tokens != 0 || src.refresh != 0 {
+		t.Errorf("初始 token 有效时不应调用来源: %v", err)
+	}
+
+	// 刷新失败时返回原错误与刷新错误
+	srv.ExpireToken(dootasktest.AdminToken)
+	_, err = client.GetUserInfo(true)
+	if !errors.Is(err, dootask.ErrUnauthorized) || src.refresh != 1 {
+		t.Errorf("期望 ErrUnauthorized，实际 %v", err)
+	}
+}
+
+func TestTokenSourceRequestPaths(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	src := &countingSource{login: dootask.LoginTokenSource("admin@dootask.com", dootasktest.Password, dootask.WithServer(srv.URL))}
+	cache := dootask.NewMemoryCache(10)
+	client := dootask.NewClient("", dootask.WithServer(srv.URL), dootask.WithTokenSource(src), dootask.WithCache(cache))
+
+	// 用户信息缓存以实际使用的 token 为键
+	if _, err := client.GetUserInfo(); err != nil {
+		t.Fatalf("获取用户信息失败: %v", err)
+	}
+	if _, ok := cache.Get(""); ok {
+		t.Errorf("不应以空 token 为缓存键")
+	}
+	if _, ok := cache.Get(dootasktest.AdminToken); !ok {
+		t.Errorf("应以登录所得 token 为缓存键")
+	}
+	srv.ExpireToken(dootasktest.AdminToken)
+	if _, err := client.GetUserInfo(true); err != nil {
+		t.Fatalf("刷新后重试失败: %v", err)
+	}
+	if _, ok := cache.Get(dootasktest.AdminToken); ok {
+		t.Errorf("刷新后应清除旧 token 的缓存")
+	}
+	if _, ok := cache.Get(client.Token()); !ok {
+		t.Errorf("应以新 token 缓存")
+	}
+
+	// 下载与上传同样刷新 token 后重试
+	srv.ExpireToken(client.Token())
+	if n, err := client.DownloadFile(dootasktest.FileID, &bytes.Buffer{}); err != nil || n == 0 {
+		t.Fatalf("下载应在刷新后重试成功: %d %v", n, err)
+	}
+	if last := srv.AssertCalled(t, "/api/file/content"); last.Token != client.Token() {
+		t.Errorf("下载应使用新 token: %s", last.Token)
+	}
+	srv.ExpireToken(client.Token())
+	if _, err := client.UploadFile(dootask.UploadFileRequest{Name: "notes.md"}, strings.NewReader("# 笔记")); err != nil {
+		t.Fatalf("可定位的上传内容应在刷新后重试成功: %v", err)
+	}
+	if last := srv.AssertCalled(t, "/api/file/content/upload"); last.Token != client.Token() {
+		t.Errorf("上传应使用新 token: %s", last.Token)
+	}
+
+	// 不可重放的上传只刷新 token，不重试
+	expired := client.Token()
+	srv.ExpireToken(expired)
+	_, err := client.UploadFile(dootask.UploadFileRequest{Name: "notes.md"}, bytes.NewBufferString("x"))
+	if !errors.Is(err, dootask.ErrUnauthorized) || client.Token() == expired {
+		t.Errorf("期望返回 ErrUnauthorized 并刷新 token，实际 %v", err)
+	}
+	if src.refresh != 4 {
+		t.Errorf("刷新次数不符: %d", src.refresh)
+	}
+}
+
+func TestTokenSourceDo(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("old-token")
+	var calls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Token") != valid.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer api.Close()
+
+	src := &countingSource{login: rotatingSource{next: func() string {
+		valid.Store("new-token")
+		return "new-token"
+	}}}
+	client := dootask.NewClient("old-token", dootask.WithServer(api.URL), dootask.WithTokenSource(src))
+
+	// 401 时刷新 token 并以新 token 重试
+	valid.Store("expired")
+	req, _ := http.NewRequest("GET", api.URL+"/apps/list", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 || client.Token() != "new-token" {
+		t.Errorf("应刷新后重试成功: %d %d %s", resp.StatusCode, calls.Load(), client.Token())
+	}
+
+	// 自带 Token 的请求不参与刷新，401 原样返回
+	req, _ = http.NewRequest("GET", api.URL+"/apps/list", nil)
+	req.Header.Set("Token", "custom")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || src.refresh != 1 {
+		t.Errorf("自带 Token 时应原样返回 401: %d %d", resp.StatusCode, src.refresh)
+	}
+}
+
+// rotatingSource Refresh 时由 next 生成新 token
+type rotatingSource struct {
+	next func() string
+}
+
+func (s rotatingSource) Token(context.Context) (string, error) {
+	return s.next(), nil
+}
+
+func (s rotatingSource) Refresh(context.Context, string) (string, error) {
+	return s.next(), nil
+}
+
+func TestTokenSourceUploadReplay(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64<<10)
+	var calls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// 旧 token 不读请求体直接拒绝，上传协程此时可能仍在读取内容
+		if r.Header.Get("Token") != "new-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"ret":-1,"msg":"请登录后继续..."}`))
+			return
+		}
+		file, _, err := r.FormFile("files")
+		if err != nil {
+			w.Write([]byte(`{"ret":0,"msg":"读取上传失败"}`))
+			return
+		}
+		got, _ := io.ReadAll(file)
+		if !bytes.Equal(got, content) {
+			w.Write([]byte(`{"ret":0,"msg":"上传内容不完整"}`))
+			return
+		}
+		w.Write([]byte(`{"ret":1,"msg":"","data":{"id":1,"name":"big.bin"}}`))
+	}))
+	defer api.Close()
+
+	src := rotatingSource{next: func() string { return "new-token" }}
+	client := dootask.NewClient("old-token", dootask.WithServer(api.URL), dootask.WithTokenSource(src))
+	file, err := client.UploadFile(dootask.UploadFileRequest{Name: "big.bin"}, &slowReader{bytes.NewReader(content)})
+	if err != nil {
+		t.Fatalf("刷新后重新上传失败: %v", err)
+	}
+	if file.ID != 1 || calls.Load() != 2 {
+		t.Errorf("应以新 token 重新上传一次: %+v %d", file, calls.Load())
+	}
+}
+
+// slowReader 包装 bytes.Reader（不暴露 WriteTo），每次读取前稍作停顿，让上传协程在服务器响应后仍在读取内容
+type slowReader struct {
+	r *bytes.Reader
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return r.r.Read(p)
+}
+
+func (r *slowReader) Seek(offset int64, whence int) (int64, error) {
+	return r.r.Seek(offset, whence)
+}
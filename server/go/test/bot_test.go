@@ -0,0 +1,130 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/bot"
+	"github.com/dootask/tools/server/go/webhook"
+)
+
+// ============================================================================
+// 机器人命令路由相关测试
+// ============================================================================
+
+func TestBotRouter(t *testing.T) {
+	var mu sync.Mutex
+	var replies []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		if text, ok := body["text"].(string); ok {
+			replies = append(replies, text)
+		}
+		mu.Unlock()
+		w.Write([]byte(`{"ret":1,"msg":"","data":{}}`))
+	}))
+	defer api.Close()
+
+	r := bot.NewRouter()
+	r.Use(bot.Recover(), bot.RateLimit(100, time.Minute))
+	r.Handle("/deploy <env> [note...]", func(c *bot.Context) error {
+		return c.Replyf("部署 %s：%s", c.Arg("env"), c.Arg("note"))
+	}).Help("部署服务")
+	r.Handle("/boom", func(c *bot.Context) error {
+		panic("boom")
+	})
+	r.Handle("/name", func(c *bot.Context) error {
+		c.Next(func(c *bot.Context) error {
+			c.State().Set("name", c.Event.Message.Text)
+			return c.Reply("你好，" + c.Event.Message.Text)
+		})
+		return c.Reply("请输入名字")
+	})
+	r.Fallback(func(c *bot.Context) error {
+		return c.Reply("echo " + c.Event.Message.Text)
+	})
+
+	hook := httptest.NewServer(r.Webhook(webhook.WithClientOptions(dootask.WithServer(api.URL))))
+	defer hook.Close()
+
+	send := func(text string) string {
+		mu.Lock()
+		replies = nil
+		mu.Unlock()
+		resp, err := http.PostForm(hook.URL, url.Values{"text": {text}, "token": {"bot"}, "dialog_id": {"1"}, "msg_id": {"2"}, "msg_uid": {"3"}})
+		if err != nil {
+			t.Fatalf("推送失败: %v", err)
+		}
+		resp.Body.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Join(replies, "|")
+	}
+
+	if got := send(`/deploy prod "hot fix" now`); got != "部署 prod：hot fix now" {
+		t.Fatalf("命令参数解析不符: %q", got)
+	}
+	if got := send("/deploy"); got != "用法：`/deploy <env> [note...]`" {
+		t.Fatalf("缺少参数应回复用法: %q", got)
+	}
+	if got := send("/help"); !strings.Contains(got, "`/deploy <env> [note...]` 部署服务") || !strings.Contains(got, "/help") {
+		t.Fatalf("帮助文本不符: %q", got)
+	}
+	if got := send("/nope"); !strings.Contains(got, "未知命令") {
+		t.Fatalf("未知命令回复不符: %q", got)
+	}
+	if got := send("/boom"); got != "处理失败，请稍后重试" {
+		t.Fatalf("panic 应被捕获: %q", got)
+	}
+	if got := send("/name"); got != "请输入名字" {
+		t.Fatalf("多轮对话第一步不符: %q", got)
+	}
+	if got := send("小明"); got != "你好，小明" {
+		t.Fatalf("多轮对话第二步不符: %q", got)
+	}
+	if got := send("小明"); got != "echo 小明" {
+		t.Fatalf("多轮对话结束后应回到 Fallback: %q", got)
+	}
+}
+
+func TestBotMiddleware(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ret":1,"msg":"","data":{}}`))
+	}))
+	defer api.Close()
+
+	var ran int
+	r := bot.NewRouter()
+	r.Use(bot.RequireUsers(3), bot.RateLimit(2, time.Minute))
+	r.Handle("/ping", func(c *bot.Context) error {
+		ran++
+		return c.Reply("pong")
+	})
+	hook := httptest.NewServer(r.Webhook(webhook.WithClientOptions(dootask.WithServer(api.URL))))
+	defer hook.Close()
+
+	send := func(uid string) {
+		resp, err := http.PostForm(hook.URL, url.Values{"text": {"/ping"}, "token": {"bot"}, "dialog_id": {"1"}, "msg_uid": {uid}})
+		if err != nil {
+			t.Fatalf("推送失败: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	send("4") // 不在允许列表
+	send("3")
+	send("3")
+	send("3") // 超出频率限制
+	if ran != 2 {
+		t.Fatalf("期望执行 2 次，实际 %d 次", ran)
+	}
+}
@@ -0,0 +1,129 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ============================================================================
+// 缓存相关测试
+// ============================================================================
+
+func TestUserInfoCache(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"ret":1,"msg":"","data":{"userid":1,"nickname":"test","identity":["admin"]}}`))
+	}))
+	defer srv.Close()
+
+	t.Run("并发请求合并", func(t *testing.T) {
+		hits.Store(0)
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := client.GetUserInfo(); err != nil {
+					t.Errorf("获取用户信息失败: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if n := hits.Load(); n != 1 {
+			t.Fatalf("期望请求 1 次，实际 %d 次", n)
+		}
+		if _, err := client.CheckUserIdentity("admin"); err != nil {
+			t.Fatalf("检查身份失败: %v", err)
+		}
+		if n := hits.Load(); n != 1 {
+			t.Fatalf("缓存未命中，实际请求 %d 次", n)
+		}
+	})
+
+	t.Run("发起者取消不影响等待者", func(t *testing.T) {
+		hits.Store(0)
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL))
+		ctx, cancel := context.WithCancel(context.Background())
+		leaderErr := make(chan error, 1)
+		go func() {
+			_, err := client.WithContext(ctx).GetUserInfo()
+			leaderErr <- err
+		}()
+		time.Sleep(5 * time.Millisecond)
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			cancel()
+		}()
+		user, err := client.WithContext(context.Background()).GetUserInfo()
+		if err != nil || user.UserID != 1 {
+			t.Fatalf("等待者应以自己的 ctx 重新请求，实际: %+v, %v", user, err)
+		}
+		if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+			t.Fatalf("发起者应返回 context.Canceled，实际: %v", err)
+		}
+		if n := hits.Load(); n != 2 {
+			t.Fatalf("期望请求 2 次，实际 %d 次", n)
+		}
+	})
+
+	t.Run("缓存过期与强制刷新", func(t *testing.T) {
+		hits.Store(0)
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL), dootask.WithCacheTTL(time.Millisecond))
+		client.GetUserInfo()
+		time.Sleep(5 * time.Millisecond)
+		client.GetUserInfo()
+		client.GetUserInfo(true)
+		if n := hits.Load(); n != 3 {
+			t.Fatalf("期望请求 3 次，实际 %d 次", n)
+		}
+	})
+
+	t.Run("自定义缓存", func(t *testing.T) {
+		hits.Store(0)
+		cache := dootask.NewMemoryCache(10)
+		cache.Set("token", dootask.UserInfo{UserID: 42}, time.Minute)
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL), dootask.WithCache(cache))
+		user, err := client.GetUserInfo()
+		if err != nil || user.UserID != 42 {
+			t.Fatalf("期望命中自定义缓存，实际: %+v, %v", user, err)
+		}
+		if n := hits.Load(); n != 0 {
+			t.Fatalf("期望不请求接口，实际 %d 次", n)
+		}
+	})
+}
+
+func TestMemoryCacheLRU(t *testing.T) {
+	cache := dootask.NewMemoryCache(2)
+	cache.Set("a", dootask.UserInfo{UserID: 1}, time.Minute)
+	cache.Set("b", dootask.UserInfo{UserID: 2}, time.Minute)
+	cache.Get("a") // a 变为最近使用
+	cache.Set("c", dootask.UserInfo{UserID: 3}, time.Minute)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("b 应被淘汰")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("a 不应被淘汰")
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("期望 2 条，实际 %d 条", cache.Len())
+	}
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Fatal("a 应已删除")
+	}
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/cassette"
+	"github.com/dootask/tools/server/go/dootasktest"
+)
+
+// ============================================================================
+// 录制回放相关测试
+// ============================================================================
+
+// cassetteScenario 录制与回放共用的调用序列，返回各步结果的摘要
+func cassetteScenario(t *testing.T, client *dootask.Client) []string {
+	t.Helper()
+	user, err := client.GetUserInfo()
+	if err != nil {
+		t.Fatalf("获取用户信息失败: %v", err)
+	}
+	tasks, err := client.GetTaskList(dootask.GetTaskListRequest{ProjectID: dootasktest.ProjectID, Archived: "no", Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("获取任务列表失败: %v", err)
+	}
+	var first, second dootask.DialogMessage
+	client.SendMessage(dootask.SendMessageRequest{DialogID: dootasktest.GroupDialogID, Text: "第一条"}, &first)
+	client.SendMessage(dootask.SendMessageRequest{DialogID: dootasktest.GroupDialogID, Text: "第二条"}, &second)
+	_, err = client.GetTask(dootask.GetTaskRequest{TaskID: 999})
+	return []string{user.Nickname, tasks.Data[0].Name, first.Msg.(map[string]any)["text"].(string), second.Msg.(map[string]any)["text"].(string), err.Error()}
+}
+
+func TestCassetteRecordReplay(t *testing.T) {
+	tape := filepath.Join(t.TempDir(), "scenario.json")
+
+	// 录制：对（模拟的）真实服务发出请求
+	srv := dootasktest.NewServer(t)
+	rec, err := cassette.Open(tape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Recording() {
+		t.Fatal("磁带不存在时应进入录制模式")
+	}
+	recorded := cassetteScenario(t, srv.Client(dootask.WithTransport(rec)))
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(tape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), dootasktest.AdminToken) || !strings.Contains(string(data), cassette.Redacted) {
+		t.Error("磁带中的 token 应被脱敏")
+	}
+
+	// 回放：服务已关闭，结果与录制一致
+	rec, err = cassette.Open(tape, cassette.WithMode(cassette.ModeReplay))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := dootask.NewClient("another-token", dootask.WithServer(srv.URL), dootask.WithTransport(rec))
+	replayed := cassetteScenario(t, client)
+	if strings.Join(replayed, "|") != strings.Join(recorded, "|") {
+		t.Errorf("回放结果不符:\n录制 %v\n回放 %v", recorded, replayed)
+	}
+
+	// 记录用完后再次请求
+	if _, err := client.GetTask(dootask.GetTaskRequest{TaskID: 999}); !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("记录用完后期望 ErrNoInteraction，实际 %v", err)
+	}
+}
+
+func TestCassetteQueryMatching(t *testing.T) {
+	tape := filepath.Join(t.TempDir(), "query.json")
+	os.WriteFile(tape, []byte(`{
+  "version": 1,
+  "interactions": [
+    {
+      "request": {"method": "GET", "path": "/api/project/task/lists", "query": "page=2&project_id=1&token=%5BREDACTED%5D"},
+      "response": {"status": 200, "body": {"ret": 1, "msg": "", "data": {"current_page": 2, "data": [], "total": 0}}}
+    },
+    {
+      "request": {"method": "GET", "path": "/api/users/info"},
+      "response": {"status": 404, "body": "not found"}
+    }
+  ]
+}`), 0o644)
+	rec, err := cassette.Open(tape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Recording() {
+		t.Fatal("磁带存在时应进入回放模式")
+	}
+
+	// 查询参数顺序与 token 值不影响匹配
+	hc := &http.Client{Transport: rec}
+	resp, err := hc.Get("http://dootask.local/api/project/task/lists?token=abc&project_id=1&page=2")
+	if err != nil {
+		t.Fatalf("匹配失败: %v", err)
+	}
+	resp.Body.Close()
+
+	client := dootask.NewClient("token", dootask.WithServer("http://dootask.local"), dootask.WithTransport(rec))
+	if _, err := client.GetUserInfo(); !errors.Is(err, dootask.ErrNotFound) {
+		t.Errorf("期望 ErrNotFound，实际 %v", err)
+	}
+	if _, err := client.GetProjectList(dootask.GetProjectListRequest{}); !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("期望 ErrNoInteraction，实际 %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ============================================================================
+// Context 相关测试
+// ============================================================================
+
+func TestContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := dootask.NewClient("token", dootask.WithServer(srv.URL), dootask.WithTimeout(30*time.Second))
+
+	t.Run("取消请求", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		_, err := client.WithContext(ctx).GetTaskList(dootask.GetTaskListRequest{})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("期望 context.Canceled，实际: %v", err)
+		}
+	})
+
+	t.Run("请求超时", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := client.NewGetRequestWithContext(ctx, "/api/system/version", nil, nil)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("期望 context.DeadlineExceeded，实际: %v", err)
+		}
+	})
+
+	t.Run("未绑定时使用 Background", func(t *testing.T) {
+		if client.Context() != context.Background() {
+			t.Fatal("未绑定 ctx 的客户端应返回 context.Background()")
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/dootasktest"
+)
+
+// ============================================================================
+// 模拟服务相关测试
+// ============================================================================
+
+func TestFakeServerErrors(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+
+	t.Run("无效 token", func(t *testing.T) {
+		_, err := srv.ClientFor("bad-token").GetUserInfo()
+		if !errors.Is(err, dootask.ErrUnauthorized) {
+			t.Errorf("期望 ErrUnauthorized，实际 %v", err)
+		}
+	})
+
+	t.Run("公开接口无需 token", func(t *testing.T) {
+		if _, err := srv.ClientFor("").GetVersion(); err != nil {
+			t.Errorf("获取版本失败: %v", err)
+		}
+	})
+
+	t.Run("不存在的数据", func(t *testing.T) {
+		_, err := srv.Client().GetTask(dootask.GetTaskRequest{TaskID: 999})
+		if !errors.Is(err, dootask.ErrNotFound) {
+			t.Errorf("期望 ErrNotFound，实际 %v", err)
+		}
+	})
+
+	t.Run("非成员访问", func(t *testing.T) {
+		guest := srv.ClientFor(dootasktest.GuestToken)
+		_, err := guest.GetDialogOne(dootask.GetDialogRequest{DialogID: dootasktest.DirectDialogID})
+		if !errors.Is(err, dootask.ErrPermissionDenied) {
+			t.Errorf("期望 ErrPermissionDenied，实际 %v", err)
+		}
+	})
+
+	t.Run("自定义接口", func(t *testing.T) {
+		srv.Handle("/api/users/bot/list", func(r *dootasktest.Request) (any, error) {
+			return nil, &dootasktest.Error{Status: http.StatusTooManyRequests, Msg: "请求过于频繁"}
+		})
+		_, err := srv.Client().GetBotList()
+		if !errors.Is(err, dootask.ErrRateLimited) {
+			t.Errorf("期望 ErrRateLimited，实际 %v", err)
+		}
+	})
+}
+
+func TestFakeServerState(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	member := srv.ClientFor(dootasktest.MemberToken)
+
+	var msg dootask.DialogMessage
+	err := member.SendMessage(dootask.SendMessageRequest{DialogID: dootasktest.GroupDialogID, Text: "第一条"}, &msg)
+	if err != nil {
+		t.Fatalf("发送消息失败: %v", err)
+	}
+	if m, ok := srv.Message(msg.ID); !ok || m.UserID != dootasktest.MemberUserID {
+		t.Fatalf("消息未记录: %+v", m)
+	}
+	r := srv.AssertCalled(t, "/api/dialog/msg/sendtext")
+	if r.UserID != dootasktest.MemberUserID || r.Params.String("text") != "第一条" {
+		t.Errorf("请求记录不符: %+v", r)
+	}
+
+	// 修改消息
+	err = member.SendMessage(dootask.SendMessageRequest{DialogID: dootasktest.GroupDialogID, Text: "已修改", UpdateID: msg.ID})
+	if err != nil {
+		t.Fatalf("修改消息失败: %v", err)
+	}
+	if m, _ := srv.Message(msg.ID); m.Modify != 1 {
+		t.Errorf("消息应标记为已修改: %+v", m)
+	}
+
+	// 完成任务后项目统计随之变化
+	admin := srv.Client()
+	if _, err := admin.UpdateTask(dootask.UpdateTaskRequest{TaskID: dootasktest.TaskID, CompleteAt: dootask.Set[any](true)}); err != nil {
+		t.Fatalf("完成任务失败: %v", err)
+	}
+	project, err := admin.GetProject(dootask.GetProjectRequest{ProjectID: dootasktest.ProjectID})
+	if err != nil {
+		t.Fatalf("获取项目失败: %v", err)
+	}
+	if project.TaskNum != 1 || project.TaskComplete != 1 {
+		t.Errorf("项目任务统计不符: %d/%d", project.TaskComplete, project.TaskNum)
+	}
+
+	srv.ResetRequests()
+	srv.AssertNotCalled(t, "/api/dialog/msg/sendtext")
+}
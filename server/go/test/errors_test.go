@@ -0,0 +1,119 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ============================================================================
+// 错误类型相关测试
+// ============================================================================
+
+func TestAPIErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/users/info":
+			w.Write([]byte(`{"ret":-1,"msg":"请登录后继续...","data":{}}`))
+		case "/api/project/task/one":
+			w.Write([]byte(`{"ret":0,"msg":"任务不存在或已被删除","data":{}}`))
+		case "/api/users/login":
+			w.Write([]byte(`{"ret":0,"msg":"请输入验证码","data":{"code":"need"}}`))
+		case "/api/users/basic":
+			w.Write([]byte(`{"ret":1,"msg":"","data":[]}`))
+		case "/api/system/version":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("<html>502 Bad Gateway</html>"))
+		default:
+			w.Write([]byte(`{"ret":0,"msg":"","data":null}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := dootask.NewClient("token", dootask.WithServer(srv.URL))
+
+	t.Run("身份失效", func(t *testing.T) {
+		_, err := client.GetUserInfo()
+		if !errors.Is(err, dootask.ErrUnauthorized) {
+			t.Fatalf("期望 ErrUnauthorized，实际: %v", err)
+		}
+		var apiErr *dootask.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("期望 *APIError，实际: %T", err)
+		}
+		if apiErr.Ret != -1 || apiErr.Method != "GET" || apiErr.Endpoint != "/api/users/info" || apiErr.StatusCode != http.StatusOK {
+			t.Fatalf("APIError 字段不符: %+v", apiErr)
+		}
+		if err.Error() != "请登录后继续..." {
+			t.Fatalf("错误信息应保持服务端原文，实际: %q", err.Error())
+		}
+	})
+
+	t.Run("资源不存在", func(t *testing.T) {
+		_, err := client.GetTask(dootask.GetTaskRequest{TaskID: 1})
+		if !errors.Is(err, dootask.ErrNotFound) {
+			t.Fatalf("期望 ErrNotFound，实际: %v", err)
+		}
+		if errors.Is(err, dootask.ErrUnauthorized) {
+			t.Fatal("不应匹配 ErrUnauthorized")
+		}
+	})
+
+	t.Run("需要验证码", func(t *testing.T) {
+		err := client.NewGetRequest("/api/users/login", map[string]any{"email": "a@b.c"}, nil)
+		if !errors.Is(err, dootask.ErrCaptchaRequired) {
+			t.Fatalf("期望 ErrCaptchaRequired，实际: %v", err)
+		}
+	})
+
+	t.Run("用户不存在", func(t *testing.T) {
+		_, err := client.GetUserBasic(999)
+		if !errors.Is(err, dootask.ErrNotFound) {
+			t.Fatalf("期望 ErrNotFound，实际: %v", err)
+		}
+		// 客户端判定的失败不伪造 APIError
+		var apiErr *dootask.APIError
+		if errors.As(err, &apiErr) {
+			t.Fatalf("不应返回 *APIError: %+v", apiErr)
+		}
+	})
+
+	t.Run("HTTP 错误", func(t *testing.T) {
+		_, err := client.GetVersion()
+		if !errors.Is(err, dootask.ErrServerError) {
+			t.Fatalf("期望 ErrServerError，实际: %v", err)
+		}
+		var apiErr *dootask.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+			t.Fatalf("期望 HTTP 502 的 *APIError，实际: %v", err)
+		}
+	})
+
+	t.Run("无法归类", func(t *testing.T) {
+		err := client.DeleteColumn(1)
+		var apiErr *dootask.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("期望 *APIError，实际: %v", err)
+		}
+		for _, sentinel := range []error{dootask.ErrUnauthorized, dootask.ErrNotFound, dootask.ErrPermissionDenied, dootask.ErrCaptchaRequired} {
+			if errors.Is(err, sentinel) {
+				t.Fatalf("不应匹配 %v", sentinel)
+			}
+		}
+		if err.Error() != "API error: 0" {
+			t.Fatalf("错误信息不符: %q", err.Error())
+		}
+	})
+
+	t.Run("传输层错误", func(t *testing.T) {
+		bad := dootask.NewClient("token", dootask.WithServer("http://127.0.0.1:1"))
+		_, err := bad.GetVersion()
+		var tErr *dootask.TransportError
+		if !errors.As(err, &tErr) || tErr.Endpoint != "/api/system/version" {
+			t.Fatalf("期望 *TransportError，实际: %v", err)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/dootasktest"
+)
+
+// ============================================================================
+// 文件相关测试
+// ============================================================================
+
+func TestFileOperations(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	client := srv.Client()
+
+	folder, err := client.Mkdir(0, "资料")
+	if err != nil {
+		t.Fatalf("创建文件夹失败: %v", err)
+	}
+	if !folder.IsFolder() || folder.PID != 0 {
+		t.Errorf("文件夹信息不符: %+v", folder)
+	}
+
+	renamed, err := client.RenameFile(folder.ID, "归档")
+	if err != nil {
+		t.Fatalf("重命名失败: %v", err)
+	}
+	if renamed.Name != "归档" {
+		t.Errorf("名称不符: %s", renamed.Name)
+	}
+
+	moved, err := client.MoveFiles([]int{dootasktest.FileID}, folder.ID)
+	if err != nil {
+		t.Fatalf("移动文件失败: %v", err)
+	}
+	if len(moved) != 1 || moved[0].PID != folder.ID {
+		t.Errorf("移动结果不符: %+v", moved)
+	}
+
+	files, err := client.ListFiles(folder.ID)
+	if err != nil {
+		t.Fatalf("获取文件列表失败: %v", err)
+	}
+	if len(files) != 1 || files[0].ID != dootasktest.FileID {
+		t.Errorf("文件列表不符: %+v", files)
+	}
+
+	found, err := client.SearchFiles(dootask.SearchFilesRequest{Key: "readme"})
+	if err != nil {
+		t.Fatalf("搜索文件失败: %v", err)
+	}
+	if len(found) != 1 || found[0].Ext != "txt" {
+		t.Errorf("搜索结果不符: %+v", found)
+	}
+
+	text, err := client.FetchFileText("/归档/readme.txt", 6, 3)
+	if err != nil {
+		t.Fatalf("读取文本失败: %v", err)
+	}
+	if text.Content != "Doo" || text.Total != 13 {
+		t.Errorf("文本片段不符: %+v", text)
+	}
+
+	if err := client.DeleteFiles([]int{folder.ID}); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if _, err := client.GetFile(dootasktest.FileID); !errors.Is(err, dootask.ErrNotFound) {
+		t.Errorf("文件夹内容应一并删除，实际 %v", err)
+	}
+}
+
+func TestFileTransfer(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	client := srv.Client()
+
+	t.Run("流式上传", func(t *testing.T) {
+		content := strings.Repeat("DooTask 文件内容\n", 1024)
+		file, err := client.UploadFile(dootask.UploadFileRequest{PID: dootasktest.FolderID, Name: "notes.md"}, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("上传失败: %v", err)
+		}
+		if file.Name != "notes" || file.Ext != "md" || file.Size != int64(len(content)) {
+			t.Errorf("文件信息不符: %+v", file)
+		}
+		req := srv.AssertCalled(t, "/api/file/content/upload")
+		if len(req.Files) != 1 || req.Files[0].Field != "files" || string(req.Files[0].Data) != content {
+			t.Errorf("上传内容不符")
+		}
+
+		_, err = client.UploadFile(dootask.UploadFileRequest{PID: dootasktest.FolderID, Name: "notes.md"}, strings.NewReader("x"))
+		if err == nil {
+			t.Errorf("同名文件未覆盖时应失败")
+		}
+		if _, err := client.UploadFile(dootask.UploadFileRequest{PID: dootasktest.FolderID, Name: "notes.md", Cover: true}, strings.NewReader("x")); err != nil {
+			t.Errorf("覆盖上传失败: %v", err)
+		}
+	})
+
+	t.Run("读取失败中止上传", func(t *testing.T) {
+		readErr := errors.New("disk error")
+		_, err := client.UploadFile(dootask.UploadFileRequest{Name: "broken.bin"}, io.MultiReader(strings.NewReader("abc"), errReader{readErr}))
+		if !errors.Is(err, readErr) {
+			t.Errorf("期望读取错误，实际 %v", err)
+		}
+	})
+
+	t.Run("流式下载", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := client.DownloadFile(dootasktest.FileID, &buf)
+		if err != nil {
+			t.Fatalf("下载失败: %v", err)
+		}
+		if n != 13 || buf.String() != "Hello DooTask" {
+			t.Errorf("下载内容不符: %d %q", n, buf.String())
+		}
+
+		_, err = client.DownloadFile(999, io.Discard)
+		if !errors.Is(err, dootask.ErrNotFound) {
+			t.Errorf("期望 ErrNotFound，实际 %v", err)
+		}
+	})
+}
+
+func TestFileShare(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	admin := srv.Client()
+	member := srv.ClientFor(dootasktest.MemberToken)
+
+	if _, err := member.GetFile(dootasktest.FileID); !errors.Is(err, dootask.ErrNotFound) {
+		t.Fatalf("未共享的文件不应可见，实际 %v", err)
+	}
+
+	err := admin.UpdateFileShare(dootask.UpdateFileShareRequest{ID: dootasktest.FolderID, UserIDs: []int{dootasktest.MemberUserID}, Permission: 0})
+	if err != nil {
+		t.Fatalf("设置共享失败: %v", err)
+	}
+	info, err := admin.GetFileShare(dootasktest.FolderID)
+	if err != nil {
+		t.Fatalf("获取共享信息失败: %v", err)
+	}
+	if len(info.List) != 1 || info.List[0].UserID != dootasktest.MemberUserID {
+		t.Errorf("共享成员不符: %+v", info)
+	}
+
+	// 共享沿文件夹继承，只读成员不能修改
+	if _, err := member.GetFile(dootasktest.FileID); err != nil {
+		t.Errorf("共享后应可见: %v", err)
+	}
+	if _, err := member.RenameFile(dootasktest.FileID, "x"); !errors.Is(err, dootask.ErrPermissionDenied) {
+		t.Errorf("期望 ErrPermissionDenied，实际 %v", err)
+	}
+
+	link, err := admin.GetFileLink(dootasktest.FileID, false)
+	if err != nil {
+		t.Fatalf("获取分享链接失败: %v", err)
+	}
+	again, _ := admin.GetFileLink(dootasktest.FileID, false)
+	refreshed, _ := admin.GetFileLink(dootasktest.FileID, true)
+	if link.URL == "" || again.URL != link.URL || refreshed.URL == link.URL {
+		t.Errorf("分享链接不符: %s %s %s", link.URL, again.URL, refreshed.URL)
+	}
+
+	if err := member.ExitFileShare(dootasktest.FolderID); err != nil {
+		t.Fatalf("退出共享失败: %v", err)
+	}
+	if _, err := member.GetFile(dootasktest.FileID); !errors.Is(err, dootask.ErrNotFound) {
+		t.Errorf("退出共享后不应可见，实际 %v", err)
+	}
+}
+
+// errReader 读取时返回指定错误
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
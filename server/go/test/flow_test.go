@@ -0,0 +1,144 @@
+package test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/dootasktest"
+)
+
+// ============================================================================
+// 工作流相关测试
+// ============================================================================
+
+func TestProjectFlow(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	client := srv.Client()
+
+	if _, err := client.GetProjectFlow(dootasktest.ProjectID); !errors.Is(err, dootask.ErrNotFound) {
+		t.Errorf("未开启工作流时期望 ErrNotFound，实际 %v", err)
+	}
+
+	flow, err := client.SaveProjectFlow(dootask.SaveProjectFlowRequest{
+		ProjectID: dootasktest.ProjectID,
+		Items: []dootask.FlowItem{
+			{ID: -1, Name: "待处理", Status: dootask.FlowStatusStart, Turns: []int{-2}},
+			{ID: -2, Name: "处理中", Status: dootask.FlowStatusProgress, Turns: []int{-1, -3}, ColumnID: dootasktest.DoingColumnID},
+			{ID: -3, Name: "已完成", Status: dootask.FlowStatusEnd, Turns: []int{-2}, UserIDs: []int{dootasktest.MemberUserID}, UserType: "replace"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("保存工作流失败: %v", err)
+	}
+	if len(flow.Items) != 3 {
+		t.Fatalf("状态数量不符: %+v", flow.Items)
+	}
+	doing, _ := flow.Item("处理中")
+	done, _ := flow.Item("已完成")
+	if doing.ID <= 0 || !doing.CanTurnTo(done.ID) || !slices.Equal(done.Turns, []int{doing.ID}) {
+		t.Errorf("临时ID应替换为正式ID: %+v", flow.Items)
+	}
+
+	// 更新：保留已有状态并新增一个状态
+	items := append(flow.Items, dootask.FlowItem{ID: -1, Name: "已取消", Status: dootask.FlowStatusEnd})
+	items[0].Turns = append(items[0].Turns, -1)
+	flow, err = client.SaveProjectFlow(dootask.SaveProjectFlowRequest{ProjectID: dootasktest.ProjectID, Items: items})
+	if err != nil {
+		t.Fatalf("更新工作流失败: %v", err)
+	}
+	cancelled, ok := flow.Item("已取消")
+	if !ok || flow.Items[0].ID != items[0].ID || !flow.Items[0].CanTurnTo(cancelled.ID) {
+		t.Errorf("更新后的工作流不符: %+v", flow.Items)
+	}
+
+	_, err = client.SaveProjectFlow(dootask.SaveProjectFlowRequest{
+		ProjectID: dootasktest.ProjectID,
+		Items:     []dootask.FlowItem{{Name: "只有开始", Status: dootask.FlowStatusStart}},
+	})
+	if err == nil {
+		t.Errorf("缺少结束状态时应失败")
+	}
+
+	member := srv.ClientFor(dootasktest.MemberToken)
+	if err := member.DeleteProjectFlow(dootasktest.ProjectID); !errors.Is(err, dootask.ErrPermissionDenied) {
+		t.Errorf("非负责人删除期望 ErrPermissionDenied，实际 %v", err)
+	}
+	if err := client.DeleteProjectFlow(dootasktest.ProjectID); err != nil {
+		t.Fatalf("删除工作流失败: %v", err)
+	}
+	if flows, err := client.GetProjectFlows(dootasktest.ProjectID); err != nil || len(flows) != 0 {
+		t.Errorf("删除后工作流应为空: %v %v", flows, err)
+	}
+}
+
+func TestTransitionTask(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	client := srv.Client()
+
+	project, err := client.CreateProject(dootask.CreateProjectRequest{Name: "流程项目", Flow: "open"})
+	if err != nil {
+		t.Fatalf("创建项目失败: %v", err)
+	}
+	flow, err := client.GetProjectFlow(project.ID)
+	if err != nil {
+		t.Fatalf("获取工作流失败: %v", err)
+	}
+	// 收紧默认工作流：待处理只能到进行中，已完成只能回到进行中
+	start, _ := flow.Item("待处理")
+	doing, _ := flow.Item("进行中")
+	done, _ := flow.Item("已完成")
+	start.Turns = []int{doing.ID}
+	done.Turns = []int{doing.ID}
+	done.UserIDs, done.UserType = []int{dootasktest.MemberUserID}, "merge"
+	if flow, err = client.SaveProjectFlow(dootask.SaveProjectFlowRequest{ProjectID: project.ID, Items: flow.Items}); err != nil {
+		t.Fatalf("保存工作流失败: %v", err)
+	}
+
+	task, err := client.CreateTask(dootask.CreateTaskRequest{ProjectID: project.ID, Name: "流程任务"})
+	if err != nil {
+		t.Fatalf("创建任务失败: %v", err)
+	}
+	if task.FlowItemName != "待处理" {
+		t.Errorf("新任务应处于开始状态: %q", task.FlowItemName)
+	}
+
+	_, err = client.TransitionTask(task.ID, "已完成")
+	if !errors.Is(err, dootask.ErrInvalidTransition) {
+		t.Fatalf("期望 ErrInvalidTransition，实际 %v", err)
+	}
+	srv.AssertNotCalled(t, "/api/project/task/move")
+	if _, err := client.TransitionTask(task.ID, "不存在"); !errors.Is(err, dootask.ErrInvalidTransition) {
+		t.Errorf("未知状态期望 ErrInvalidTransition，实际 %v", err)
+	}
+
+	if task, err = client.TransitionTask(task.ID, "进行中"); err != nil {
+		t.Fatalf("流转到进行中失败: %v", err)
+	}
+	if task.FlowItemName != "进行中" || task.CompleteAt != "" {
+		t.Errorf("流转结果不符: %+v", task)
+	}
+	req := srv.AssertCalled(t, "/api/project/task/move")
+	if req.Params.Int("flow_item_id") != doing.ID || req.Params.Int("project_id") != project.ID {
+		t.Errorf("流转请求参数不符: %v", req.Params)
+	}
+
+	if task, err = client.TransitionTask(task.ID, "已完成"); err != nil {
+		t.Fatalf("流转到已完成失败: %v", err)
+	}
+	if task.CompleteAt == "" {
+		t.Errorf("结束状态应标记完成: %+v", task)
+	}
+	stored, _ := srv.Task(task.ID)
+	if !slices.Equal(stored.Owners, []int{dootasktest.MemberUserID}) || !slices.Equal(stored.Assists, []int{dootasktest.AdminUserID}) {
+		t.Errorf("状态负责人设置不符: owners=%v assists=%v", stored.Owners, stored.Assists)
+	}
+
+	// 已处于目标状态时不发请求
+	srv.ResetRequests()
+	if _, err := client.TransitionTask(task.ID, "已完成"); err != nil {
+		t.Fatalf("重复流转失败: %v", err)
+	}
+	srv.AssertNotCalled(t, "/api/project/task/move")
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ============================================================================
+// 消息历史遍历相关测试
+// ============================================================================
+
+// historyServer 模拟共 total 条消息的对话；偶数 ID 为 image，奇数为 text。
+// 与线上接口一样，prev_id / next_id 批次会带上游标处的边界消息
+func historyServer(total int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		prev, _ := strconv.Atoi(q.Get("prev_id"))
+		next, _ := strconv.Atoi(q.Get("next_id"))
+		take, _ := strconv.Atoi(q.Get("take"))
+		msgType := q.Get("msg_type")
+
+		typeOf := func(id int) string {
+			if id%2 == 0 {
+				return "image"
+			}
+			return "text"
+		}
+		var list []dootask.DialogMessage
+		add := func(id int) bool {
+			if msgType == "" || typeOf(id) == msgType {
+				list = append(list, dootask.DialogMessage{ID: id, DialogID: 1, Type: typeOf(id)})
+			}
+			return len(list) >= take
+		}
+		switch {
+		case next > 0:
+			for id := next; id <= total && !add(id); id++ {
+			}
+		case prev > 0:
+			for id := prev; id >= 1 && !add(id); id-- {
+			}
+		default:
+			for id := total; id >= 1 && !add(id); id-- {
+			}
+		}
+		data, _ := json.Marshal(map[string]any{"list": list})
+		w.Write([]byte(`{"ret":1,"msg":"","data":` + string(data) + `}`))
+	}))
+}
+
+func TestMessageHistory(t *testing.T) {
+	srv := historyServer(250)
+	defer srv.Close()
+	client := dootask.NewClient("token", dootask.WithServer(srv.URL))
+	ctx := context.Background()
+
+	collect := func(seq iter.Seq2[dootask.DialogMessage, error]) []int {
+		var ids []int
+		for msg, err := range seq {
+			if err != nil {
+				t.Fatalf("遍历消息失败: %v", err)
+			}
+			ids = append(ids, msg.ID)
+		}
+		return ids
+	}
+
+	t.Run("从最新消息向前遍历", func(t *testing.T) {
+		ids := collect(client.MessagesBefore(ctx, 1, 0))
+		if len(ids) != 250 || ids[0] != 250 || ids[249] != 1 {
+			t.Fatalf("遍历结果不符: 共 %d 条", len(ids))
+		}
+		seen := map[int]bool{}
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("消息 %d 重复", id)
+			}
+			seen[id] = true
+		}
+	})
+
+	t.Run("向后遍历并限制条数", func(t *testing.T) {
+		ids := collect(client.MessagesAfter(ctx, 1, 100, dootask.WithMessageBatch(30), dootask.WithMessageLimit(50)))
+		if len(ids) != 50 || ids[0] != 101 || ids[49] != 150 {
+			t.Fatalf("遍历结果不符: %v", ids)
+		}
+	})
+
+	t.Run("按类型过滤", func(t *testing.T) {
+		ids := collect(client.MessagesBefore(ctx, 1, 21, dootask.WithMessageType("image")))
+		if len(ids) != 10 || ids[0] != 20 || ids[9] != 2 {
+			t.Fatalf("遍历结果不符: %v", ids)
+		}
+	})
+
+	t.Run("向后遍历需要起点", func(t *testing.T) {
+		for _, err := range client.MessagesAfter(ctx, 1, 0) {
+			if err == nil {
+				t.Fatal("期望报错")
+			}
+		}
+	})
+}
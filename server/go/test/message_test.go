@@ -0,0 +1,73 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ============================================================================
+// 消息内容解析相关测试
+// ============================================================================
+
+func TestDecodeMessage(t *testing.T) {
+	var list struct {
+		List []dootask.DialogMessage `json:"list"`
+	}
+	data := `{"list":[
+		{"id":1,"type":"text","msg":{"text":"<p>你好</p>","type":"md"}},
+		{"id":2,"type":"image","msg":{"name":"a.png","size":1024,"ext":"png","path":"uploads/a.png","width":100,"height":80}},
+		{"id":3,"type":"record","msg":{"path":"uploads/a.mp3","duration":3000,"text":"语音"}},
+		{"id":4,"type":"notice","msg":{"notice":"已加入群组"}},
+		{"id":5,"type":"vote","msg":{"text":"午饭","type":"create","uuid":"u1","multiple":"1","anonymous":0}},
+		{"id":6,"type":"unknown-type","msg":{"foo":"bar"}}
+	]}`
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		t.Fatalf("解析消息列表失败: %v", err)
+	}
+
+	bodies := make([]dootask.MessageBody, len(list.List))
+	for i := range list.List {
+		body, err := list.List[i].Decode()
+		if err != nil {
+			t.Fatalf("解析消息 %d 失败: %v", list.List[i].ID, err)
+		}
+		bodies[i] = body
+	}
+
+	if m, ok := bodies[0].(*dootask.TextMessage); !ok || m.Text != "<p>你好</p>" {
+		t.Fatalf("文本消息解析不符: %#v", bodies[0])
+	}
+	if m, ok := bodies[1].(*dootask.FileMessage); !ok || !m.IsImage() || m.Width != 100 || m.Size != 1024 {
+		t.Fatalf("图片消息解析不符: %#v", bodies[1])
+	}
+	if m, ok := bodies[2].(*dootask.RecordMessage); !ok || m.Duration != 3000 {
+		t.Fatalf("语音消息解析不符: %#v", bodies[2])
+	}
+	if m, ok := bodies[3].(*dootask.NoticeMessage); !ok || m.Notice != "已加入群组" {
+		t.Fatalf("通知消息解析不符: %#v", bodies[3])
+	}
+	if m, ok := bodies[4].(*dootask.VoteMessage); !ok || m.Multiple != 1 {
+		t.Fatalf("投票消息解析不符: %#v", bodies[4])
+	}
+	if m, ok := bodies[5].(*dootask.RawMessage); !ok || m.MessageType() != "unknown-type" || string(m.Raw) != `{"foo":"bar"}` {
+		t.Fatalf("未知消息应保留原始 JSON: %#v", bodies[5])
+	}
+
+	if text, ok := list.List[0].AsText(); !ok || text != "<p>你好</p>" {
+		t.Fatalf("AsText 不符: %q %v", text, ok)
+	}
+	if _, ok := list.List[1].AsText(); ok {
+		t.Fatal("图片消息 AsText 应返回 false")
+	}
+
+	dialog := dootask.DialogInfo{LastMsg: map[string]any{"id": 9, "type": "text", "msg": map[string]any{"text": "hi"}}}
+	last, err := dialog.LastMessage()
+	if err != nil || last.ID != 9 {
+		t.Fatalf("解析最后一条消息失败: %+v %v", last, err)
+	}
+	if text, _ := last.AsText(); text != "hi" {
+		t.Fatalf("最后一条消息内容不符: %q", text)
+	}
+}
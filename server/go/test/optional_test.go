@@ -0,0 +1,91 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ============================================================================
+// 部分更新相关测试
+// ============================================================================
+
+func TestOptionalPatch(t *testing.T) {
+	t.Run("请求体只包含已设置字段", func(t *testing.T) {
+		req := dootask.UpdateTaskRequest{
+			TaskID:     1,
+			Name:       dootask.Set("新名称"),
+			Assist:     dootask.Set([]int{}),
+			TaskTag:    dootask.Set([]dootask.TaskTagItem{{Name: "紧急"}}),
+			CompleteAt: dootask.Set[any](true),
+		}
+		b, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("序列化失败: %v", err)
+		}
+		want := `{"task_id":1,"name":"新名称","assist":[],"task_tag":[{"name":"紧急"}],"complete_at":true}`
+		if string(b) != want {
+			t.Errorf("请求体不符:\n期望 %s\n实际 %s", want, b)
+		}
+
+		b, _ = json.Marshal(dootask.EditBotRequest{ID: 2, WebhookURL: dootask.Set("")})
+		if want := `{"id":2,"webhook_url":""}`; string(b) != want {
+			t.Errorf("请求体不符:\n期望 %s\n实际 %s", want, b)
+		}
+
+		b, _ = json.Marshal(dootask.UpdateProjectRequest{ProjectID: 1, Name: "项目", ArchiveDays: dootask.Set(0)})
+		if want := `{"project_id":1,"name":"项目","archive_days":0}`; string(b) != want {
+			t.Errorf("请求体不符:\n期望 %s\n实际 %s", want, b)
+		}
+		b, _ = json.Marshal(dootask.UpdateColumnRequest{ColumnID: 3, Color: dootask.Set("")})
+		if want := `{"column_id":3,"color":""}`; string(b) != want {
+			t.Errorf("请求体不符:\n期望 %s\n实际 %s", want, b)
+		}
+	})
+
+	t.Run("反序列化标记已设置", func(t *testing.T) {
+		var v struct {
+			Name  dootask.Optional[string] `json:"name"`
+			Color dootask.Optional[string] `json:"color"`
+		}
+		if err := json.Unmarshal([]byte(`{"name":""}`), &v); err != nil {
+			t.Fatalf("反序列化失败: %v", err)
+		}
+		if name, ok := v.Name.Get(); !ok || name != "" {
+			t.Errorf("name 应为已设置的空字符串: %v", v.Name)
+		}
+		if v.Color.IsSet() {
+			t.Errorf("color 不应被设置: %v", v.Color)
+		}
+	})
+
+	t.Run("查询参数清空字段", func(t *testing.T) {
+		var query, body string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			b, _ := io.ReadAll(r.Body)
+			body = string(b)
+			w.Write([]byte(`{"ret":1,"msg":"","data":{}}`))
+		}))
+		defer srv.Close()
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL))
+
+		if err := client.NewGetRequest("/api/project/column/update", dootask.UpdateColumnRequest{ColumnID: 3, Color: dootask.Set("")}, nil); err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		if want := "column_id=3&color="; query != want {
+			t.Errorf("查询参数不符:\n期望 %s\n实际 %s", want, query)
+		}
+
+		if _, err := client.UpdateTask(dootask.UpdateTaskRequest{TaskID: 5, Content: dootask.Set("")}); err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		if want := `{"task_id":5,"content":""}`; body != want {
+			t.Errorf("请求体不符:\n期望 %s\n实际 %s", want, body)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/dootasktest"
+)
+
+// ============================================================================
+// 页面操作相关测试
+// ============================================================================
+
+// pageBrowser 模拟浏览器：派发后第 ready 次轮询时回包，ready 为 0 时一直不回包
+type pageBrowser struct {
+	mu       sync.Mutex
+	ready    int
+	polls    int
+	dispatch []dootasktest.Params
+	results  map[string]map[string]any
+}
+
+func newPageBrowser(srv *dootasktest.Server, ready int, results map[string]map[string]any) *pageBrowser {
+	b := &pageBrowser{ready: ready, results: results}
+	srv.Handle("/api/assistant/operation/dispatch", func(r *dootasktest.Request) (any, error) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.dispatch = append(b.dispatch, r.Params)
+		b.polls = 0
+		return map[string]any{"requestId": fmt.Sprintf("req-%d", len(b.dispatch))}, nil
+	})
+	srv.Handle("/api/assistant/operation/result", func(r *dootasktest.Request) (any, error) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.polls++
+		if b.ready == 0 || b.polls < b.ready {
+			return map[string]any{"status": "pending"}, nil
+		}
+		action := b.dispatch[len(b.dispatch)-1].String("action")
+		return b.results[action], nil
+	})
+	return b
+}
+
+// last 返回最后一次派发的参数与当前轮询次数
+func (b *pageBrowser) last() (dootasktest.Params, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dispatch[len(b.dispatch)-1], b.polls
+}
+
+func TestPageOps(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	browser := newPageBrowser(srv, 3, map[string]map[string]any{
+		"get_page_context": {"status": "ready", "success": true, "result": map[string]any{
+			"url":      "/manage/project/1",
+			"title":    "项目",
+			"elements": []map[string]any{{"uid": "e1", "tag": "button", "text": "新建任务", "interactive": true}},
+		}},
+		"execute_element_action": {"status": "ready", "success": true, "result": map[string]any{"element_uid": "e1", "action": "click", "message": "已点击"}},
+		"execute_action":         {"status": "ready", "success": false, "error": "未知操作"},
+	})
+	pages := srv.Client().PageOps(7, dootask.WithPagePoll(time.Millisecond, 5*time.Millisecond, 2))
+	ctx := context.Background()
+
+	op, err := pages.GetPageContext(ctx, dootask.PageContextRequest{Query: "新建", NoElements: true})
+	if err != nil {
+		t.Fatalf("派发失败: %v", err)
+	}
+	if op.RequestID != "req-1" {
+		t.Errorf("请求ID不符: %s", op.RequestID)
+	}
+	page, err := op.Wait(ctx)
+	if err != nil {
+		t.Fatalf("获取页面上下文失败: %v", err)
+	}
+	if page.Title != "项目" || len(page.Elements) != 1 || page.Elements[0].UID != "e1" || !page.Elements[0].Interactive || len(page.Raw) == 0 {
+		t.Errorf("页面上下文不符: %+v", page)
+	}
+	params, polls := browser.last()
+	payload, _ := params["payload"].(map[string]any)
+	if params.Int("fd") != 7 || payload["query"] != "新建" || payload["include_elements"] != false || polls != 3 {
+		t.Errorf("派发参数不符（轮询 %d 次）: %v", polls, params)
+	}
+	// 完成后可重复取结果
+	if again, err := op.Wait(ctx); err != nil || again.Title != "项目" {
+		t.Errorf("重复等待结果不符: %+v %v", again, err)
+	}
+
+	elem, err := pages.ExecuteElementAction(ctx, dootask.ElementActionRequest{ElementUID: "e1", Action: "click"})
+	if err != nil {
+		t.Fatalf("派发元素动作失败: %v", err)
+	}
+	<-elem.Done()
+	if result, err := elem.Wait(ctx); err != nil || result.Message != "已点击" {
+		t.Errorf("元素动作结果不符: %+v %v", result, err)
+	}
+	if _, err := pages.ExecuteElementAction(ctx, dootask.ElementActionRequest{ElementUID: "e1"}); err == nil {
+		t.Errorf("缺少动作应失败")
+	}
+
+	action, err := pages.ExecuteAction(ctx, "open_task", map[string]any{"task_id": 1})
+	if err != nil {
+		t.Fatalf("派发业务操作失败: %v", err)
+	}
+	_, err = action.Wait(ctx)
+	var opErr *dootask.PageOpError
+	if !errors.As(err, &opErr) || opErr.Message != "未知操作" || opErr.RequestID != action.RequestID {
+		t.Errorf("期望 PageOpError，实际 %v", err)
+	}
+
+	// 派发失败时直接返回错误
+	srv.Handle("/api/assistant/operation/dispatch", func(r *dootasktest.Request) (any, error) {
+		return nil, dootasktest.Errorf("会话不在线")
+	})
+	if _, err := pages.ExecuteAction(ctx, "open_task", nil); err == nil || err.Error() != "会话不在线" {
+		t.Errorf("期望派发错误，实际 %v", err)
+	}
+}
+
+func TestPageOpsCancel(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	browser := newPageBrowser(srv, 0, nil)
+	client := srv.Client()
+	ctx := context.Background()
+
+	// 超时
+	pages := client.PageOps(1, dootask.WithPagePoll(time.Millisecond, time.Millisecond, 1), dootask.WithPageTimeout(30*time.Millisecond))
+	op, err := pages.GetPageContext(ctx, dootask.PageContextRequest{})
+	if err != nil {
+		t.Fatalf("派发失败: %v", err)
+	}
+	if _, err := op.Wait(ctx); !errors.Is(err, dootask.ErrPageTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望 ErrPageTimeout，实际 %v", err)
+	}
+
+	// Wait 的 ctx 结束不影响操作，Cancel 才停止轮询
+	pages = client.PageOps(1, dootask.WithPageTimeout(0))
+	op, err = pages.GetPageContext(ctx, dootask.PageContextRequest{})
+	if err != nil {
+		t.Fatalf("派发失败: %v", err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := op.Wait(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望等待超时，实际 %v", err)
+	}
+	select {
+	case <-op.Done():
+		t.Fatalf("等待超时不应结束操作")
+	default:
+	}
+	op.Cancel()
+	if _, err := op.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("期望 context.Canceled，实际 %v", err)
+	}
+
+	// 派发时的 ctx 取消同样结束轮询
+	dispatchCtx, cancelDispatch := context.WithCancel(ctx)
+	op, err = pages.GetPageContext(dispatchCtx, dootask.PageContextRequest{})
+	if err != nil {
+		t.Fatalf("派发失败: %v", err)
+	}
+	cancelDispatch()
+	if _, err := op.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("期望 context.Canceled，实际 %v", err)
+	}
+
+	// 轮询间隔按倍数退避：10、20、40、80、80 毫秒……200 毫秒内不超过 6 次
+	pages = client.PageOps(1, dootask.WithPagePoll(10*time.Millisecond, 80*time.Millisecond, 2), dootask.WithPageTimeout(200*time.Millisecond))
+	op, err = pages.GetPageContext(ctx, dootask.PageContextRequest{})
+	if err != nil {
+		t.Fatalf("派发失败: %v", err)
+	}
+	op.Wait(ctx)
+	if _, polls := browser.last(); polls < 2 || polls > 6 {
+		t.Errorf("退避后的轮询次数不符: %d", polls)
+	}
+
+	// 初始间隔 <= 0 时取默认 200 毫秒，不会连续轮询
+	pages = client.PageOps(1, dootask.WithPagePoll(0, 0, 2), dootask.WithPageTimeout(100*time.Millisecond))
+	op, err = pages.GetPageContext(ctx, dootask.PageContextRequest{})
+	if err != nil {
+		t.Fatalf("派发失败: %v", err)
+	}
+	op.Wait(ctx)
+	if _, polls := browser.last(); polls > 2 {
+		t.Errorf("初始间隔为 0 时轮询次数不符: %d", polls)
+	}
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ============================================================================
+// 自动分页相关测试
+// ============================================================================
+
+// pagedTaskServer 返回共 total 条任务、每页 perPage 条的任务列表接口
+func pagedTaskServer(total, perPage int, hits *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page <= 0 {
+			page = 1
+		}
+		from := (page - 1) * perPage
+		var data []string
+		for i := from; i < from+perPage && i < total; i++ {
+			data = append(data, fmt.Sprintf(`{"id":%d}`, i+1))
+		}
+		next := "null"
+		if from+perPage < total {
+			next = fmt.Sprintf(`"/api/project/task/lists?page=%d"`, page+1)
+		}
+		fmt.Fprintf(w, `{"ret":1,"msg":"","data":{"current_page":%d,"data":[%s],"next_page_url":%s,"per_page":"%d","to":%d,"total":%d}}`,
+			page, strings.Join(data, ","), next, perPage, from+len(data), total)
+	}))
+}
+
+func TestPaginate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("遍历全部页", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := pagedTaskServer(25, 10, &hits)
+		defer srv.Close()
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL))
+
+		var ids []int
+		for task, err := range client.AllTasks(ctx, dootask.GetTaskListRequest{PageSize: 10}) {
+			if err != nil {
+				t.Fatalf("遍历任务失败: %v", err)
+			}
+			ids = append(ids, task.ID)
+		}
+		if len(ids) != 25 || ids[0] != 1 || ids[24] != 25 {
+			t.Fatalf("遍历结果不符: %v", ids)
+		}
+		if n := hits.Load(); n != 3 {
+			t.Fatalf("期望请求 3 页，实际 %d 次", n)
+		}
+	})
+
+	t.Run("提前结束", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := pagedTaskServer(25, 10, &hits)
+		defer srv.Close()
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL))
+
+		count := 0
+		for _, err := range client.AllTasks(ctx, dootask.GetTaskListRequest{}) {
+			if err != nil {
+				t.Fatalf("遍历任务失败: %v", err)
+			}
+			count++
+			if count == 5 {
+				break
+			}
+		}
+		if n := hits.Load(); n != 1 {
+			t.Fatalf("期望只请求 1 页，实际 %d 次", n)
+		}
+	})
+
+	t.Run("预取与数量上限", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := pagedTaskServer(100, 10, &hits)
+		defer srv.Close()
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL))
+
+		count := 0
+		for _, err := range client.AllTasks(ctx, dootask.GetTaskListRequest{}, dootask.WithPrefetch(), dootask.WithMaxItems(15)) {
+			if err != nil {
+				t.Fatalf("遍历任务失败: %v", err)
+			}
+			count++
+		}
+		if count != 15 {
+			t.Fatalf("期望 15 条，实际 %d 条", count)
+		}
+		if n := hits.Load(); n != 2 {
+			t.Fatalf("期望请求 2 页，实际 %d 次", n)
+		}
+	})
+
+	t.Run("分页字段归一", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := pagedTaskServer(25, 10, &hits)
+		defer srv.Close()
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL))
+
+		page, err := client.GetTaskList(dootask.GetTaskListRequest{Page: 3})
+		if err != nil {
+			t.Fatalf("获取任务列表失败: %v", err)
+		}
+		if page.PerPage != 10 || page.To != 25 {
+			t.Fatalf("per_page/to 归一失败: %d/%d", page.PerPage, page.To)
+		}
+	})
+}
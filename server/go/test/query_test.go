@@ -0,0 +1,86 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ============================================================================
+// 查询参数编码相关测试
+// ============================================================================
+
+func TestQueryEncoding(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		w.Write([]byte(`{"ret":1,"msg":"","data":{}}`))
+	}))
+	defer srv.Close()
+	client := dootask.NewClient("token", dootask.WithServer(srv.URL))
+
+	query := func(t *testing.T, params any) string {
+		t.Helper()
+		queries = nil
+		if err := client.NewGetRequest("/api/test", params, nil); err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		return queries[0]
+	}
+
+	t.Run("map 按键排序且稳定", func(t *testing.T) {
+		params := map[string]any{"zeta": 1, "alpha": "a b", "mid": true, "empty": "", "none": nil, "ids": []int{3, 1}}
+		want := "alpha=a+b&ids[]=3&ids[]=1&mid=1&zeta=1"
+		for i := 0; i < 20; i++ {
+			if got := query(t, params); got != want {
+				t.Fatalf("第 %d 次编码不符:\n期望 %s\n实际 %s", i, want, got)
+			}
+		}
+	})
+
+	t.Run("嵌套结构与对象数组", func(t *testing.T) {
+		params := map[string]any{
+			"keys":  dootask.TaskListKeys{Status: "completed", Tag: "紧急"},
+			"rows":  []map[string]any{{"id": 1, "name": "x"}, {"id": 2}},
+			"extra": map[string]any{"a": map[string]any{"b": 1.5}},
+		}
+		want := "extra[a][b]=1.5&keys[status]=completed&keys[tag]=%E7%B4%A7%E6%80%A5&rows[0][id]=1&rows[0][name]=x&rows[1][id]=2"
+		if got := query(t, params); got != want {
+			t.Errorf("编码不符:\n期望 %s\n实际 %s", want, got)
+		}
+	})
+
+	t.Run("结构体按字段顺序与 omitempty", func(t *testing.T) {
+		type embedded struct {
+			Page int `json:"page"`
+		}
+		type request struct {
+			Name  string          `json:"name"`
+			Skip  string          `json:"-"`
+			Count int             `json:"count,omitempty"`
+			Zero  int             `json:"zero"`
+			Flex  dootask.FlexInt `json:"flex"`
+			Ptr   *int            `json:"ptr"`
+			Times []string        `json:"times,omitempty"`
+			embedded
+		}
+		got := query(t, request{Name: "n", Skip: "x", Flex: 7})
+		if want := "name=n&zero=0&flex=7&page=0"; got != want {
+			t.Errorf("编码不符:\n期望 %s\n实际 %s", want, got)
+		}
+	})
+
+	t.Run("类型化请求", func(t *testing.T) {
+		got := query(t, dootask.GetTaskListRequest{ProjectID: 1, Keys: dootask.TaskListKeys{Name: "周报"}})
+		if !strings.Contains(got, "keys[name]=%E5%91%A8%E6%8A%A5") || strings.Contains(got, "keys[status]") {
+			t.Errorf("筛选条件编码不符: %s", got)
+		}
+		got = query(t, map[string]any{"keys": dootask.ReportListKeys{Type: "weekly", CreatedAt: []string{"2024-01-01", "2024-01-31"}}})
+		if want := "keys[type]=weekly&keys[created_at][]=2024-01-01&keys[created_at][]=2024-01-31"; got != want {
+			t.Errorf("编码不符:\n期望 %s\n实际 %s", want, got)
+		}
+	})
+}
@@ -0,0 +1,268 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ============================================================================
+// 实时事件相关测试
+// ============================================================================
+
+// wsPeer 测试用 WebSocket 服务端连接
+type wsPeer struct {
+	conn net.Conn
+	br   *bufio.Reader
+	mu   sync.Mutex
+}
+
+// upgradeWS 完成服务端握手
+func upgradeWS(w http.ResponseWriter, r *http.Request) (*wsPeer, error) {
+	conn, brw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	brw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := brw.Flush(); err != nil {
+		return nil, err
+	}
+	return &wsPeer{conn: conn, br: brw.Reader}, nil
+}
+
+// send 发送文本帧（服务端帧不带掩码）
+func (p *wsPeer) send(text string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	frame := []byte{0x81}
+	switch n := len(text); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	default:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	}
+	_, err := p.conn.Write(append(frame, text...))
+	return err
+}
+
+// read 读取一个客户端帧并去掉掩码
+func (p *wsPeer) read() (byte, string, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(p.br, head[:]); err != nil {
+		return 0, "", err
+	}
+	n := int(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(p.br, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(p.br, ext[:])
+		n = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	var mask [4]byte
+	io.ReadFull(p.br, mask[:])
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(p.br, payload); err != nil {
+		return 0, "", err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return head[0] & 0x0F, string(payload), nil
+}
+
+func nextEvent(t *testing.T, rt *dootask.Realtime) dootask.RealtimeEvent {
+	t.Helper()
+	select {
+	case e, ok := <-rt.Events():
+		if !ok {
+			t.Fatalf("事件通道已关闭: %v", rt.Err())
+		}
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待事件超时")
+	}
+	return nil
+}
+
+func TestRealtimeEvents(t *testing.T) {
+	received := make(chan string, 16)
+	var gotToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws" {
+			http.NotFound(w, r)
+			return
+		}
+		gotToken = r.URL.Query().Get("token")
+		peer, err := upgradeWS(w, r)
+		if err != nil {
+			return
+		}
+		defer peer.conn.Close()
+		go func() {
+			for {
+				op, text, err := peer.read()
+				if err != nil || op == 0x8 {
+					return
+				}
+				received <- text
+			}
+		}()
+		peer.send(`{"type":"open","data":{"fd":42}}`)
+		peer.send(`{"type":"dialog","mode":"add","msgId":"m1","data":{"id":9,"dialog_id":3,"userid":5,"type":"text","msg":{"text":"hi"}}}`)
+		peer.send(`{"type":"dialog","mode":"delete","data":{"id":9,"dialog_id":3}}`)
+		peer.send(`{"type":"dialog","mode":"readed","data":{"dialog_id":"3","userid":5}}`)
+		peer.send(`{"type":"projectTask","action":"update","data":{"id":7,"project_id":2,"name":"写文档"}}`)
+		peer.send(`{"type":"line","data":{"userid":5,"online":true}}`)
+		peer.send(`{"type":"custom","action":"ping","data":{}}`)
+		time.Sleep(time.Second)
+	}))
+	defer server.Close()
+
+	client := dootask.NewClient("user-token", dootask.WithServer(server.URL))
+	rt, err := client.Connect(context.Background(), dootask.WithRealtimeHeartbeat(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	defer rt.Close()
+
+	if gotToken != "user-token" {
+		t.Errorf("连接应携带 token，实际 %q", gotToken)
+	}
+	if e, ok := nextEvent(t, rt).(*dootask.ConnectedEvent); !ok || e.FD != 42 || e.Reconnect {
+		t.Fatalf("首个事件应为 ConnectedEvent{FD:42}，实际 %#v", e)
+	}
+	if rt.FD() != 42 {
+		t.Errorf("FD 期望 42，实际 %d", rt.FD())
+	}
+
+	msg, ok := nextEvent(t, rt).(*dootask.MessageEvent)
+	if !ok || msg.Mode != "add" || msg.Message.ID != 9 || msg.Message.DialogID != 3 {
+		t.Fatalf("新消息事件不符: %#v", msg)
+	}
+	if text, _ := msg.Message.AsText(); text != "hi" {
+		t.Errorf("消息内容不符: %q", text)
+	}
+	if e, ok := nextEvent(t, rt).(*dootask.MessageWithdrawnEvent); !ok || e.MsgID != 9 || e.DialogID != 3 {
+		t.Fatalf("撤回事件不符: %#v", e)
+	}
+	if e, ok := nextEvent(t, rt).(*dootask.DialogUpdatedEvent); !ok || e.Mode != "readed" || e.DialogID != 3 {
+		t.Fatalf("对话更新事件不符: %#v", e)
+	}
+	if e, ok := nextEvent(t, rt).(*dootask.TaskChangedEvent); !ok || e.Action != "update" || e.Task.ID != 7 || e.Task.Name != "写文档" {
+		t.Fatalf("任务变更事件不符: %#v", e)
+	}
+	if e, ok := nextEvent(t, rt).(*dootask.UserOnlineEvent); !ok || e.UserID != 5 || !e.Online {
+		t.Fatalf("在线事件不符: %#v", e)
+	}
+	if e, ok := nextEvent(t, rt).(*dootask.RawEvent); !ok || e.EventType() != "custom" || e.Mode != "ping" {
+		t.Fatalf("未识别推送应为 RawEvent: %#v", e)
+	}
+
+	// 带 msgId 的推送应回执，且定期发送心跳
+	var gotReceipt, gotHeartbeat bool
+	deadline := time.After(2 * time.Second)
+	for !gotReceipt || !gotHeartbeat {
+		select {
+		case text := <-received:
+			var p map[string]string
+			json.Unmarshal([]byte(text), &p)
+			gotReceipt = gotReceipt || (p["type"] == "receipt" && p["msgId"] == "m1")
+			gotHeartbeat = gotHeartbeat || p["type"] == "heartbeat"
+		case <-deadline:
+			t.Fatalf("回执 %v，心跳 %v", gotReceipt, gotHeartbeat)
+		}
+	}
+}
+
+func TestRealtimeReconnect(t *testing.T) {
+	var mu sync.Mutex
+	conns := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conns++
+		n := conns
+		mu.Unlock()
+		if n == 2 {
+			// 第一次重连失败，应继续退避重试
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		peer, err := upgradeWS(w, r)
+		if err != nil {
+			return
+		}
+		defer peer.conn.Close()
+		fd := 1
+		if n > 1 {
+			fd = 2
+		}
+		peer.send(fmt.Sprintf(`{"type":"open","data":{"fd":%d}}`, fd))
+		if n == 1 {
+			return // 主动断开
+		}
+		peer.read()
+	}))
+	defer server.Close()
+
+	client := dootask.NewClient("user-token", dootask.WithServer(server.URL))
+	rt, err := client.Connect(context.Background(), dootask.WithRealtimeReconnect(10*time.Millisecond, 20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+
+	if e, ok := nextEvent(t, rt).(*dootask.ConnectedEvent); !ok || e.FD != 1 {
+		t.Fatalf("首次连接事件不符: %#v", e)
+	}
+	if _, ok := nextEvent(t, rt).(*dootask.DisconnectedEvent); !ok {
+		t.Fatal("应收到断开事件")
+	}
+	if e, ok := nextEvent(t, rt).(*dootask.ConnectedEvent); !ok || e.FD != 2 || !e.Reconnect {
+		t.Fatalf("重连事件不符: %#v", e)
+	}
+
+	rt.Close()
+	if _, ok := <-rt.Events(); ok {
+		t.Error("Close 后事件通道应关闭")
+	}
+	if !errors.Is(rt.Err(), context.Canceled) {
+		t.Errorf("Close 后 Err 应为 context.Canceled，实际 %v", rt.Err())
+	}
+}
+
+func TestRealtimeUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := dootask.NewClient("bad-token", dootask.WithServer(server.URL))
+	_, err := client.Connect(context.Background())
+	if !errors.Is(err, dootask.ErrUnauthorized) {
+		t.Fatalf("期望 ErrUnauthorized，实际 %v", err)
+	}
+	var apiErr *dootask.APIError
+	if !errors.As(err, &apiErr) || apiErr.Endpoint != "/ws" {
+		t.Errorf("错误应记录接口路径: %#v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/dootasktest"
+)
+
+// ============================================================================
+// 报告相关测试
+// ============================================================================
+
+func TestReportReceive(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	admin := srv.Client()
+
+	received, err := admin.ListReceivedReports(dootask.ReportListRequest{
+		Keys: dootask.ReportListKeys{Type: "weekly", Status: "unread"},
+	})
+	if err != nil {
+		t.Fatalf("获取收到的报告失败: %v", err)
+	}
+	if received.Total != 1 || received.Data[0].ID != dootasktest.ReportID || received.Data[0].Username != "成员" {
+		t.Errorf("收到的报告不符: %+v", received)
+	}
+	req := srv.AssertCalled(t, "/api/report/receive")
+	if req.Params.String("keys[type]") != "weekly" || req.Params.String("keys[status]") != "unread" {
+		t.Errorf("筛选条件不符: %v", req.Params)
+	}
+
+	if n, err := admin.GetUnreadReportCount(); err != nil || n != 1 {
+		t.Errorf("未读数量不符: %d %v", n, err)
+	}
+
+	report, err := admin.GetReport(dootasktest.ReportID)
+	if err != nil {
+		t.Fatalf("获取报告详情失败: %v", err)
+	}
+	if report.Content == "" || len(report.Receives) != 1 {
+		t.Errorf("报告详情不符: %+v", report)
+	}
+	if n, _ := admin.GetUnreadReportCount(); n != 0 {
+		t.Errorf("查看详情后应标记已读，未读数量 %d", n)
+	}
+
+	if err := admin.MarkReports([]int{dootasktest.ReportID}, "unread"); err != nil {
+		t.Fatalf("标记未读失败: %v", err)
+	}
+	if n, _ := admin.GetUnreadReportCount(); n != 1 {
+		t.Errorf("标记未读后未读数量 %d", n)
+	}
+
+	err = admin.SaveReportAnalysis(dootask.SaveReportAnalysisRequest{ID: dootasktest.ReportID, Text: "## 分析", Focus: []string{"风险"}})
+	if err != nil {
+		t.Fatalf("保存分析失败: %v", err)
+	}
+	if rep, _ := srv.Report(dootasktest.ReportID); rep.Analysis != "## 分析" {
+		t.Errorf("分析内容不符: %q", rep.Analysis)
+	}
+
+	err = admin.ShareReports(dootask.ShareReportsRequest{IDs: []int{dootasktest.ReportID}, DialogIDs: []int{dootasktest.GroupDialogID}, LeaveMessage: "请查阅"})
+	if err != nil {
+		t.Fatalf("分享报告失败: %v", err)
+	}
+	msgs := srv.Messages(dootasktest.GroupDialogID)
+	if last := msgs[len(msgs)-1].Msg.(map[string]any)["text"].(string); !strings.Contains(last, "成员的周报") {
+		t.Errorf("分享消息不符: %s", last)
+	}
+
+	guest := srv.ClientFor(dootasktest.GuestToken)
+	if _, err := guest.GetReport(dootasktest.ReportID); !errors.Is(err, dootask.ErrNotFound) {
+		t.Errorf("期望 ErrNotFound，实际 %v", err)
+	}
+}
+
+func TestReportSubmit(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	member := srv.ClientFor(dootasktest.MemberToken)
+
+	tmpl, err := member.GetReportTemplate(dootask.ReportTemplateRequest{Type: "daily"})
+	if err != nil {
+		t.Fatalf("生成模板失败: %v", err)
+	}
+	if tmpl.ID != 0 || tmpl.Sign == "" || !strings.Contains(tmpl.Title, "日报") {
+		t.Errorf("模板不符: %+v", tmpl)
+	}
+
+	submit := dootask.SubmitReportRequest{
+		Type:    "daily",
+		Title:   tmpl.Title,
+		Content: tmpl.Content,
+		Sign:    tmpl.Sign,
+		Receive: []int{dootasktest.AdminUserID},
+	}
+	report, err := member.SubmitReport(submit)
+	if err != nil {
+		t.Fatalf("提交报告失败: %v", err)
+	}
+	if report.ID == 0 || report.UserID != dootasktest.MemberUserID {
+		t.Errorf("报告信息不符: %+v", report)
+	}
+
+	// 同一周期重复提交覆盖原报告
+	submit.Title = "修改后的日报"
+	again, err := member.SubmitReport(submit)
+	if err != nil {
+		t.Fatalf("重复提交失败: %v", err)
+	}
+	if again.ID != report.ID || again.Title != "修改后的日报" {
+		t.Errorf("应覆盖原报告: %+v", again)
+	}
+	if tmpl, _ := member.GetReportTemplate(dootask.ReportTemplateRequest{Type: "daily"}); tmpl.ID != report.ID {
+		t.Errorf("模板应返回已提交的报告ID: %d", tmpl.ID)
+	}
+
+	mine, err := member.ListMyReports(dootask.ReportListRequest{Keys: dootask.ReportListKeys{Type: "daily"}})
+	if err != nil {
+		t.Fatalf("获取我的报告失败: %v", err)
+	}
+	if mine.Total != 1 || mine.Data[0].ID != report.ID {
+		t.Errorf("我的报告不符: %+v", mine)
+	}
+
+	if _, err := member.SubmitReport(dootask.SubmitReportRequest{Type: "daily", Title: "空报告"}); err == nil {
+		t.Errorf("缺少内容时应失败")
+	}
+}
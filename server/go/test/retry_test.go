@@ -0,0 +1,111 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ============================================================================
+// 重试相关测试
+// ============================================================================
+
+// flakyServer 前 failures 次请求返回 502，之后正常响应
+func flakyServer(failures int32, hits *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"ret":1,"msg":"","data":{"version":"1.0.0"}}`))
+	}))
+}
+
+func TestRetry(t *testing.T) {
+	t.Run("GET 重试后成功", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := flakyServer(2, &hits)
+		defer srv.Close()
+
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL), dootask.WithRetry(3, time.Millisecond))
+		if _, err := client.GetVersion(); err != nil {
+			t.Fatalf("期望重试后成功，实际: %v", err)
+		}
+		if n := hits.Load(); n != 3 {
+			t.Fatalf("期望请求 3 次，实际 %d 次", n)
+		}
+	})
+
+	t.Run("超过最大次数", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := flakyServer(5, &hits)
+		defer srv.Close()
+
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL), dootask.WithRetry(2, time.Millisecond))
+		if _, err := client.GetVersion(); err == nil {
+			t.Fatal("期望失败")
+		}
+		if n := hits.Load(); n != 2 {
+			t.Fatalf("期望请求 2 次，实际 %d 次", n)
+		}
+	})
+
+	t.Run("以 GET 发送的写操作不重试", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := flakyServer(1, &hits)
+		defer srv.Close()
+
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL), dootask.WithRetry(3, time.Millisecond))
+		if err := client.DeleteColumn(1); err == nil {
+			t.Fatal("期望失败")
+		}
+		if n := hits.Load(); n != 1 {
+			t.Fatalf("期望请求 1 次，实际 %d 次", n)
+		}
+
+		hits.Store(0)
+		if err := client.MarkReports([]int{1}, "read"); err == nil {
+			t.Fatal("期望失败")
+		}
+		if n := hits.Load(); n != 1 {
+			t.Fatalf("标记已读不应重试，实际请求 %d 次", n)
+		}
+	})
+
+	t.Run("POST 默认不重试", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := flakyServer(1, &hits)
+		defer srv.Close()
+
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL), dootask.WithRetry(3, time.Millisecond))
+		if err := client.SendMessage(dootask.SendMessageRequest{DialogID: 1, Text: "hi"}); err == nil {
+			t.Fatal("期望失败")
+		}
+		if n := hits.Load(); n != 1 {
+			t.Fatalf("期望请求 1 次，实际 %d 次", n)
+		}
+	})
+
+	t.Run("标记为幂等的 POST 重试", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := flakyServer(1, &hits)
+		defer srv.Close()
+
+		client := dootask.NewClient("token",
+			dootask.WithServer(srv.URL),
+			dootask.WithRetry(3, time.Millisecond),
+			dootask.WithIdempotentEndpoints("/api/dialog/msg/sendtext"),
+		)
+		if err := client.SendMessage(dootask.SendMessageRequest{DialogID: 1, Text: "hi"}); err != nil {
+			t.Fatalf("期望重试后成功，实际: %v", err)
+		}
+		if n := hits.Load(); n != 2 {
+			t.Fatalf("期望请求 2 次，实际 %d 次", n)
+		}
+	})
+}
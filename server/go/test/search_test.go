@@ -0,0 +1,199 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/dootasktest"
+)
+
+// ============================================================================
+// 统一搜索测试
+// ============================================================================
+
+func TestSearch(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	client := srv.Client()
+
+	result, err := client.Search(context.Background(), "默认")
+	if err != nil {
+		t.Fatalf("搜索失败: %v", err)
+	}
+	if len(result.Tasks) != 1 || result.Tasks[0].ID != dootasktest.TaskID || result.Tasks[0].Relevance <= 0 {
+		t.Errorf("任务结果不符: %+v", result.Tasks)
+	}
+	if len(result.Projects) != 1 || result.Projects[0].ID != dootasktest.ProjectID {
+		t.Errorf("项目结果不符: %+v", result.Projects)
+	}
+	if len(result.Files) != 0 || len(result.Contacts) != 0 || len(result.Messages) != 0 {
+		t.Errorf("其他类型应为空: %+v", result)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("不应有错误: %v", result.Errors)
+	}
+	if len(result.Hits) != 2 || result.Hits[0].Type != dootask.SearchTypeTask || result.Hits[1].Type != dootask.SearchTypeProject {
+		t.Errorf("合并结果不符: %+v", result.Hits)
+	}
+	for _, typ := range dootask.SearchTypes {
+		req := srv.AssertCalled(t, "/api/search/"+string(typ))
+		if req.Params.String("key") != "默认" || req.Params.Int("take") != 20 || req.Params.Int("page") != 1 {
+			t.Errorf("%s 请求参数不符: %v", typ, req.Params)
+		}
+	}
+
+	contacts, err := client.Search(context.Background(), "成员", dootask.SearchTypeContact)
+	if err != nil {
+		t.Fatalf("搜索联系人失败: %v", err)
+	}
+	if len(contacts.Contacts) != 1 || contacts.Hits[0].ID != dootasktest.MemberUserID || contacts.Hits[0].Title != "成员" {
+		t.Errorf("联系人结果不符: %+v", contacts.Hits)
+	}
+	srv.AssertCalledTimes(t, "/api/search/task", 1)
+
+	if _, err := client.Search(context.Background(), ""); err == nil {
+		t.Errorf("关键词为空时应失败")
+	}
+	if _, err := client.Search(context.Background(), "默认", "unknown"); err == nil {
+		t.Errorf("未知类型应失败")
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	srv.Handle("/api/search/task", func(r *dootasktest.Request) (any, error) {
+		return []map[string]any{
+			{"id": 1, "name": "任务一", "relevance": 10},
+			{"id": 2, "name": "任务二", "relevance": 5},
+		}, nil
+	})
+	srv.Handle("/api/search/project", func(r *dootasktest.Request) (any, error) {
+		return []map[string]any{
+			{"id": 3, "name": "项目一", "relevance": "0.8"},
+			{"id": 4, "name": "项目二", "relevance": "0.2"},
+		}, nil
+	})
+	srv.Handle("/api/search/message", func(r *dootasktest.Request) (any, error) {
+		return []map[string]any{
+			{"id": 5, "msg_id": 6, "content_preview": "消息一"},
+			{"id": 7, "msg_id": 8, "content_preview": "消息二"},
+		}, nil
+	})
+
+	result, err := srv.Client().Search(context.Background(), "一", dootask.SearchTypeTask, dootask.SearchTypeProject, dootask.SearchTypeMessage)
+	if err != nil {
+		t.Fatalf("搜索失败: %v", err)
+	}
+	// 各类型内相关度归一化，无相关度的消息按名次计分
+	want := []struct {
+		id    int
+		score float64
+	}{{1, 1}, {3, 1}, {6, 1}, {2, 0.5}, {8, 0.5}, {4, 0.25}}
+	if len(result.Hits) != len(want) {
+		t.Fatalf("合并结果数量 %d，期望 %d", len(result.Hits), len(want))
+	}
+	for i, w := range want {
+		if h := result.Hits[i]; h.ID != w.id || h.Score != w.score {
+			t.Errorf("第 %d 条为 #%d(%v)，期望 #%d(%v)", i, h.ID, h.Score, w.id, w.score)
+		}
+	}
+	if task, ok := result.Hits[0].Item.(*dootask.SearchTaskHit); !ok || task.Name != "任务一" {
+		t.Errorf("Item 应指向任务结果: %#v", result.Hits[0].Item)
+	}
+}
+
+func TestSearchPartialFailure(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	srv.Handle("/api/search/file", func(r *dootasktest.Request) (any, error) {
+		return nil, dootasktest.Errorf("文件搜索不可用")
+	})
+
+	result, err := srv.Client().Search(context.Background(), "默认")
+	if err != nil {
+		t.Fatalf("部分失败不应返回错误: %v", err)
+	}
+	if len(result.Errors) != 1 || result.Errors[dootask.SearchTypeFile] == nil {
+		t.Errorf("错误记录不符: %v", result.Errors)
+	}
+	if len(result.Tasks) != 1 {
+		t.Errorf("其他类型应照常返回: %+v", result.Tasks)
+	}
+
+	for _, typ := range dootask.SearchTypes {
+		srv.Handle("/api/search/"+string(typ), func(r *dootasktest.Request) (any, error) {
+			return nil, dootasktest.Errorf("搜索服务不可用")
+		})
+	}
+	_, err = srv.Client().Search(context.Background(), "默认")
+	var apiErr *dootask.APIError
+	if !errors.As(err, &apiErr) || apiErr.Msg != "搜索服务不可用" {
+		t.Errorf("全部失败时应返回错误，实际 %v", err)
+	}
+}
+
+func TestSearchConcurrency(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	var running, peak atomic.Int32
+	for _, typ := range dootask.SearchTypes {
+		srv.Handle("/api/search/"+string(typ), func(r *dootasktest.Request) (any, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return []any{}, nil
+		})
+	}
+
+	_, err := srv.Client().SearchWithOptions(context.Background(), dootask.SearchRequest{Key: "默认", Concurrency: 2})
+	if err != nil {
+		t.Fatalf("搜索失败: %v", err)
+	}
+	if p := peak.Load(); p < 1 || p > 2 {
+		t.Errorf("最大并发 %d，期望不超过 2", p)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := srv.Client().Search(ctx, "默认"); !errors.Is(err, context.Canceled) {
+		t.Errorf("期望 context.Canceled，实际 %v", err)
+	}
+}
+
+func TestSearchPagination(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	client := srv.Client()
+	for i := range 3 {
+		if err := client.SendMessage(dootask.SendMessageRequest{DialogID: dootasktest.GroupDialogID, Text: "分页消息"}); err != nil {
+			t.Fatalf("发送第 %d 条消息失败: %v", i, err)
+		}
+	}
+
+	req := dootask.SearchRequest{Key: "分页", Types: []dootask.SearchType{dootask.SearchTypeMessage}, Take: 2}
+	first, err := client.SearchWithOptions(context.Background(), req)
+	if err != nil {
+		t.Fatalf("搜索第一页失败: %v", err)
+	}
+	if len(first.Messages) != 2 || !first.HasMore[dootask.SearchTypeMessage] {
+		t.Errorf("第一页不符: %d %v", len(first.Messages), first.HasMore)
+	}
+
+	req.Page = 2
+	second, err := client.SearchWithOptions(context.Background(), req)
+	if err != nil {
+		t.Fatalf("搜索第二页失败: %v", err)
+	}
+	if second.Page != 2 || len(second.Messages) != 1 || second.HasMore[dootask.SearchTypeMessage] {
+		t.Errorf("第二页不符: %d %v", len(second.Messages), second.HasMore)
+	}
+	if second.Messages[0].MsgID == first.Messages[0].MsgID || second.Messages[0].MsgID == first.Messages[1].MsgID {
+		t.Errorf("第二页不应与第一页重复")
+	}
+}
@@ -0,0 +1,205 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/stream"
+)
+
+// ============================================================================
+// 流式消息相关测试
+// ============================================================================
+
+// streamAPI 模拟主程序：占位消息返回ID 77，记录写回与通知请求
+type streamAPI struct {
+	*httptest.Server
+	mu      sync.Mutex
+	sent    []map[string]any
+	notices []map[string]any
+}
+
+func newStreamAPI() *streamAPI {
+	a := &streamAPI{}
+	a.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		switch r.URL.Path {
+		case "/api/dialog/msg/sendtext":
+			a.sent = append(a.sent, body)
+			w.Write([]byte(`{"ret":1,"msg":"","data":{"id":77,"dialog_id":1}}`))
+		case "/api/dialog/msg/stream":
+			a.notices = append(a.notices, body)
+			w.Write([]byte(`{"ret":1,"msg":"","data":{}}`))
+		}
+	}))
+	return a
+}
+
+// sseEvent SSE 事件
+type sseEvent struct {
+	Event   string
+	Content string
+}
+
+// readSSE 读取 SSE 事件直到 done
+func readSSE(t *testing.T, url string) []sseEvent {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("订阅失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type 不符: %s", ct)
+	}
+
+	var events []sseEvent
+	var cur sseEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			cur.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			var data struct {
+				Content string `json:"content"`
+			}
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data)
+			cur.Content = data.Content
+		case line == "":
+			events = append(events, cur)
+			if cur.Event == "done" {
+				return events
+			}
+			cur = sseEvent{}
+		}
+	}
+	return events
+}
+
+func joinSSE(events []sseEvent) string {
+	var parts []string
+	for _, e := range events {
+		parts = append(parts, e.Event+":"+e.Content)
+	}
+	return strings.Join(parts, "|")
+}
+
+func TestStreamStartAndClose(t *testing.T) {
+	api := newStreamAPI()
+	defer api.Close()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	streams := stream.NewServer(stream.WithBaseURL(srv.URL + "/stream"))
+	mux.Handle("/stream/", streams)
+
+	client := dootask.NewClient("bot-token", dootask.WithServer(api.URL))
+	st, err := streams.Start(context.Background(), client, stream.Target{DialogID: 1, UserIDs: []int{2, 3}, ReplyID: 5})
+	if err != nil {
+		t.Fatalf("Start 失败: %v", err)
+	}
+	if st.MessageID() != 77 {
+		t.Errorf("占位消息ID期望 77，实际 %d", st.MessageID())
+	}
+	if len(api.notices) != 2 || api.notices[0]["stream_url"] != st.URL() || api.notices[1]["userid"] != float64(3) {
+		t.Fatalf("通知成员监听不符: %v", api.notices)
+	}
+
+	got := make(chan []sseEvent)
+	go func() { got <- readSSE(t, st.URL()) }()
+
+	st.Append("Hel")
+	time.Sleep(20 * time.Millisecond)
+	st.Append("lo")
+	time.Sleep(20 * time.Millisecond)
+	st.Replace("Hi")
+	time.Sleep(20 * time.Millisecond)
+	if err := st.Close(); err != nil {
+		t.Fatalf("Close 失败: %v", err)
+	}
+	if err := st.Append("late"); !errors.Is(err, stream.ErrClosed) {
+		t.Errorf("结束后写入应返回 ErrClosed，实际 %v", err)
+	}
+
+	events := <-got
+	if joinSSE(events) != "append:Hel|append:lo|replace:Hi|done:" {
+		t.Errorf("SSE 事件不符: %s", joinSSE(events))
+	}
+
+	// 写回占位消息
+	final := api.sent[len(api.sent)-1]
+	if final["update_id"] != float64(77) || final["text"] != "Hi" {
+		t.Errorf("写回消息不符: %v", final)
+	}
+
+	// 保留期内的新订阅者直接取到全文
+	if late := joinSSE(readSSE(t, st.URL())); late != "replace:Hi|done:" {
+		t.Errorf("迟到订阅者事件不符: %s", late)
+	}
+}
+
+func TestStreamSend(t *testing.T) {
+	api := newStreamAPI()
+	defer api.Close()
+	streams := stream.NewServer(stream.WithBaseURL("http://bot/stream"))
+
+	client := dootask.NewClient("bot-token", dootask.WithServer(api.URL))
+	chunks := func(yield func(string, error) bool) {
+		for _, s := range []string{"你", "好"} {
+			if !yield(s, nil) {
+				return
+			}
+		}
+		yield("", errors.New("模型中断"))
+	}
+	err := streams.Send(context.Background(), client, stream.Target{DialogID: 1, UserIDs: []int{2}}, chunks)
+	if err == nil || !strings.Contains(err.Error(), "模型中断") {
+		t.Fatalf("应返回片段错误，实际 %v", err)
+	}
+	final := api.sent[len(api.sent)-1]
+	if final["update_id"] != float64(77) || final["text"] != "你好\n\n> 模型中断" {
+		t.Errorf("写回消息不符: %v", final)
+	}
+}
+
+func TestStreamIdleExpiry(t *testing.T) {
+	streams := stream.NewServer(stream.WithIdleTimeout(30*time.Millisecond), stream.WithRetention(30*time.Millisecond))
+	srv := httptest.NewServer(streams)
+	defer srv.Close()
+
+	st := streams.Open()
+	st.Append("partial")
+
+	// 闲置超时后自动结束，订阅者收到 done
+	if got := joinSSE(readSSE(t, srv.URL+"/"+st.ID)); got != "append:partial|done:" {
+		t.Errorf("闲置结束事件不符: %s", got)
+	}
+
+	// 保留期过后移除
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := streams.Get(st.ID); ok {
+		t.Error("过期的流应被移除")
+	}
+	resp, err := http.Get(srv.URL + "/" + st.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("过期的流应返回 404，实际 %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/dootasktest"
+)
+
+// ============================================================================
+// 标签相关测试
+// ============================================================================
+
+func TestProjectTags(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	client := srv.Client()
+
+	tag, err := client.SaveProjectTag(dootask.SaveProjectTagRequest{ProjectID: dootasktest.ProjectID, Name: "紧急", Color: "#FF0000"})
+	if err != nil {
+		t.Fatalf("创建标签失败: %v", err)
+	}
+	if tag.ID == 0 || tag.Color != "#FF0000" {
+		t.Errorf("标签信息不符: %+v", tag)
+	}
+	if _, err := client.SaveProjectTag(dootask.SaveProjectTagRequest{ProjectID: dootasktest.ProjectID, Name: "紧急"}); err == nil {
+		t.Errorf("重名标签应失败")
+	}
+
+	tag, err = client.SaveProjectTag(dootask.SaveProjectTagRequest{ProjectID: dootasktest.ProjectID, ID: tag.ID, Name: "非常紧急", Color: "#CC0000"})
+	if err != nil {
+		t.Fatalf("修改标签失败: %v", err)
+	}
+	tags, err := client.ListProjectTags(dootasktest.ProjectID)
+	if err != nil {
+		t.Fatalf("获取标签失败: %v", err)
+	}
+	if len(tags) != 1 || tags[0].Name != "非常紧急" || tags[0].Color != "#CC0000" {
+		t.Errorf("标签列表不符: %+v", tags)
+	}
+
+	guest := srv.ClientFor(dootasktest.GuestToken)
+	if err := guest.DeleteProjectTag(tag.ID); !errors.Is(err, dootask.ErrNotFound) {
+		t.Errorf("非项目成员期望 ErrNotFound，实际 %v", err)
+	}
+	if err := client.DeleteProjectTag(tag.ID); err != nil {
+		t.Fatalf("删除标签失败: %v", err)
+	}
+	if tags, _ := client.ListProjectTags(dootasktest.ProjectID); len(tags) != 0 {
+		t.Errorf("删除后标签应为空: %+v", tags)
+	}
+}
+
+func TestTaskTags(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	client := srv.Client()
+
+	task, err := client.SetTaskTags(dootasktest.TaskID, dootask.TaskTagItem{Name: "前端", Color: "#00F"}, dootask.TaskTagItem{Name: "紧急"})
+	if err != nil {
+		t.Fatalf("设置标签失败: %v", err)
+	}
+	if names := tagNames(task); names != "前端,紧急" {
+		t.Errorf("设置后标签不符: %s", names)
+	}
+
+	// 添加时保留已有标签，同名标签只更新颜色
+	task, err = client.AddTaskTags(dootasktest.TaskID, dootask.TaskTagItem{Name: "后端"}, dootask.TaskTagItem{Name: "紧急", Color: "#F00"})
+	if err != nil {
+		t.Fatalf("添加标签失败: %v", err)
+	}
+	if names := tagNames(task); names != "前端,紧急,后端" {
+		t.Errorf("添加后标签不符: %s", names)
+	}
+	if task.TaskTag[0].Color != "#00F" || task.TaskTag[1].Color != "#F00" {
+		t.Errorf("标签颜色不符: %+v", task.TaskTag)
+	}
+
+	task, err = client.RemoveTaskTags(dootasktest.TaskID, "前端", "不存在")
+	if err != nil {
+		t.Fatalf("移除标签失败: %v", err)
+	}
+	if names := tagNames(task); names != "紧急,后端" {
+		t.Errorf("移除后标签不符: %s", names)
+	}
+
+	// 标签未变化时不发送更新请求
+	srv.ResetRequests()
+	if _, err := client.AddTaskTags(dootasktest.TaskID, dootask.TaskTagItem{Name: "后端"}); err != nil {
+		t.Fatalf("重复添加失败: %v", err)
+	}
+	srv.AssertNotCalled(t, "/api/project/task/update")
+
+	if task, err = client.SetTaskTags(dootasktest.TaskID); err != nil || len(task.TaskTag) != 0 {
+		t.Errorf("清空标签失败: %+v %v", task, err)
+	}
+}
+
+// tagNames 以逗号连接任务标签名称
+func tagNames(task *dootask.ProjectTask) string {
+	names := make([]string, 0, len(task.TaskTag))
+	for _, tag := range task.TaskTag {
+		names = append(names, tag.Name)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,93 @@
+package test
+
+import (
+	"slices"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/dootasktest"
+)
+
+// ============================================================================
+// 任务操作测试
+// ============================================================================
+
+func TestMoveTask(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	client := srv.Client()
+
+	// 只给列表时自动取任务当前项目
+	task, err := client.MoveTask(dootask.MoveTaskRequest{TaskID: dootasktest.TaskID, ColumnID: dootasktest.DoingColumnID})
+	if err != nil {
+		t.Fatalf("移动任务失败: %v", err)
+	}
+	if task.ColumnID != dootasktest.DoingColumnID || task.ColumnName != "进行中" {
+		t.Errorf("移动结果不符: %+v", task)
+	}
+	req := srv.AssertCalled(t, "/api/project/task/move")
+	if req.Params.Int("project_id") != dootasktest.ProjectID || req.Params.Has("owner") || req.Params.Has("completed") {
+		t.Errorf("移动请求参数不符: %v", req.Params)
+	}
+
+	task, err = client.MoveTask(dootask.MoveTaskRequest{
+		TaskID:    dootasktest.TaskID,
+		ProjectID: dootasktest.ProjectID,
+		ColumnID:  dootasktest.DoneColumnID,
+		Owner:     dootask.Set([]int{dootasktest.MemberUserID}),
+		Completed: dootask.Set(true),
+	})
+	if err != nil {
+		t.Fatalf("移动并完成任务失败: %v", err)
+	}
+	if task.CompleteAt == "" {
+		t.Errorf("应同时标记完成: %+v", task)
+	}
+	if stored, _ := srv.Task(dootasktest.TaskID); !slices.Equal(stored.Owners, []int{dootasktest.MemberUserID}) {
+		t.Errorf("负责人不符: %v", stored.Owners)
+	}
+
+	// 跨项目移动需要目标列表
+	project, err := client.CreateProject(dootask.CreateProjectRequest{Name: "目标项目", Columns: "收件箱"})
+	if err != nil {
+		t.Fatalf("创建项目失败: %v", err)
+	}
+	if _, err := client.MoveTask(dootask.MoveTaskRequest{TaskID: dootasktest.TaskID, ProjectID: project.ID}); err == nil {
+		t.Errorf("跨项目移动缺少列表时应失败")
+	}
+	columns, _ := client.GetColumnList(dootask.GetColumnListRequest{ProjectID: project.ID})
+	task, err = client.MoveTask(dootask.MoveTaskRequest{TaskID: dootasktest.TaskID, ProjectID: project.ID, ColumnID: columns.Data[0].ID})
+	if err != nil {
+		t.Fatalf("跨项目移动失败: %v", err)
+	}
+	if task.ProjectID != project.ID || task.ProjectName != "目标项目" {
+		t.Errorf("跨项目移动结果不符: %+v", task)
+	}
+}
+
+func TestCompleteTask(t *testing.T) {
+	srv := dootasktest.NewServer(t)
+	client := srv.Client()
+
+	task, err := client.CompleteTask(dootasktest.TaskID)
+	if err != nil {
+		t.Fatalf("完成任务失败: %v", err)
+	}
+	if task.CompleteAt == "" || task.Percent != 100 {
+		t.Errorf("任务应已完成: %+v", task)
+	}
+	req := srv.AssertCalled(t, "/api/project/task/update")
+	if _, ok := req.Params["complete_at"].(string); !ok || len(req.Params) != 2 {
+		t.Errorf("完成请求应只提交 task_id 与日期字符串 complete_at: %v", req.Params)
+	}
+
+	task, err = client.ReopenTask(dootasktest.TaskID)
+	if err != nil {
+		t.Fatalf("取消完成失败: %v", err)
+	}
+	if task.CompleteAt != "" {
+		t.Errorf("任务应未完成: %+v", task)
+	}
+	if req := srv.AssertCalled(t, "/api/project/task/update"); req.Params["complete_at"] != false {
+		t.Errorf("取消完成应提交 complete_at=false: %v", req.Params)
+	}
+}
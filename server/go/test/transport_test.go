@@ -0,0 +1,88 @@
+package test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// ============================================================================
+// 传输层与中间件相关测试
+// ============================================================================
+
+func TestTransportAndMiddleware(t *testing.T) {
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ret":1,"msg":"","data":{"version":"` + r.Header.Get("X-Trace") + `"}}`))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	t.Run("默认复用连接", func(t *testing.T) {
+		conns.Store(0)
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL))
+		for i := 0; i < 3; i++ {
+			if _, err := client.GetVersion(); err != nil {
+				t.Fatalf("获取版本信息失败: %v", err)
+			}
+		}
+		if n := conns.Load(); n != 1 {
+			t.Fatalf("期望复用 1 个连接，实际新建 %d 个", n)
+		}
+	})
+
+	t.Run("中间件顺序与请求改写", func(t *testing.T) {
+		var order []string
+		mw := func(name string) dootask.Middleware {
+			return func(next dootask.RoundTripFunc) dootask.RoundTripFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					order = append(order, name+">")
+					req.Header.Set("X-Trace", req.Header.Get("X-Trace")+name)
+					resp, err := next(req)
+					order = append(order, "<"+name)
+					return resp, err
+				}
+			}
+		}
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL), dootask.WithMiddleware(mw("a"), mw("b")))
+		v, err := client.GetVersion()
+		if err != nil {
+			t.Fatalf("获取版本信息失败: %v", err)
+		}
+		if v.Version != "ab" {
+			t.Fatalf("请求头注入不符，实际: %q", v.Version)
+		}
+		if got := strings.Join(order, " "); got != "a> b> <b <a" {
+			t.Fatalf("中间件顺序不符，实际: %s", got)
+		}
+	})
+
+	t.Run("自定义传输层", func(t *testing.T) {
+		var called atomic.Bool
+		rt := dootask.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			called.Store(true)
+			return http.DefaultTransport.RoundTrip(req)
+		})
+		hc := &http.Client{}
+		client := dootask.NewClient("token", dootask.WithServer(srv.URL), dootask.WithHTTPClient(hc), dootask.WithTransport(rt))
+		if _, err := client.GetVersion(); err != nil {
+			t.Fatalf("获取版本信息失败: %v", err)
+		}
+		if !called.Load() {
+			t.Fatal("自定义传输层未被调用")
+		}
+		if hc.Transport != nil {
+			t.Fatal("不应修改传入的 http.Client")
+		}
+	})
+}
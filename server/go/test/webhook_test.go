@@ -0,0 +1,105 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/webhook"
+)
+
+// ============================================================================
+// Webhook 相关测试
+// ============================================================================
+
+func TestWebhookHandler(t *testing.T) {
+	// 模拟主程序：记录机器人回复
+	var sent []map[string]any
+	var sentToken string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/dialog/msg/sendtext":
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			sent = append(sent, body)
+			sentToken = r.Header.Get("Token")
+			w.Write([]byte(`{"ret":1,"msg":"","data":{}}`))
+		case "/api/dialog/msg/webhookmsg2ai":
+			w.Write([]byte(`{"ret":1,"msg":"","data":{"msg":"AI: hello"}}`))
+		}
+	}))
+	defer api.Close()
+
+	var got *webhook.Event
+	handler := webhook.NewHandler(func(ctx context.Context, e *webhook.Event) error {
+		got = e
+		return e.Reply("收到：" + e.Message.Text)
+	}, webhook.WithClientOptions(dootask.WithServer(api.URL)), webhook.WithAIConversion())
+	hook := httptest.NewServer(handler)
+	defer hook.Close()
+
+	t.Run("表单推送的消息", func(t *testing.T) {
+		form := url.Values{
+			"text":        {"hello"},
+			"token":       {"bot-token"},
+			"dialog_id":   {"12"},
+			"dialog_type": {"group"},
+			"msg_id":      {"345"},
+			"msg_uid":     {"6"},
+			"mention":     {"1"},
+			"bot_uid":     {"7"},
+			"extras":      {`{"foo":"bar"}`},
+		}
+		resp, err := http.PostForm(hook.URL, form)
+		if err != nil {
+			t.Fatalf("推送失败: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("期望 200，实际 %d", resp.StatusCode)
+		}
+
+		if got.Type != webhook.EventMessage || got.Sender.UserID != 6 || !got.Dialog.IsGroup() || !got.Message.Mention || got.Extras["foo"] != "bar" {
+			t.Fatalf("事件解析不符: %+v", got)
+		}
+		if got.Message.AIText != "AI: hello" {
+			t.Fatalf("AI 转换结果不符: %q", got.Message.AIText)
+		}
+		if len(sent) != 1 || sent[0]["dialog_id"] != float64(12) || sent[0]["reply_id"] != float64(345) || sent[0]["text"] != "收到：hello" {
+			t.Fatalf("回复内容不符: %v", sent)
+		}
+		if sentToken != "bot-token" {
+			t.Fatalf("回复应使用机器人 token，实际: %q", sentToken)
+		}
+	})
+
+	t.Run("JSON 推送的命令", func(t *testing.T) {
+		body := `{"text":"/deploy prod now","token":"bot-token","dialog_id":12,"msg_id":346,"msg_uid":6}`
+		resp, err := http.Post(hook.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("推送失败: %v", err)
+		}
+		resp.Body.Close()
+		if got.Type != webhook.EventCommand || got.Command != "/deploy" || strings.Join(got.Args, ",") != "prod,now" {
+			t.Fatalf("命令解析不符: %+v", got)
+		}
+	})
+
+	t.Run("无效推送", func(t *testing.T) {
+		resp, err := http.Post(hook.URL, "application/json", strings.NewReader(`{}`))
+		if err != nil {
+			t.Fatalf("推送失败: %v", err)
+		}
+		msg, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("期望 400，实际 %d: %s", resp.StatusCode, msg)
+		}
+	})
+}
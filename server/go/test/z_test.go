@@ -7,18 +7,16 @@ import (
 	"time"
 
 	dootask "github.com/dootask/tools/server/go"
+	"github.com/dootask/tools/server/go/dootasktest"
 )
 
-// 测试配置常量
+// 测试配置常量（对应 dootasktest 默认种子数据）
 const (
-	testToken  = "YIG8ANC8q2ROQF91r8Pe6-53rIG3oCxcqQN-mMdZpQKe7mKwNqIHenDNqbDDdyQIdo9w2KdveEpF1NaH-5Nfmv0dBr9TkjJ7KFMkfEUL11wOjyId0nuoSJaAliRz8d5z"
-	testServer = "http://127.0.0.1:2222"
-
 	// 测试用的用户ID和对话ID
-	testUserID    = 3
-	testUserID2   = 33
-	testDialogID  = 2367
-	testDialogID2 = 2889
+	testUserID    = dootasktest.GuestUserID
+	testUserID2   = dootasktest.MemberUserID
+	testDialogID  = dootasktest.DirectDialogID
+	testDialogID2 = dootasktest.GroupDialogID
 
 	// 时间格式常量
 	timeFormat = "2006-01-02 15:04:05"
@@ -33,9 +31,10 @@ func formatJSON(v interface{}) string {
 	return string(jsonBytes)
 }
 
-// setupTestClient 创建测试客户端
-func setupTestClient() *dootask.Client {
-	return dootask.NewClient(testToken, dootask.WithServer(testServer), dootask.WithTimeout(30*time.Second))
+// setupTestClient 启动模拟服务并创建管理员身份的测试客户端
+func setupTestClient(t *testing.T) (*dootask.Client, *dootasktest.Server) {
+	srv := dootasktest.NewServer(t)
+	return srv.Client(dootask.WithTimeout(30 * time.Second)), srv
 }
 
 // ============================================================================
@@ -43,7 +42,7 @@ func setupTestClient() *dootask.Client {
 // ============================================================================
 
 func TestUserAPI(t *testing.T) {
-	client := setupTestClient()
+	client, srv := setupTestClient(t)
 
 	t.Run("获取用户信息", func(t *testing.T) {
 		user, err := client.GetUserInfo()
@@ -54,6 +53,9 @@ func TestUserAPI(t *testing.T) {
 		if user == nil {
 			t.Fatal("用户信息为空")
 		}
+		if user.UserID != dootasktest.AdminUserID {
+			t.Errorf("用户ID期望 %d，实际 %d", dootasktest.AdminUserID, user.UserID)
+		}
 
 		t.Logf("用户信息:\n%s", formatJSON(user))
 	})
@@ -78,9 +80,10 @@ func TestUserAPI(t *testing.T) {
 			t.Fatalf("获取多个用户基础信息失败: %v", err)
 		}
 
-		if len(userBasics) == 0 {
-			t.Fatal("用户基础信息列表为空")
+		if len(userBasics) != len(userIDs) {
+			t.Fatalf("用户基础信息数量期望 %d，实际 %d", len(userIDs), len(userBasics))
 		}
+		srv.AssertCalledTimes(t, "/api/users/basic", 2)
 
 		t.Logf("多个用户基础信息:\n%s", formatJSON(userBasics))
 	})
@@ -91,7 +94,7 @@ func TestUserAPI(t *testing.T) {
 // ============================================================================
 
 func TestMessageAPI(t *testing.T) {
-	client := setupTestClient()
+	client, srv := setupTestClient(t)
 
 	t.Run("发送消息", func(t *testing.T) {
 		req := dootask.SendMessageRequest{
@@ -104,6 +107,11 @@ func TestMessageAPI(t *testing.T) {
 			t.Fatalf("发送消息失败: %v", err)
 		}
 
+		msgs := srv.Messages(testDialogID)
+		if text, _ := msgs[len(msgs)-1].AsText(); text != req.Text {
+			t.Errorf("对话最新消息不符: %s", text)
+		}
+
 		t.Log("消息发送成功")
 	})
 
@@ -118,6 +126,8 @@ func TestMessageAPI(t *testing.T) {
 			t.Fatalf("发送消息到用户失败: %v", err)
 		}
 
+		srv.AssertCalled(t, "/api/dialog/open/user")
+
 		t.Log("消息发送到用户成功")
 	})
 
@@ -132,6 +142,10 @@ func TestMessageAPI(t *testing.T) {
 			t.Fatalf("发送机器人消息失败: %v", err)
 		}
 
+		if r := srv.AssertCalled(t, "/api/dialog/msg/sendbot"); r.Params.Int("userid") != testUserID {
+			t.Errorf("机器人消息接收者不符: %v", r.Params)
+		}
+
 		t.Log("机器人消息发送成功")
 	})
 
@@ -146,6 +160,8 @@ func TestMessageAPI(t *testing.T) {
 			t.Fatalf("发送匿名消息失败: %v", err)
 		}
 
+		srv.AssertCalled(t, "/api/dialog/msg/sendanon")
+
 		t.Log("匿名消息发送成功")
 	})
 }
@@ -155,7 +171,7 @@ func TestMessageAPI(t *testing.T) {
 // ============================================================================
 
 func TestDialogAPI(t *testing.T) {
-	client := setupTestClient()
+	client, srv := setupTestClient(t)
 
 	t.Run("获取对话列表", func(t *testing.T) {
 		req := dootask.TimeRangeRequest{}
@@ -167,6 +183,10 @@ func TestDialogAPI(t *testing.T) {
 		if dialogs == nil {
 			t.Fatal("对话列表为空")
 		}
+		if len(dialogs.Data) != 3 {
+			t.Errorf("对话数量期望 3，实际 %d", len(dialogs.Data))
+		}
+		srv.AssertCalled(t, "/api/dialog/lists")
 
 		t.Logf("对话列表:\n%s", formatJSON(dialogs))
 	})
@@ -192,8 +212,8 @@ func TestDialogAPI(t *testing.T) {
 			t.Fatalf("获取对话成员失败: %v", err)
 		}
 
-		if dialogMembers == nil {
-			t.Fatal("对话成员信息为空")
+		if len(dialogMembers) != 3 {
+			t.Fatalf("对话成员数量期望 3，实际 %d", len(dialogMembers))
 		}
 
 		t.Logf("对话成员:\n%s", formatJSON(dialogMembers))
@@ -205,7 +225,7 @@ func TestDialogAPI(t *testing.T) {
 // ============================================================================
 
 func TestGroupAPI(t *testing.T) {
-	client := setupTestClient()
+	client, srv := setupTestClient(t)
 
 	t.Run("创建群组", func(t *testing.T) {
 		req := dootask.CreateGroupRequest{
@@ -223,7 +243,7 @@ func TestGroupAPI(t *testing.T) {
 
 	t.Run("修改群组", func(t *testing.T) {
 		req := dootask.EditGroupRequest{
-			DialogID: 27211,
+			DialogID: testDialogID2,
 			ChatName: "测试修改群组" + time.Now().Format(timeFormat),
 		}
 
@@ -232,13 +252,17 @@ func TestGroupAPI(t *testing.T) {
 			t.Fatalf("修改群组失败: %v", err)
 		}
 
+		if d, _ := srv.Dialog(testDialogID2); d.Name != req.ChatName {
+			t.Errorf("群组名称未修改: %s", d.Name)
+		}
+
 		t.Log("群组修改成功")
 	})
 
 	t.Run("添加群组成员", func(t *testing.T) {
 		req := dootask.AddGroupUserRequest{
-			DialogID: 27211,
-			UserIDs:  []int{34},
+			DialogID: testDialogID2,
+			UserIDs:  []int{dootasktest.BotUserID},
 		}
 
 		err := client.AddGroupUser(req)
@@ -251,8 +275,8 @@ func TestGroupAPI(t *testing.T) {
 
 	t.Run("移除群组成员", func(t *testing.T) {
 		req := dootask.RemoveGroupUserRequest{
-			DialogID: 27211,
-			UserIDs:  []int{34},
+			DialogID: testDialogID2,
+			UserIDs:  []int{dootasktest.BotUserID},
 		}
 
 		err := client.RemoveGroupUser(req)
@@ -265,8 +289,8 @@ func TestGroupAPI(t *testing.T) {
 
 	t.Run("转让群组", func(t *testing.T) {
 		req := dootask.TransferGroupRequest{
-			DialogID: 27211,
-			UserID:   33,
+			DialogID: testDialogID2,
+			UserID:   testUserID2,
 		}
 
 		err := client.TransferGroup(req)
@@ -274,6 +298,10 @@ func TestGroupAPI(t *testing.T) {
 			t.Fatalf("转让群组失败: %v", err)
 		}
 
+		if d, _ := srv.Dialog(testDialogID2); d.OwnerID != testUserID2 {
+			t.Errorf("群主期望 %d，实际 %d", testUserID2, d.OwnerID)
+		}
+
 		t.Log("群组转让成功")
 	})
 }
@@ -283,7 +311,7 @@ func TestGroupAPI(t *testing.T) {
 // ============================================================================
 
 func TestProjectAPI(t *testing.T) {
-	client := setupTestClient()
+	client, srv := setupTestClient(t)
 
 	t.Run("项目管理完整流程", func(t *testing.T) {
 		// 1. 获取项目列表
@@ -343,7 +371,7 @@ func TestProjectAPI(t *testing.T) {
 		updateParams := dootask.UpdateProjectRequest{
 			ProjectID: projectID,
 			Name:      "更新后的测试项目",
-			Desc:      "这是一个更新后的测试项目描述",
+			Desc:      dootask.Set("这是一个更新后的测试项目描述"),
 		}
 
 		project, err = client.UpdateProject(updateParams)
@@ -387,8 +415,8 @@ func TestProjectAPI(t *testing.T) {
 		t.Log("--- 测试更新任务列表 ---")
 		updateColumnParams := dootask.UpdateColumnRequest{
 			ColumnID: columnID,
-			Name:     "更新后的测试列表",
-			Color:    "#FF0000",
+			Name:     dootask.Set("更新后的测试列表"),
+			Color:    dootask.Set("#FF0000"),
 		}
 
 		column, err = client.UpdateColumn(updateColumnParams)
@@ -490,9 +518,9 @@ func TestProjectAPI(t *testing.T) {
 		t.Log("--- 测试更新任务 ---")
 		updateTaskParams := dootask.UpdateTaskRequest{
 			TaskID:  taskID,
-			Name:    "更新后的测试任务",
-			Content: "这是更新后的任务内容",
-			Color:   "#00FF00",
+			Name:    dootask.Set("更新后的测试任务"),
+			Content: dootask.Set("这是更新后的任务内容"),
+			Color:   dootask.Set("#00FF00"),
 		}
 
 		task, err = client.UpdateTask(updateTaskParams)
@@ -531,6 +559,10 @@ func TestProjectAPI(t *testing.T) {
 			t.Fatalf("删除项目失败: %v", err)
 		}
 
+		if _, ok := srv.Project(projectID); ok {
+			t.Errorf("项目 %d 应已删除", projectID)
+		}
+
 		t.Logf("✓ 项目删除成功 (ID: %d)", projectID)
 
 		t.Log("=== 项目管理测试完成 ===")
@@ -542,7 +574,7 @@ func TestProjectAPI(t *testing.T) {
 // ============================================================================
 
 func TestBasicProjectAPI(t *testing.T) {
-	client := setupTestClient()
+	client, _ := setupTestClient(t)
 
 	t.Run("获取项目列表", func(t *testing.T) {
 		req := dootask.GetProjectListRequest{}
@@ -551,6 +583,10 @@ func TestBasicProjectAPI(t *testing.T) {
 			t.Fatalf("获取项目列表失败: %v", err)
 		}
 
+		if projects.Total != 1 {
+			t.Errorf("项目总数期望 1，实际 %d", projects.Total)
+		}
+
 		t.Logf("项目列表: %s", formatJSON(projects))
 	})
 }
@@ -560,11 +596,11 @@ func TestBasicProjectAPI(t *testing.T) {
 // ============================================================================
 
 func TestMessageListAPI(t *testing.T) {
-	client := setupTestClient()
+	client, _ := setupTestClient(t)
 
 	t.Run("获取消息列表", func(t *testing.T) {
 		req := dootask.GetMessageListRequest{
-			DialogID: 5176,
+			DialogID: testDialogID,
 			Take:     10,
 		}
 		messages, err := client.GetMessageList(req)
@@ -572,6 +608,10 @@ func TestMessageListAPI(t *testing.T) {
 			t.Fatalf("获取消息列表失败: %v", err)
 		}
 
+		if len(messages.List) != 1 {
+			t.Errorf("消息数量期望 1，实际 %d", len(messages.List))
+		}
+
 		t.Logf("消息列表: %s", formatJSON(messages))
 	})
 }
@@ -581,13 +621,16 @@ func TestMessageListAPI(t *testing.T) {
 // ============================================================================
 
 func TestGetBotList(t *testing.T) {
-	client := setupTestClient()
+	client, _ := setupTestClient(t)
 
 	t.Run("获取机器人列表", func(t *testing.T) {
 		botList, err := client.GetBotList()
 		if err != nil {
 			t.Fatalf("获取机器人列表失败: %v", err)
 		}
+		if len(botList.List) != 1 || botList.List[0].ID != dootasktest.BotUserID {
+			t.Errorf("机器人列表不符: %v", botList.List)
+		}
 		t.Logf("机器人列表: %s", formatJSON(botList.List))
 	})
 }
@@ -597,25 +640,31 @@ func TestGetBotList(t *testing.T) {
 // ============================================================================
 
 func TestGetSystemSettings(t *testing.T) {
-	client := setupTestClient()
+	client, _ := setupTestClient(t)
 
 	t.Run("获取系统设置", func(t *testing.T) {
 		settings, err := client.GetSystemSettings()
 		if err != nil {
 			t.Fatalf("获取系统设置失败: %v", err)
 		}
+		if settings.Reg == nil || *settings.Reg != "open" {
+			t.Errorf("注册设置不符: %v", settings.Reg)
+		}
 		t.Logf("系统设置: %s", formatJSON(settings))
 	})
 }
 
 func TestGetVersion(t *testing.T) {
-	client := setupTestClient()
+	client, _ := setupTestClient(t)
 
 	t.Run("获取版本信息", func(t *testing.T) {
 		version, err := client.GetVersion()
 		if err != nil {
 			t.Fatalf("获取版本信息失败: %v", err)
 		}
+		if version.Version != "1.0.0" {
+			t.Errorf("版本期望 1.0.0，实际 %s", version.Version)
+		}
 		t.Logf("版本信息: %s", formatJSON(version))
 	})
 }
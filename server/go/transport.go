@@ -0,0 +1,146 @@
+package dootask
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ------------------------------------------------------------------------------------------
+// 传输层与中间件
+// ------------------------------------------------------------------------------------------
+
+// RoundTripFunc 发送一个 HTTP 请求并返回响应，同时实现 http.RoundTripper
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip 实现 http.RoundTripper 接口
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware 请求中间件，包装下一个 RoundTripFunc，可用于鉴权刷新、日志、指标、
+// 注入请求头或改写请求。中间件可以修改 req，但不应关闭 next 返回的响应体
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithHTTPClient 使用自定义 http.Client 发送请求（其 Timeout 优先于 WithTimeout）
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTransport 使用自定义 http.RoundTripper 发送请求，缺省为 http.DefaultTransport（复用连接）。
+// 与 WithHTTPClient 同时使用时替换其 Transport（不修改传入的 http.Client）
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+// WithMiddleware 追加请求中间件，先添加的位于外层（先看到请求、后看到响应）
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// initTransport 在选项应用完成后构建共享的 http.Client 与中间件链
+func (c *Client) initTransport() {
+	switch {
+	case c.httpClient == nil:
+		c.httpClient = &http.Client{Timeout: c.timeout, Transport: c.transport}
+	case c.transport != nil:
+		hc := *c.httpClient
+		hc.Transport = c.transport
+		c.httpClient = &hc
+	}
+
+	next := RoundTripFunc(c.httpClient.Do)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		next = c.middlewares[i](next)
+	}
+	c.roundTrip = next
+}
+
+// HTTPClient 返回客户端使用的 http.Client（不经过中间件）
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// Server 返回客户端请求的服务器地址
+func (c *Client) Server() string {
+	return c.server
+}
+
+// Do 以客户端的鉴权与版本请求头，经中间件、传输层与重试策略发送 req 并返回原始响应，
+// 调用方负责关闭响应体。请求受 req.Context() 控制（而非 WithContext 绑定的 ctx）；
+// req 已设置的同名请求头保留不覆盖；幂等性按去掉服务器路径后的 req.URL.Path 判断。用于响应格式不同于 {ret,msg,data} 的接口（如 appstore 包），
+// 响应体不做解析。设置了 TokenSource 且 req 未自带 Token 时，响应 HTTP 401 会刷新 token，
+// 请求体可重放（无请求体或设置了 GetBody）时以新 token 重试一次，最终仍返回 401 响应
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if req.URL == nil || !req.URL.IsAbs() {
+		return nil, errors.New("dootask: Do requires an absolute request URL")
+	}
+	ctx := req.Context()
+	preset := req.Header.Clone()
+	api := req.URL.Path
+	if u, err := url.Parse(c.server); err == nil {
+		api = strings.TrimPrefix(api, strings.TrimRight(u.Path, "/"))
+	}
+
+	// send 以 token 发送一次请求，重试时克隆 req 并重建请求体
+	var resp *http.Response
+	attempt := 0
+	send := func(token string) error {
+		r := req
+		if attempt++; attempt > 1 {
+			resp.Body.Close()
+			r = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return err
+				}
+				r.Body = body
+			}
+		}
+		c.setHeaders(r, token)
+		for key, values := range preset {
+			r.Header[key] = values
+		}
+		var err error
+		if resp, err = c.do(ctx, r, api); err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			return errUnauthorizedResponse
+		}
+		return nil
+	}
+
+	if preset.Get("Token") != "" {
+		err := send("")
+		if err == errUnauthorizedResponse {
+			err = nil
+		}
+		return resp, err
+	}
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	_, err := c.authorized(ctx, replayable, send)
+	if errors.Is(err, errUnauthorizedResponse) && resp != nil && resp.StatusCode == http.StatusUnauthorized {
+		// 401 响应原样交给调用方
+		return resp, nil
+	}
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// errUnauthorizedResponse 标记 Do 收到 HTTP 401，供 authorized 识别为 token 失效
+var errUnauthorizedResponse = fmt.Errorf("unexpected status %d: %w", http.StatusUnauthorized, ErrUnauthorized)
@@ -1,6 +1,15 @@
 package dootask
 
-import "time"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+)
 
 // ------------------------------------------------------------------------------------------
 // 基础结构定义
@@ -8,12 +17,25 @@ import "time"
 
 // Client DooTask客户端类
 type Client struct {
+	ctx       context.Context
 	token     string
 	server    string
 	version   string
-	cache     map[string]UserCache
+	cache     Cache
 	cacheTime time.Duration
+	flight    *flightGroup // 合并并发的用户信息请求
 	timeout   time.Duration
+
+	httpClient  *http.Client      // 共享的 http.Client，复用连接
+	transport   http.RoundTripper // 自定义传输层
+	middlewares []Middleware      // 请求中间件
+	roundTrip   RoundTripFunc     // 包装了中间件的最终发送函数
+
+	retryMax     int             // 最大发送次数（含首次），<=1 表示不重试
+	retryBackoff time.Duration   // 重试退避基数
+	idempotency  map[string]bool // 自定义接口幂等性分类
+
+	tokens *tokenState // TokenSource 维护的 token，nil 表示使用固定 token
 }
 
 // ClientOption 客户端选项
@@ -32,13 +54,49 @@ type ResponsePaginate[T any] struct {
 	Data        []T     `json:"data"`
 	NextPageUrl *string `json:"next_page_url"`
 	Path        string  `json:"path"`
-	PerPage     any     `json:"per_page"` // 可能是 int 或 string
+	PerPage     FlexInt `json:"per_page"` // 接口可能返回 int 或 string
 	PrevPageUrl *string `json:"prev_page_url"`
-	To          any     `json:"to"` // 可能是 int 或 string
+	To          FlexInt `json:"to"` // 接口可能返回 int、string 或 null
 	Total       int     `json:"total"`
 }
 
-// UserCache 用户缓存
+// FlexInt 兼容 JSON 数字、数字字符串与 null 的整数
+type FlexInt int
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口
+func (n *FlexInt) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		*n = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeOf(*n)}
+	}
+	*n = FlexInt(f)
+	return nil
+}
+
+// FlexFloat 兼容 JSON 数字、数字字符串与 null 的浮点数
+type FlexFloat float64
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口
+func (n *FlexFloat) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		*n = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeOf(*n)}
+	}
+	*n = FlexFloat(f)
+	return nil
+}
+
+// UserCache 用户缓存条目
 type UserCache struct {
 	User      UserInfo
 	ExpiresAt time.Time
@@ -116,11 +174,16 @@ type CreateBotRequest struct {
 
 // EditBotRequest 编辑机器人请求
 type EditBotRequest struct {
-	ID         int    `json:"id"`          // 必填：机器人ID
-	Name       string `json:"name"`        // 可选：机器人名称
-	Avatar     string `json:"avatar"`      // 可选：机器人头像
-	ClearDay   int    `json:"clear_day"`   // 可选：清理天数
-	WebhookURL string `json:"webhook_url"` // 可选：Webhook地址
+	ID         int              `json:"id"`          // 必填：机器人ID
+	Name       Optional[string] `json:"name"`        // 可选：机器人名称
+	Avatar     Optional[string] `json:"avatar"`      // 可选：机器人头像
+	ClearDay   Optional[int]    `json:"clear_day"`   // 可选：清理天数
+	WebhookURL Optional[string] `json:"webhook_url"` // 可选：Webhook地址
+}
+
+// MarshalJSON 只发送已设置的字段
+func (r EditBotRequest) MarshalJSON() ([]byte, error) {
+	return marshalPatch(r)
 }
 
 // DeleteBotRequest 删除机器人请求
@@ -497,11 +560,16 @@ type CreateProjectRequest struct {
 
 // UpdateProjectRequest 更新项目请求
 type UpdateProjectRequest struct {
-	ProjectID     int    `json:"project_id"`     // 必填：项目ID
-	Name          string `json:"name"`           // 必填：项目名称
-	Desc          string `json:"desc"`           // 可选：项目描述
-	ArchiveMethod string `json:"archive_method"` // 可选：归档方式
-	ArchiveDays   int    `json:"archive_days"`   // 可选：自动归档天数
+	ProjectID     int              `json:"project_id"`     // 必填：项目ID
+	Name          string           `json:"name"`           // 必填：项目名称
+	Desc          Optional[string] `json:"desc"`           // 可选：项目描述
+	ArchiveMethod Optional[string] `json:"archive_method"` // 可选：归档方式
+	ArchiveDays   Optional[int]    `json:"archive_days"`   // 可选：自动归档天数
+}
+
+// MarshalJSON 只发送已设置的字段
+func (r UpdateProjectRequest) MarshalJSON() ([]byte, error) {
+	return marshalPatch(r)
 }
 
 // ProjectActionRequest 项目操作请求
@@ -510,6 +578,27 @@ type ProjectActionRequest struct {
 	Type      string `json:"type"`       // 可选：操作类型，如 add、recovery
 }
 
+// ProjectTag 项目任务标签（项目内预设，供任务选用）
+type ProjectTag struct {
+	ID        int    `json:"id"`         // 标签ID
+	ProjectID int    `json:"project_id"` // 项目ID
+	Name      string `json:"name"`       // 名称
+	Color     string `json:"color"`      // 颜色
+	Desc      string `json:"desc"`       // 描述
+	UserID    int    `json:"userid"`     // 创建者ID
+	CreatedAt string `json:"created_at"` // 创建时间
+	UpdatedAt string `json:"updated_at"` // 更新时间
+}
+
+// SaveProjectTagRequest 创建或修改项目标签请求
+type SaveProjectTagRequest struct {
+	ProjectID int    `json:"project_id"`   // 必填：项目ID
+	ID        int    `json:"id,omitempty"` // 可选：标签ID，为空时新建
+	Name      string `json:"name"`         // 必填：名称
+	Color     string `json:"color"`        // 可选：颜色
+	Desc      string `json:"desc"`         // 可选：描述
+}
+
 // ------------------------------------------------------------------------------------------
 // 任务列表相关结构体
 // ------------------------------------------------------------------------------------------
@@ -540,9 +629,14 @@ type CreateColumnRequest struct {
 
 // UpdateColumnRequest 更新列表请求
 type UpdateColumnRequest struct {
-	ColumnID int    `json:"column_id"` // 必填：列表ID
-	Name     string `json:"name"`      // 可选：列表名称
-	Color    string `json:"color"`     // 可选：颜色
+	ColumnID int              `json:"column_id"` // 必填：列表ID
+	Name     Optional[string] `json:"name"`      // 可选：列表名称
+	Color    Optional[string] `json:"color"`     // 可选：颜色
+}
+
+// MarshalJSON 只发送已设置的字段
+func (r UpdateColumnRequest) MarshalJSON() ([]byte, error) {
+	return marshalPatch(r)
 }
 
 // ColumnActionRequest 列表操作请求
@@ -617,13 +711,21 @@ type TaskContent struct {
 
 // GetTaskListRequest 获取任务列表请求
 type GetTaskListRequest struct {
-	ProjectID int    `json:"project_id"` // 可选：项目ID
-	ParentID  int    `json:"parent_id"`  // 可选：主任务ID
-	Archived  string `json:"archived"`   // 可选：归档状态，all、yes、no
-	Deleted   string `json:"deleted"`    // 可选：删除状态，all、yes、no
-	TimeRange string `json:"timerange"`  // 可选：时间范围
-	Page      int    `json:"page"`       // 可选：当前页，默认1
-	PageSize  int    `json:"pagesize"`   // 可选：每页数量，默认100
+	ProjectID int          `json:"project_id"` // 可选：项目ID
+	ParentID  int          `json:"parent_id"`  // 可选：主任务ID
+	Archived  string       `json:"archived"`   // 可选：归档状态，all、yes、no
+	Deleted   string       `json:"deleted"`    // 可选：删除状态，all、yes、no
+	TimeRange string       `json:"timerange"`  // 可选：时间范围
+	Keys      TaskListKeys `json:"keys"`       // 可选：筛选条件
+	Page      int          `json:"page"`       // 可选：当前页，默认1
+	PageSize  int          `json:"pagesize"`   // 可选：每页数量，默认100
+}
+
+// TaskListKeys 任务列表筛选条件，以 keys[...] 查询参数发送
+type TaskListKeys struct {
+	Name   string `json:"name,omitempty"`   // 可选：按名称/描述搜索
+	Status string `json:"status,omitempty"` // 可选：状态，completed、uncompleted、flow-<状态ID>
+	Tag    string `json:"tag,omitempty"`    // 可选：标签
 }
 
 // GetTaskRequest 获取任务信息请求
@@ -662,15 +764,39 @@ type CreateSubTaskRequest struct {
 
 // UpdateTaskRequest 更新任务请求
 type UpdateTaskRequest struct {
-	TaskID     int      `json:"task_id"`     // 必填：任务ID
-	Name       string   `json:"name"`        // 可选：任务名称
-	Content    string   `json:"content"`     // 可选：任务内容
-	Times      []string `json:"times"`       // 可选：计划时间
-	Owner      []int    `json:"owner"`       // 可选：负责人
-	Assist     []int    `json:"assist"`      // 可选：协助人
-	Color      string   `json:"color"`       // 可选：颜色
-	Visibility int      `json:"visibility"`  // 可选：可见性
-	CompleteAt any      `json:"complete_at"` // 可选：完成时间
+	TaskID     int                     `json:"task_id"`      // 必填：任务ID
+	Name       Optional[string]        `json:"name"`         // 可选：任务名称
+	Content    Optional[string]        `json:"content"`      // 可选：任务内容
+	Times      Optional[[]string]      `json:"times"`        // 可选：计划时间 [开始, 结束]，空数组清空
+	Owner      Optional[[]int]         `json:"owner"`        // 可选：负责人
+	Assist     Optional[[]int]         `json:"assist"`       // 可选：协助人
+	Color      Optional[string]        `json:"color"`        // 可选：颜色
+	Visibility Optional[int]           `json:"visibility"`   // 可选：可见性，1 项目人员、2 任务人员、3 指定成员
+	TaskTag    Optional[[]TaskTagItem] `json:"task_tag"`     // 可选：任务标签（整体替换），空数组清空
+	FlowItemID Optional[int]           `json:"flow_item_id"` // 可选：工作流状态ID
+	CompleteAt Optional[any]           `json:"complete_at"`  // 可选：完成时间，true 或时间表示完成，false 表示未完成
+}
+
+// MarshalJSON 只发送已设置的字段
+func (r UpdateTaskRequest) MarshalJSON() ([]byte, error) {
+	return marshalPatch(r)
+}
+
+// TaskTagItem 更新任务时提交的标签
+type TaskTagItem struct {
+	Name  string `json:"name"`            // 名称
+	Color string `json:"color,omitempty"` // 颜色，可为空
+}
+
+// MoveTaskRequest 移动任务请求
+type MoveTaskRequest struct {
+	TaskID     int             `json:"task_id"`              // 必填：任务ID
+	ProjectID  int             `json:"project_id,omitempty"` // 可选：目标项目ID，为空时取任务当前项目
+	ColumnID   int             `json:"column_id,omitempty"`  // 可选：目标列表ID，跨项目移动时必填
+	FlowItemID Optional[int]   `json:"flow_item_id"`         // 可选：工作流状态ID
+	Owner      Optional[[]int] `json:"owner"`                // 可选：负责人
+	Assist     Optional[[]int] `json:"assist"`               // 可选：协助人
+	Completed  Optional[bool]  `json:"completed"`            // 可选：同时标记完成或未完成
 }
 
 // TaskActionRequest 任务操作请求
@@ -691,6 +817,311 @@ type CreateTaskDialogResponse struct {
 	DialogData any `json:"dialog_data"` // 对话数据
 }
 
+// ------------------------------------------------------------------------------------------
+// 工作流相关结构体
+// ------------------------------------------------------------------------------------------
+
+// FlowStatus 工作流状态类型
+type FlowStatus string
+
+const (
+	FlowStatusStart    FlowStatus = "start"    // 开始
+	FlowStatusProgress FlowStatus = "progress" // 进行中
+	FlowStatusTest     FlowStatus = "test"     // 待验收
+	FlowStatusEnd      FlowStatus = "end"      // 结束（任务标记完成）
+)
+
+// ProjectFlow 项目工作流（每个项目最多一个）
+type ProjectFlow struct {
+	ID        int        `json:"id"`                // 工作流ID
+	ProjectID int        `json:"project_id"`        // 项目ID
+	Name      string     `json:"name"`              // 名称
+	CreatedAt string     `json:"created_at"`        // 创建时间
+	UpdatedAt string     `json:"updated_at"`        // 更新时间
+	Items     []FlowItem `json:"project_flow_item"` // 状态列表
+}
+
+// Item 按名称查找状态
+func (f ProjectFlow) Item(name string) (*FlowItem, bool) {
+	for i := range f.Items {
+		if f.Items[i].Name == name {
+			return &f.Items[i], true
+		}
+	}
+	return nil, false
+}
+
+// ItemByID 按ID查找状态
+func (f ProjectFlow) ItemByID(id int) (*FlowItem, bool) {
+	for i := range f.Items {
+		if f.Items[i].ID == id {
+			return &f.Items[i], true
+		}
+	}
+	return nil, false
+}
+
+// FlowItem 工作流状态
+type FlowItem struct {
+	ID        int        `json:"id"`         // 状态ID；保存工作流时新状态填负数作为临时ID
+	ProjectID int        `json:"project_id"` // 项目ID
+	FlowID    int        `json:"flow_id"`    // 工作流ID
+	Name      string     `json:"name"`       // 状态名称
+	Status    FlowStatus `json:"status"`     // 状态类型
+	Turns     []int      `json:"turns"`      // 可流转到的状态ID
+	UserIDs   []int      `json:"userids"`    // 流转到此状态时自动设置的负责人
+	UserType  string     `json:"usertype"`   // 负责人设置方式：add 添加、replace 替换、merge 原负责人转为协助人
+	UserLimit int        `json:"userlimit"`  // 1 表示仅状态负责人可流转到此状态
+	ColumnID  int        `json:"columnid"`   // 流转到此状态时移动到的列表ID，0 不移动
+	Sort      int        `json:"sort"`       // 排序
+}
+
+// CanTurnTo 是否允许从此状态流转到 id
+func (i FlowItem) CanTurnTo(id int) bool {
+	return slices.Contains(i.Turns, id)
+}
+
+// SaveProjectFlowRequest 保存工作流请求：按 Items 整体替换状态，未列出的状态被删除
+type SaveProjectFlowRequest struct {
+	ProjectID int        `json:"project_id"` // 必填：项目ID
+	Items     []FlowItem `json:"flows"`      // 必填：状态列表，Turns 可引用新状态的临时ID
+}
+
+// ------------------------------------------------------------------------------------------
+// 报告相关结构体
+// ------------------------------------------------------------------------------------------
+
+// ReportListKeys 报告列表筛选条件，以 keys[...] 查询参数发送
+type ReportListKeys struct {
+	Key       string   `json:"key,omitempty"`        // 可选：关键词
+	Type      string   `json:"type,omitempty"`       // 可选：类型，weekly、daily、all
+	Status    string   `json:"status,omitempty"`     // 可选：阅读状态，read、unread、all（仅收到的报告）
+	CreatedAt []string `json:"created_at,omitempty"` // 可选：创建时间范围 [开始, 结束]
+}
+
+// Report 工作报告
+type Report struct {
+	ID        int    `json:"id"`         // 报告ID
+	Title     string `json:"title"`      // 标题
+	Type      string `json:"type"`       // 类型：weekly、daily
+	UserID    int    `json:"userid"`     // 汇报人ID
+	Username  string `json:"username"`   // 汇报人名称
+	Sign      string `json:"sign"`       // 周期签名，同一周期重复提交时覆盖
+	Content   string `json:"content"`    // 内容（HTML），列表中可能为空
+	Receives  []int  `json:"receives"`   // 接收人ID列表
+	CreatedAt string `json:"created_at"` // 创建时间
+	UpdatedAt string `json:"updated_at"` // 更新时间
+}
+
+// ReportListRequest 报告列表请求
+type ReportListRequest struct {
+	Keys     ReportListKeys `json:"keys"`               // 可选：筛选条件
+	Page     int            `json:"page,omitempty"`     // 可选：当前页，默认1
+	PageSize int            `json:"pagesize,omitempty"` // 可选：每页数量，默认20
+}
+
+// ReportTemplateRequest 生成报告模板请求
+type ReportTemplateRequest struct {
+	Type   string `json:"type"`   // 类型：weekly、daily
+	Offset int    `json:"offset"` // 可选：周期偏移，0 为当前周期，-1 为上一周期
+}
+
+// ReportTemplate 报告模板
+type ReportTemplate struct {
+	ID      int    `json:"id"`      // 本周期已提交的报告ID，0 表示尚未提交
+	Sign    string `json:"sign"`    // 周期签名，提交时原样带回
+	Title   string `json:"title"`   // 标题
+	Content string `json:"content"` // 内容（HTML，含周期内完成与计划的任务）
+}
+
+// SubmitReportRequest 提交报告请求
+type SubmitReportRequest struct {
+	ID      int    `json:"id,omitempty"`      // 可选：报告ID，修改已提交的报告时填写
+	Type    string `json:"type"`              // 类型：weekly、daily
+	Title   string `json:"title"`             // 标题
+	Content string `json:"content"`           // 内容（HTML）
+	Sign    string `json:"sign,omitempty"`    // 可选：周期签名（来自 ReportTemplate）
+	Receive []int  `json:"receive,omitempty"` // 可选：接收人ID列表
+	Offset  int    `json:"offset"`            // 可选：周期偏移
+}
+
+// SaveReportAnalysisRequest 保存报告 AI 分析请求
+type SaveReportAnalysisRequest struct {
+	ID    int      `json:"id"`              // 报告ID
+	Text  string   `json:"text"`            // 分析内容（Markdown）
+	Model string   `json:"model,omitempty"` // 可选：模型标识
+	Focus []string `json:"focus,omitempty"` // 可选：关注点
+}
+
+// ShareReportsRequest 分享报告请求，对话与成员至少指定其一
+type ShareReportsRequest struct {
+	IDs          []int  `json:"id"`                      // 报告ID列表，最多 20 个
+	DialogIDs    []int  `json:"dialogids,omitempty"`     // 可选：目标对话ID列表
+	UserIDs      []int  `json:"userids,omitempty"`       // 可选：目标成员ID列表
+	LeaveMessage string `json:"leave_message,omitempty"` // 可选：附带留言
+}
+
+// ------------------------------------------------------------------------------------------
+// 文件相关结构体
+// ------------------------------------------------------------------------------------------
+
+// File 文件或文件夹
+type File struct {
+	ID        int    `json:"id"`         // 文件ID
+	PID       int    `json:"pid"`        // 父文件夹ID，0 为根目录
+	Name      string `json:"name"`       // 名称
+	Type      string `json:"type"`       // 类型：folder、document、mind、drawio、word、excel、ppt、file 等
+	Ext       string `json:"ext"`        // 扩展名
+	Size      int64  `json:"size"`       // 大小（字节）
+	UserID    int    `json:"userid"`     // 所有者ID
+	Share     int    `json:"share"`      // 是否共享：0 否、1 是
+	CreatedID int    `json:"created_id"` // 创建者ID
+	CreatedAt string `json:"created_at"` // 创建时间
+	UpdatedAt string `json:"updated_at"` // 更新时间
+}
+
+// IsFolder 是否为文件夹
+func (f File) IsFolder() bool {
+	return f.Type == "folder"
+}
+
+// FileContent 文件内容（在线文档的最新版本）
+type FileContent struct {
+	ID        int    `json:"id"`         // 内容ID
+	FID       int    `json:"fid"`        // 文件ID
+	Content   any    `json:"content"`    // 内容，结构随文件类型变化
+	Text      string `json:"text"`       // 纯文本内容
+	Size      int64  `json:"size"`       // 大小（字节）
+	UserID    int    `json:"userid"`     // 最后编辑者ID
+	CreatedAt string `json:"created_at"` // 创建时间
+	UpdatedAt string `json:"updated_at"` // 更新时间
+}
+
+// FileText 按路径读取的文件文本片段
+type FileText struct {
+	Content string `json:"content"` // 文本内容
+	Offset  int    `json:"offset"`  // 起始偏移
+	Limit   int    `json:"limit"`   // 长度上限
+	Total   int    `json:"total"`   // 文本总长度
+}
+
+// SearchFilesRequest 搜索文件请求
+type SearchFilesRequest struct {
+	Key  string `json:"key"`            // 关键词
+	Take int    `json:"take,omitempty"` // 可选：返回数量，最大 100
+}
+
+// UploadFileRequest 上传文件请求
+type UploadFileRequest struct {
+	PID   int    // 目标文件夹ID，0 为根目录
+	Name  string // 文件名（含扩展名）
+	Cover bool   // 同名文件是否覆盖
+}
+
+// FileLink 文件分享链接
+type FileLink struct {
+	ID  int    `json:"id"`  // 文件ID
+	URL string `json:"url"` // 分享链接
+	Num int    `json:"num"` // 访问次数
+}
+
+// FileShare 文件共享成员
+type FileShare struct {
+	ID         int `json:"id"`         // 共享ID
+	FileID     int `json:"file_id"`    // 文件ID
+	UserID     int `json:"userid"`     // 成员ID，0 表示所有人
+	Permission int `json:"permission"` // 权限：0 只读、1 读写
+}
+
+// FileShareInfo 文件共享信息
+type FileShareInfo struct {
+	ID   int         `json:"id"`   // 文件ID
+	List []FileShare `json:"list"` // 共享成员
+}
+
+// UpdateFileShareRequest 设置文件共享请求
+type UpdateFileShareRequest struct {
+	ID         int   `json:"id"`         // 文件ID
+	UserIDs    []int `json:"userids"`    // 成员ID列表，0 表示所有人
+	Permission int   `json:"permission"` // 权限：0 只读、1 读写、-1 取消共享
+}
+
+// ------------------------------------------------------------------------------------------
+// 搜索相关结构体
+// ------------------------------------------------------------------------------------------
+
+// SearchType 统一搜索的类型
+type SearchType string
+
+// 统一搜索支持的类型
+const (
+	SearchTypeTask    SearchType = "task"    // 任务
+	SearchTypeProject SearchType = "project" // 项目
+	SearchTypeFile    SearchType = "file"    // 文件
+	SearchTypeContact SearchType = "contact" // 联系人
+	SearchTypeMessage SearchType = "message" // 消息
+)
+
+// SearchTypes 全部搜索类型（同分时按此顺序排列）
+var SearchTypes = []SearchType{SearchTypeTask, SearchTypeProject, SearchTypeFile, SearchTypeContact, SearchTypeMessage}
+
+// SearchRequest 统一搜索请求
+type SearchRequest struct {
+	Key         string       // 必填：搜索关键词
+	Types       []SearchType // 可选：搜索类型，为空时搜索全部类型
+	Take        int          // 可选：每类每页数量，默认20，最大50
+	Page        int          // 可选：页码，默认1
+	Concurrency int          // 可选：最大并发请求数，默认3
+}
+
+// SearchTaskHit 任务搜索结果
+type SearchTaskHit struct {
+	ProjectTask
+	Relevance FlexFloat `json:"relevance"` // 相关度
+}
+
+// SearchProjectHit 项目搜索结果
+type SearchProjectHit struct {
+	Project
+	Relevance FlexFloat `json:"relevance"` // 相关度
+}
+
+// SearchFileHit 文件搜索结果
+type SearchFileHit struct {
+	File
+	Relevance FlexFloat `json:"relevance"` // 相关度
+}
+
+// SearchContactHit 联系人搜索结果
+type SearchContactHit struct {
+	UserBasic
+	Relevance FlexFloat `json:"relevance"` // 相关度
+}
+
+// SearchHit 合并排序后的搜索结果
+type SearchHit struct {
+	Type      SearchType `json:"type"`      // 结果类型
+	ID        int        `json:"id"`        // 任务、项目、文件、消息ID或联系人用户ID
+	Title     string     `json:"title"`     // 标题（名称、昵称或消息预览）
+	Relevance float64    `json:"relevance"` // 相关度（接口返回值）
+	Score     float64    `json:"score"`     // 排序分值：相关度按类型内最大值归一化，无相关度时按类型内名次
+	Item      any        `json:"item"`      // 原始结果，如 *SearchTaskHit、*MessageSearchItem
+}
+
+// SearchResult 统一搜索结果
+type SearchResult struct {
+	Key      string               `json:"key"`      // 搜索关键词
+	Page     int                  `json:"page"`     // 页码
+	Tasks    []SearchTaskHit      `json:"tasks"`    // 任务
+	Projects []SearchProjectHit   `json:"projects"` // 项目
+	Files    []SearchFileHit      `json:"files"`    // 文件
+	Contacts []SearchContactHit   `json:"contacts"` // 联系人
+	Messages []MessageSearchItem  `json:"messages"` // 消息
+	Hits     []SearchHit          `json:"hits"`     // 按相关度合并排序的全部结果
+	HasMore  map[SearchType]bool  `json:"has_more"` // 各类型是否可能还有下一页
+	Errors   map[SearchType]error `json:"-"`        // 各类型的请求错误，成功的类型不出现
+}
+
 // ------------------------------------------------------------------------------------------
 // 系统相关结构体
 // ------------------------------------------------------------------------------------------
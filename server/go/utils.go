@@ -2,15 +2,14 @@ package dootask
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"reflect"
 	"slices"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -46,76 +45,50 @@ func NewClient(token string, opts ...ClientOption) *Client {
 	client := &Client{
 		token:     token,
 		server:    "http://nginx",
-		cache:     make(map[string]UserCache),
 		cacheTime: 10 * time.Minute,
+		flight:    &flightGroup{},
 		timeout:   10 * time.Second,
 	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
+	if client.tokens != nil {
+		client.tokens.token = token
+	}
+	if client.cache == nil {
+		client.cache = NewMemoryCache(defaultCacheSize)
+	}
+	client.initTransport()
 
 	return client
 }
 
-// buildURL 构建带查询参数的URL
-func buildURL(baseURL string, params map[string]any) string {
-	if len(params) == 0 {
-		return baseURL
+// WithContext 返回绑定 ctx 的客户端浅拷贝（共享 token、服务器与缓存），
+// 其上发起的所有请求都受 ctx 控制：ctx 取消或超时会中断进行中的 HTTP 调用，
+// 并返回 context.Canceled / context.DeadlineExceeded。
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
 	}
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
 
-	var queryParams []string
-	for key, value := range params {
-		if value == nil {
-			continue
-		}
-
-		switch v := value.(type) {
-		case string:
-			if v != "" {
-				queryParams = append(queryParams, key+"="+url.QueryEscape(v))
-			}
-		case int:
-			queryParams = append(queryParams, key+"="+strconv.Itoa(v))
-		case int64:
-			queryParams = append(queryParams, key+"="+strconv.FormatInt(v, 10))
-		case float64:
-			queryParams = append(queryParams, key+"="+strconv.FormatFloat(v, 'f', -1, 64))
-		case []int:
-			for _, id := range v {
-				queryParams = append(queryParams, key+"[]="+strconv.Itoa(id))
-			}
-		case []string:
-			for _, str := range v {
-				if str != "" {
-					queryParams = append(queryParams, key+"[]="+url.QueryEscape(str))
-				}
-			}
-		case []any:
-			for _, id := range v {
-				switch id := id.(type) {
-				case int:
-					queryParams = append(queryParams, key+"[]="+strconv.Itoa(id))
-				case string:
-					queryParams = append(queryParams, key+"[]="+url.QueryEscape(id))
-				default:
-					queryParams = append(queryParams, key+"[]="+url.QueryEscape(fmt.Sprintf("%v", id)))
-				}
-			}
-		case bool:
-			if v {
-				queryParams = append(queryParams, key+"=1")
-			} else {
-				queryParams = append(queryParams, key+"=0")
-			}
-		default:
-			// 对于其他类型，转换为字符串
-			queryParams = append(queryParams, key+"="+url.QueryEscape(fmt.Sprintf("%v", v)))
-		}
+// Context 返回客户端绑定的 ctx，未绑定时返回 context.Background()
+func (c *Client) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
 	}
+	return context.Background()
+}
 
-	if len(queryParams) == 0 {
-		return baseURL
+// buildURL 构建带查询参数的URL（编码规则见 encodeQuery）
+func buildURL(baseURL string, params any) (string, error) {
+	query, err := encodeQuery(params)
+	if err != nil || query == "" {
+		return baseURL, err
 	}
 
 	separator := "?"
@@ -123,58 +96,51 @@ func buildURL(baseURL string, params map[string]any) string {
 		separator = "&"
 	}
 
-	return baseURL + separator + strings.Join(queryParams, "&")
+	return baseURL + separator + query, nil
 }
 
-// structToMap 将结构体转换为 map[string]any
-func structToMap(data any) (map[string]any, error) {
-	if data == nil {
-		return nil, nil
-	}
-
-	// 如果已经是 map，直接返回
-	if m, ok := data.(map[string]any); ok {
-		return m, nil
-	}
-
-	// 使用 JSON 序列化然后反序列化
-	jsonBytes, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("marshal to json failed: %w", err)
-	}
-
-	var result map[string]any
-	if err := json.Unmarshal(jsonBytes, &result); err != nil {
-		return nil, fmt.Errorf("unmarshal from json failed: %w", err)
-	}
+// NewRequest 创建请求（使用客户端绑定的 ctx，见 WithContext）
+func (c *Client) NewRequest(method, api string, requestData any, responseData any, headers ...map[string]any) error {
+	return c.NewRequestWithContext(c.Context(), method, api, requestData, responseData, headers...)
+}
 
-	return result, nil
+// NewRequestWithContext 创建受 ctx 控制的请求。设置了 TokenSource 时，
+// 返回 ErrUnauthorized 后刷新 token 并重试一次
+func (c *Client) NewRequestWithContext(ctx context.Context, method, api string, requestData any, responseData any, headers ...map[string]any) error {
+	_, err := c.request(ctx, method, api, requestData, responseData, headers...)
+	return err
 }
 
-// NewRequest 创建请求
-func (c *Client) NewRequest(method, api string, requestData any, responseData any, headers ...map[string]any) error {
+// request 发送请求并返回实际使用的 token
+func (c *Client) request(ctx context.Context, method, api string, requestData any, responseData any, headers ...map[string]any) (string, error) {
 	// 验证 responseData 必须是指针（如果不为 nil）
 	if responseData != nil {
 		rv := reflect.ValueOf(responseData)
 		if rv.Kind() != reflect.Ptr || rv.IsNil() {
-			return errors.New("responseData must be a non-nil pointer")
+			return "", errors.New("responseData must be a non-nil pointer")
 		}
 	}
+	return c.authorized(ctx, true, func(token string) error {
+		return c.send(ctx, token, method, api, requestData, responseData, headers...)
+	})
+}
 
+// send 以 token 构建并发送一次请求，解析响应
+func (c *Client) send(ctx context.Context, token, method, api string, requestData any, responseData any, headers ...map[string]any) error {
 	var req *http.Request
 	var err error
 	fullURL := c.server + api
 
-	switch strings.ToUpper(method) {
+	method = strings.ToUpper(method)
+	switch method {
 	case "GET":
-		// GET 请求：将 requestData 作为查询参数
+		// GET 请求：将 requestData（结构体或 map）作为查询参数
 		if requestData != nil {
-			// 如果 requestData 是结构体，则将 requestData 转换为 map[string]any
-			if params, err := structToMap(requestData); err == nil && len(params) > 0 {
-				fullURL = buildURL(fullURL, params)
+			if fullURL, err = buildURL(fullURL, requestData); err != nil {
+				return fmt.Errorf("build query failed: %w", err)
 			}
 		}
-		req, err = http.NewRequest("GET", fullURL, nil)
+		req, err = http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 
 	case "POST", "PUT", "PATCH":
 		// POST/PUT/PATCH 请求：将 requestData 作为 JSON body
@@ -186,7 +152,7 @@ func (c *Client) NewRequest(method, api string, requestData any, responseData an
 			}
 			body = bytes.NewBuffer(jsonData)
 		}
-		req, err = http.NewRequest(method, fullURL, body)
+		req, err = http.NewRequestWithContext(ctx, method, fullURL, body)
 		if err == nil && requestData != nil {
 			req.Header.Set("Content-Type", "application/json")
 		}
@@ -194,11 +160,11 @@ func (c *Client) NewRequest(method, api string, requestData any, responseData an
 	case "DELETE":
 		// DELETE 请求：支持查询参数
 		if requestData != nil {
-			if params, err := structToMap(requestData); err == nil && len(params) > 0 {
-				fullURL = buildURL(fullURL, params)
+			if fullURL, err = buildURL(fullURL, requestData); err != nil {
+				return fmt.Errorf("build query failed: %w", err)
 			}
 		}
-		req, err = http.NewRequest("DELETE", fullURL, nil)
+		req, err = http.NewRequestWithContext(ctx, "DELETE", fullURL, nil)
 
 	default:
 		return fmt.Errorf("unsupported HTTP method: %s", method)
@@ -208,52 +174,65 @@ func (c *Client) NewRequest(method, api string, requestData any, responseData an
 		return fmt.Errorf("create request failed: %w", err)
 	}
 
-	// 设置通用请求头
-	req.Header.Set("Token", c.token)
+	c.setHeaders(req, token, headers...)
+
+	// 发送请求
+	resp, err := c.do(ctx, req, api)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return &TransportError{Method: method, Endpoint: api, Err: err}
+	}
+	defer resp.Body.Close()
+
+	return decodeResponse(ctx, method, api, resp, responseData)
+}
+
+// setHeaders 设置通用请求头与自定义请求头（自定义请求头可覆盖默认头）
+func (c *Client) setHeaders(req *http.Request, token string, headers ...map[string]any) {
+	req.Header.Set("Token", token)
 	req.Header.Set("User-Agent", "DooTask-Go-Client/1.0")
 	if c.version != "" {
 		req.Header.Set("Version", c.version)
 	}
 
-	// 设置请求头（可覆盖上面的默认头）
 	for _, header := range headers {
 		for key, value := range header {
 			req.Header.Set(key, fmt.Sprintf("%v", value))
 		}
 	}
+}
 
-	// 发送请求
-	client := &http.Client{Timeout: c.timeout}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// 检查 HTTP 状态码
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP %d: %s, body: %s", resp.StatusCode, resp.Status, string(bodyBytes))
-	}
-
+// decodeResponse 读取 {ret,msg,data} 响应，业务失败时返回 *APIError，成功时把 data 解析到 responseData
+func decodeResponse(ctx context.Context, method, api string, resp *http.Response, responseData any) error {
 	// 读取响应体
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("read response failed: %w", err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return &TransportError{Method: method, Endpoint: api, Err: fmt.Errorf("read response failed: %w", err)}
 	}
 
 	// 解析响应
 	var apiResp Response[json.RawMessage]
-	if err := json.Unmarshal(bodyBytes, &apiResp); err != nil {
-		return fmt.Errorf("parse response failed: %w", err)
+	parseErr := json.Unmarshal(bodyBytes, &apiResp)
+
+	// 检查 HTTP 状态码（响应体是标准结构时保留 ret/msg/data）
+	if resp.StatusCode != http.StatusOK {
+		if parseErr == nil && apiResp.Msg != "" {
+			return newAPIError(method, api, resp.StatusCode, apiResp.Ret, apiResp.Msg, apiResp.Data)
+		}
+		return newAPIError(method, api, resp.StatusCode, 0, strings.TrimSpace(string(bodyBytes)), nil)
+	}
+	if parseErr != nil {
+		return fmt.Errorf("parse response failed: %w", parseErr)
 	}
 
 	// 检查业务状态
 	if apiResp.Ret != 1 {
-		if apiResp.Msg != "" {
-			return fmt.Errorf("%s", apiResp.Msg)
-		}
-		return fmt.Errorf("API error: %d", apiResp.Ret)
+		return newAPIError(method, api, resp.StatusCode, apiResp.Ret, apiResp.Msg, apiResp.Data)
 	}
 
 	// 如果不需要响应数据，直接返回
@@ -281,38 +260,64 @@ func (c *Client) NewPostRequest(api string, requestData any, responseData any) e
 	return c.NewRequest("POST", api, requestData, responseData)
 }
 
+// NewGetRequestWithContext 创建受 ctx 控制的GET请求
+func (c *Client) NewGetRequestWithContext(ctx context.Context, api string, requestData any, responseData any, headers ...map[string]any) error {
+	return c.NewRequestWithContext(ctx, "GET", api, requestData, responseData, headers...)
+}
+
+// NewPostRequestWithContext 创建受 ctx 控制的POST请求
+func (c *Client) NewPostRequestWithContext(ctx context.Context, api string, requestData any, responseData any) error {
+	return c.NewRequestWithContext(ctx, "POST", api, requestData, responseData)
+}
+
 // ------------------------------------------------------------------------------------------
 // 用户相关接口
 // ------------------------------------------------------------------------------------------
 
-// GetUserInfo 获取用户信息
+// GetUserInfo 获取用户信息（带缓存，同一 token 的并发请求只调用一次接口；
+// 发起请求的调用方取消时，其余调用方以自己的 ctx 重新请求）。
+// 缓存以实际请求所用的 token 为键，TokenSource 刷新 token 后旧 token 的缓存随之清除
 func (c *Client) GetUserInfo(noCache ...bool) (*UserInfo, error) {
+	ctx := c.Context()
+	token, err := c.resolveToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// 检查缓存
-	if cache, ok := c.cache[c.token]; ok {
-		if time.Now().Before(cache.ExpiresAt) && !slices.Contains(noCache, true) {
-			return &cache.User, nil
+	if !slices.Contains(noCache, true) {
+		if user, ok := c.cache.Get(token); ok {
+			return &user, nil
 		}
-		delete(c.cache, c.token)
+	} else {
+		c.cache.Delete(token)
 	}
 
 	// 验证 token
-	var response UserInfo
-	err := c.NewGetRequest("/api/users/info", nil, &response)
+	v, err := c.flight.do(ctx, token, func() (any, error) {
+		var response UserInfo
+		used, err := c.request(ctx, "GET", "/api/users/info", nil, &response)
+		if err != nil {
+			return nil, err
+		}
+
+		// 更新缓存
+		if used != token {
+			c.cache.Delete(token)
+		}
+		c.cache.Set(used, response, c.cacheTime)
+		return response, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// 更新缓存
-	c.cache[c.token] = UserCache{
-		User:      response,
-		ExpiresAt: time.Now().Add(c.cacheTime),
-	}
-
-	// 返回用户信息
-	return &response, nil
+	// 返回用户信息（副本，避免调用方修改影响其它调用）
+	user := v.(UserInfo)
+	return &user, nil
 }
 
-// CheckUserIdentity 检查用户是否具有指定身份
+// CheckUserIdentity 检查用户是否具有指定身份，不具有时返回包装 ErrPermissionDenied 的错误
 func (c *Client) CheckUserIdentity(identity string) (*UserInfo, error) {
 	user, err := c.GetUserInfo()
 	if err != nil {
@@ -320,7 +325,7 @@ func (c *Client) CheckUserIdentity(identity string) (*UserInfo, error) {
 	}
 
 	if !slices.Contains(user.Identity, identity) {
-		return nil, errors.New("insufficient permissions")
+		return nil, fmt.Errorf("user %d lacks identity %q: %w", user.UserID, identity, ErrPermissionDenied)
 	}
 
 	return user, nil
@@ -350,7 +355,7 @@ func (c *Client) GetUsersBasic(userids []int) ([]UserBasic, error) {
 	return response, nil
 }
 
-// GetUserBasic 获取指定用户基础信息（单个用户）
+// GetUserBasic 获取指定用户基础信息（单个用户），用户不存在时返回包装 ErrNotFound 的错误
 func (c *Client) GetUserBasic(userid int) (*UserBasic, error) {
 	users, err := c.GetUsersBasic([]int{userid})
 	if err != nil {
@@ -358,7 +363,7 @@ func (c *Client) GetUserBasic(userid int) (*UserBasic, error) {
 	}
 
 	if len(users) == 0 {
-		return nil, errors.New("用户不存在")
+		return nil, fmt.Errorf("user %d: %w", userid, ErrNotFound)
 	}
 
 	return &users[0], nil
@@ -912,6 +917,36 @@ func (c *Client) DeleteTask(taskID int, deleteType string) error {
 	return c.NewGetRequest("/api/project/task/remove", params, nil)
 }
 
+// MoveTask 移动任务到其他列表或项目，可同时设置工作流状态、负责人与完成状态；
+// 未指定 ProjectID 时取任务当前所在项目（同项目内跨列移动只需 ColumnID）
+func (c *Client) MoveTask(params MoveTaskRequest) (*ProjectTask, error) {
+	if params.ProjectID == 0 {
+		task, err := c.GetTask(GetTaskRequest{TaskID: params.TaskID})
+		if err != nil {
+			return nil, err
+		}
+		params.ProjectID = task.ProjectID
+	}
+	var response ProjectTask
+	err := c.NewGetRequest("/api/project/task/move", params, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CompleteTask 标记任务完成
+func (c *Client) CompleteTask(taskID int) (*ProjectTask, error) {
+	// 服务端要求 complete_at 为日期字符串才标记完成（实际取服务端当前时间）
+	return c.UpdateTask(UpdateTaskRequest{TaskID: taskID, CompleteAt: Set[any](time.Now().Format(time.DateTime))})
+}
+
+// ReopenTask 取消任务完成
+func (c *Client) ReopenTask(taskID int) (*ProjectTask, error) {
+	// complete_at 为 false（非日期）时标记未完成
+	return c.UpdateTask(UpdateTaskRequest{TaskID: taskID, CompleteAt: Set[any](false)})
+}
+
 // ------------------------------------------------------------------------------------------
 // 系统相关接口
 // ------------------------------------------------------------------------------------------
@@ -0,0 +1,299 @@
+// Package webhook 接收 DooTask 机器人 Webhook 推送，把请求解析为事件并提供回复能力。
+//
+// 机器人（见 dootask.Client.CreateBot）配置 WebhookURL 后，主程序会把机器人收到的消息等事件
+// POST 到该地址，请求中附带机器人自己的 token。Handler 用该 token 构造客户端，
+// 因此事件处理函数中的回复以机器人身份发送。
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	dootask "github.com/dootask/tools/server/go"
+)
+
+// EventType 事件类型
+type EventType string
+
+const (
+	EventMessage     EventType = "message"      // 收到消息
+	EventCommand     EventType = "command"      // 收到以 / 开头的命令消息
+	EventDialogOpen  EventType = "dialog_open"  // 用户打开与机器人的会话
+	EventMemberJoin  EventType = "member_join"  // 机器人所在群组有成员加入
+	EventMemberLeave EventType = "member_leave" // 机器人所在群组有成员退出
+)
+
+// maxBodySize 请求体大小上限
+const maxBodySize = 1 << 20
+
+// Payload Webhook 原始推送数据
+type Payload struct {
+	Event      string `json:"event"`       // 事件类型，缺省为 message
+	Text       string `json:"text"`        // 消息文本
+	Token      string `json:"token"`       // 机器人 token
+	DialogID   int    `json:"dialog_id"`   // 对话ID
+	DialogType string `json:"dialog_type"` // 对话类型：user、group
+	MsgID      int    `json:"msg_id"`      // 消息ID
+	MsgUID     int    `json:"msg_uid"`     // 发送者ID
+	Mention    int    `json:"mention"`     // 是否 @ 了机器人
+	BotUID     int    `json:"bot_uid"`     // 机器人用户ID
+	Version    string `json:"version"`     // 主程序版本
+	Extras     string `json:"extras"`      // 附加数据（JSON 字符串）
+}
+
+// Sender 消息发送者
+type Sender struct {
+	UserID int // 用户ID
+}
+
+// Dialog 事件所在对话
+type Dialog struct {
+	ID   int    // 对话ID
+	Type string // 对话类型：user、group
+}
+
+// IsGroup 是否群聊
+func (d Dialog) IsGroup() bool {
+	return d.Type == "group"
+}
+
+// Message 触发事件的消息
+type Message struct {
+	ID      int    // 消息ID
+	Text    string // 消息文本
+	AIText  string // 转换为 AI 对话格式后的文本（开启 WithAIConversion 时）
+	Mention bool   // 是否 @ 了机器人
+}
+
+// Event Webhook 事件
+type Event struct {
+	Type      EventType      // 事件类型
+	Sender    Sender         // 发送者
+	Dialog    Dialog         // 所在对话
+	Message   Message        // 消息
+	Command   string         // 命令名，如 /deploy（EventCommand）
+	Args      []string       // 命令参数（EventCommand）
+	BotUserID int            // 机器人用户ID
+	Version   string         // 主程序版本
+	Extras    map[string]any // 附加数据
+	Payload   Payload        // 原始推送数据
+
+	client *dootask.Client
+}
+
+// Client 返回以机器人身份调用接口的客户端（已绑定请求 ctx）
+func (e *Event) Client() *dootask.Client {
+	return e.client
+}
+
+// Reply 向事件所在对话回复 Markdown 文本，并引用触发事件的消息
+func (e *Event) Reply(text string) error {
+	return e.ReplyMessage(dootask.SendMessageRequest{Text: text, ReplyID: e.Message.ID})
+}
+
+// ReplyMessage 向事件所在对话发送消息，DialogID 固定为事件所在对话
+func (e *Event) ReplyMessage(message dootask.SendMessageRequest) error {
+	if e.client == nil {
+		return errors.New("webhook: event has no client")
+	}
+	message.DialogID = e.Dialog.ID
+	return e.client.SendMessage(message)
+}
+
+// HandlerFunc 事件处理函数
+type HandlerFunc func(ctx context.Context, e *Event) error
+
+// Option Handler 选项
+type Option func(*Handler)
+
+// WithClientOptions 设置构造机器人客户端时使用的选项（如 WithServer）
+func WithClientOptions(opts ...dootask.ClientOption) Option {
+	return func(h *Handler) {
+		h.clientOpts = append(h.clientOpts, opts...)
+	}
+}
+
+// WithClient 使用固定客户端处理所有事件，而不是按推送中的机器人 token 构造
+func WithClient(client *dootask.Client) Option {
+	return func(h *Handler) {
+		h.client = client
+	}
+}
+
+// WithAIConversion 处理前调用 ConvertWebhookMessageToAI 转换消息，结果写入 Message.AIText
+func WithAIConversion() Option {
+	return func(h *Handler) {
+		h.convertToAI = true
+	}
+}
+
+// WithErrorHandler 设置处理失败时的回调，缺省返回 500 与错误信息
+func WithErrorHandler(fn func(w http.ResponseWriter, r *http.Request, err error)) Option {
+	return func(h *Handler) {
+		h.onError = fn
+	}
+}
+
+// Handler 接收 Webhook 推送的 http.Handler
+type Handler struct {
+	fn          HandlerFunc
+	client      *dootask.Client
+	clientOpts  []dootask.ClientOption
+	convertToAI bool
+	onError     func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// NewHandler 创建 Webhook Handler
+func NewHandler(fn HandlerFunc, opts ...Option) *Handler {
+	h := &Handler{fn: fn}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ServeHTTP 实现 http.Handler 接口
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	e, err := ParseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	e.client = h.clientFor(e.Payload.Token).WithContext(ctx)
+
+	if h.convertToAI && e.Message.Text != "" {
+		resp, err := e.client.ConvertWebhookMessageToAI(dootask.ConvertWebhookMessageRequest{Msg: e.Message.Text})
+		if err != nil {
+			h.fail(w, r, fmt.Errorf("webhook: convert message failed: %w", err))
+			return
+		}
+		e.Message.AIText = resp.Msg
+	}
+
+	if err := h.fn(ctx, e); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"ret":1,"msg":"ok"}`))
+}
+
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if h.onError != nil {
+		h.onError(w, r, err)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// clientFor 返回机器人 token 对应的客户端。推送中的 token 未经校验，不缓存客户端，
+// 每次请求新建（连接由共享的传输层复用）
+func (h *Handler) clientFor(token string) *dootask.Client {
+	if h.client != nil {
+		return h.client
+	}
+	return dootask.NewClient(token, h.clientOpts...)
+}
+
+// ParseRequest 解析 Webhook 请求，支持 application/x-www-form-urlencoded、multipart/form-data 与 JSON
+func ParseRequest(r *http.Request) (*Event, error) {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxBodySize)
+
+	var p Payload
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var raw map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			return nil, fmt.Errorf("webhook: invalid json payload: %w", err)
+		}
+		p = payloadFromValues(func(key string) string {
+			switch v := raw[key].(type) {
+			case nil:
+				return ""
+			case string:
+				return v
+			case float64:
+				return strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				if v {
+					return "1"
+				}
+				return "0"
+			default:
+				b, _ := json.Marshal(v)
+				return string(b)
+			}
+		})
+	} else {
+		if err := r.ParseMultipartForm(maxBodySize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return nil, fmt.Errorf("webhook: invalid form payload: %w", err)
+		}
+		p = payloadFromValues(r.PostForm.Get)
+	}
+
+	if p.DialogID == 0 {
+		return nil, errors.New("webhook: missing dialog_id")
+	}
+	return NewEvent(p), nil
+}
+
+// payloadFromValues 从表单或 JSON 字段构造 Payload（主程序推送的数字字段可能是字符串）
+func payloadFromValues(get func(key string) string) Payload {
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(strings.TrimSpace(get(key)))
+		return n
+	}
+	return Payload{
+		Event:      get("event"),
+		Text:       get("text"),
+		Token:      get("token"),
+		DialogID:   atoi("dialog_id"),
+		DialogType: get("dialog_type"),
+		MsgID:      atoi("msg_id"),
+		MsgUID:     atoi("msg_uid"),
+		Mention:    atoi("mention"),
+		BotUID:     atoi("bot_uid"),
+		Version:    get("version"),
+		Extras:     get("extras"),
+	}
+}
+
+// NewEvent 由原始推送数据构造事件（不含客户端，Reply 不可用）
+func NewEvent(p Payload) *Event {
+	e := &Event{
+		Type:      EventType(p.Event),
+		Sender:    Sender{UserID: p.MsgUID},
+		Dialog:    Dialog{ID: p.DialogID, Type: p.DialogType},
+		Message:   Message{ID: p.MsgID, Text: p.Text, Mention: p.Mention == 1},
+		BotUserID: p.BotUID,
+		Version:   p.Version,
+		Payload:   p,
+	}
+	if e.Type == "" {
+		e.Type = EventMessage
+	}
+	if p.Extras != "" {
+		_ = json.Unmarshal([]byte(p.Extras), &e.Extras)
+	}
+	if e.Type == EventMessage {
+		if fields := strings.Fields(p.Text); len(fields) > 0 && strings.HasPrefix(fields[0], "/") {
+			e.Type = EventCommand
+			e.Command = fields[0]
+			e.Args = fields[1:]
+		}
+	}
+	return e
+}
@@ -0,0 +1,267 @@
+package dootask
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------------------------
+// WebSocket 连接（RFC 6455 客户端最小实现，仅供 Realtime 使用）
+// ------------------------------------------------------------------------------------------
+
+// WebSocket 帧类型
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// wsMaxMessageSize 单条消息大小上限
+const wsMaxMessageSize = 16 << 20
+
+// wsGUID 握手时计算 Sec-WebSocket-Accept 使用的固定串
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// errWebSocketClosed 对端发送了关闭帧
+var errWebSocketClosed = errors.New("websocket: closed by peer")
+
+// wsConn WebSocket 客户端连接，读操作只能在单个 goroutine 中进行，写操作并发安全
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	wmu  sync.Mutex
+}
+
+// dialWebSocket 建立 WebSocket 连接；握手失败且服务端返回非 101 时返回 *APIError
+func dialWebSocket(ctx context.Context, rawURL string, header http.Header, timeout time.Duration) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse websocket url failed: %w", err)
+	}
+	var useTLS bool
+	switch u.Scheme {
+	case "ws", "http":
+	case "wss", "https":
+		useTLS = true
+	default:
+		return nil, fmt.Errorf("unsupported websocket scheme: %s", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		if useTLS {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var conn net.Conn
+	if useTLS {
+		d := &tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}
+		conn, err = d.DialContext(ctx, "tcp", host)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 握手期间 ctx 取消时中断读写
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Unix(1, 0)) })
+	ws, err := handshakeWebSocket(conn, u, header)
+	if !stop() || err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return ws, nil
+}
+
+// handshakeWebSocket 发送升级请求并校验响应
+func handshakeWebSocket(conn net.Conn, u *url.URL, header http.Header) (*wsConn, error) {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: u.EscapedPath(), RawQuery: u.RawQuery},
+		Host:       u.Host,
+		Header:     header.Clone(),
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	if req.URL.Path == "" {
+		req.URL.Path = "/"
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, newAPIError(http.MethodGet, u.Path, resp.StatusCode, 0, strings.TrimSpace(string(body)), nil)
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, errors.New("websocket: invalid Sec-WebSocket-Accept")
+	}
+	return &wsConn{conn: conn, br: br}, nil
+}
+
+// readMessage 读取一条完整的文本或二进制消息，自动应答 ping 并合并分片
+func (ws *wsConn) readMessage() (byte, []byte, error) {
+	var msgOp byte
+	var msg []byte
+	for {
+		fin, op, payload, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case wsOpPing:
+			if err := ws.writeFrame(wsOpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			ws.writeFrame(wsOpClose, payload)
+			return 0, nil, errWebSocketClosed
+		case wsOpText, wsOpBinary:
+			msgOp, msg = op, payload
+		case wsOpContinuation:
+			if msgOp == 0 {
+				return 0, nil, errors.New("websocket: unexpected continuation frame")
+			}
+			if len(msg)+len(payload) > wsMaxMessageSize {
+				return 0, nil, errors.New("websocket: message too large")
+			}
+			msg = append(msg, payload...)
+		default:
+			return 0, nil, fmt.Errorf("websocket: unknown opcode %d", op)
+		}
+		if fin {
+			return msgOp, msg, nil
+		}
+	}
+}
+
+// readFrame 读取单个帧（服务端帧不带掩码）
+func (ws *wsConn) readFrame() (bool, byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(ws.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := head[0]&0x80 != 0
+	op := head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(ws.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(ws.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > wsMaxMessageSize {
+		return false, 0, nil, errors.New("websocket: frame too large")
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(ws.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(ws.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame 写入单个帧（客户端帧必须带掩码）
+func (ws *wsConn) writeFrame(op byte, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|op)
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	var mask [4]byte
+	rand.Read(mask[:])
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+	ws.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := ws.conn.Write(frame)
+	return err
+}
+
+// close 发送关闭帧并关闭连接
+func (ws *wsConn) close() error {
+	ws.writeFrame(wsOpClose, binary.BigEndian.AppendUint16(nil, 1000))
+	return ws.conn.Close()
+}